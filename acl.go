@@ -60,10 +60,14 @@ type getACLBuilder struct {
 }
 
 func (b *getACLBuilder) ForPath(givenPath string) ([]zk.ACL, error) {
-	adjustedPath := b.client.fixForNamespace(givenPath, false)
+	adjustedPath, err := b.client.fixForNamespace(givenPath, false)
+
+	if err != nil {
+		return nil, err
+	}
 
 	if b.backgrounding.inBackground {
-		go b.pathInBackground(adjustedPath, givenPath)
+		b.client.client.inBackground(func() { b.pathInBackground(adjustedPath, givenPath) })
 
 		return nil, nil
 	} else {
@@ -108,6 +112,8 @@ func (b *getACLBuilder) pathInForeground(path string) ([]zk.ACL, error) {
 			acls, stat, err := conn.GetACL(path)
 
 			if stat != nil {
+				b.client.client.recordStat(stat)
+
 				if b.stat != nil {
 					*b.stat = *stat
 				} else {
@@ -162,10 +168,14 @@ type setACLBuilder struct {
 }
 
 func (b *setACLBuilder) ForPath(givenPath string) (*zk.Stat, error) {
-	adjustedPath := b.client.fixForNamespace(givenPath, false)
+	adjustedPath, err := b.client.fixForNamespace(givenPath, false)
+
+	if err != nil {
+		return nil, err
+	}
 
 	if b.backgrounding.inBackground {
-		go b.pathInBackground(adjustedPath, givenPath)
+		b.client.client.inBackground(func() { b.pathInBackground(adjustedPath, givenPath) })
 
 		return nil, nil
 	} else {
@@ -201,6 +211,10 @@ func (b *setACLBuilder) pathInBackground(path string, givenPath string) {
 }
 
 func (b *setACLBuilder) pathInForeground(path string) (*zk.Stat, error) {
+	if err := b.client.ensureWritable(); err != nil {
+		return nil, err
+	}
+
 	zkClient := b.client.ZookeeperClient()
 
 	result, err := zkClient.NewRetryLoop().CallWithRetry(func() (interface{}, error) {
@@ -213,6 +227,8 @@ func (b *setACLBuilder) pathInForeground(path string) (*zk.Stat, error) {
 
 	stat, _ := result.(*zk.Stat)
 
+	b.client.client.recordStat(stat)
+
 	return stat, err
 }
 
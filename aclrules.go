@@ -0,0 +1,361 @@
+package curator
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"path"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"github.com/yxdrlitao/go-zookeeper/zk"
+	"gopkg.in/yaml.v3"
+)
+
+// A rule of RuleACLProvider, which applies the ACL list to the nodes matched by the path pattern.
+//
+// The pattern is either a path prefix, e.g. "/app/config" matches "/app/config" and all its descendants,
+// or a glob where "*" matches one segment (or part of it, e.g. "lock-*") and "**" matches any segments,
+// e.g. "/app/*/secrets" or "/**/locks". A glob matches the nodes and all their descendants as well.
+type ACLRule struct {
+	Path string
+	ACL  []zk.ACL
+
+	segments []string
+	literals int
+}
+
+func NewACLRule(pattern string, acls ...zk.ACL) ACLRule {
+	rule := ACLRule{Path: pattern, ACL: acls}
+
+	for _, segment := range strings.Split(strings.Trim(pattern, PATH_SEPARATOR), PATH_SEPARATOR) {
+		if len(segment) == 0 {
+			continue
+		}
+
+		rule.segments = append(rule.segments, segment)
+
+		if !strings.ContainsAny(segment, "*?[") {
+			rule.literals++
+		}
+	}
+
+	return rule
+}
+
+// Return the number of segments of the path matched by the rule, or -1 if not matched
+func (r *ACLRule) match(segments []string) int {
+	return matchSegments(r.segments, segments)
+}
+
+func matchSegments(patterns, segments []string) int {
+	if len(patterns) == 0 {
+		return 0
+	}
+
+	if patterns[0] == "**" {
+		matched := -1
+
+		for i := 0; i <= len(segments); i++ {
+			if n := matchSegments(patterns[1:], segments[i:]); n >= 0 && i+n > matched {
+				matched = i + n
+			}
+		}
+
+		return matched
+	}
+
+	if len(segments) == 0 {
+		return -1
+	}
+
+	if ok, err := path.Match(patterns[0], segments[0]); err != nil || !ok {
+		return -1
+	}
+
+	if n := matchSegments(patterns[1:], segments[1:]); n >= 0 {
+		return n + 1
+	}
+
+	return -1
+}
+
+// ACLProvider built from rules, the most specific rule matching a path wins.
+//
+// A rule is more specific when it matches more segments of the path,
+// then when it has more literal segments, and at last when it is declared first.
+type RuleACLProvider struct {
+	defaultAcls []zk.ACL
+	rules       []ACLRule
+	namespace   string
+}
+
+func NewRuleACLProvider(defaultAcls []zk.ACL, rules ...ACLRule) *RuleACLProvider {
+	if defaultAcls == nil {
+		defaultAcls = OPEN_ACL_UNSAFE
+	}
+
+	p := &RuleACLProvider{defaultAcls: defaultAcls}
+
+	for _, rule := range rules {
+		p.rules = append(p.rules, NewACLRule(rule.Path, rule.ACL...))
+	}
+
+	return p
+}
+
+// Make the rules relative to the namespace, the paths outside of the namespace get the default ACL list
+func (p *RuleACLProvider) WithNamespace(namespace string) *RuleACLProvider {
+	p.namespace = strings.Trim(namespace, PATH_SEPARATOR)
+
+	return p
+}
+
+func (p *RuleACLProvider) GetDefaultAcl() []zk.ACL {
+	return p.defaultAcls
+}
+
+func (p *RuleACLProvider) GetAclForPath(givenPath string) []zk.ACL {
+	if rule := p.ruleForPath(givenPath); rule != nil {
+		return rule.ACL
+	}
+
+	return p.defaultAcls
+}
+
+func (p *RuleACLProvider) ruleForPath(givenPath string) *ACLRule {
+	if len(p.namespace) > 0 {
+		prefix := JoinPath(p.namespace)
+
+		if givenPath == prefix {
+			givenPath = PATH_SEPARATOR
+		} else if strings.HasPrefix(givenPath, prefix+PATH_SEPARATOR) {
+			givenPath = givenPath[len(prefix):]
+		} else {
+			return nil
+		}
+	}
+
+	var segments []string
+
+	if trimmed := strings.Trim(givenPath, PATH_SEPARATOR); len(trimmed) > 0 {
+		segments = strings.Split(trimmed, PATH_SEPARATOR)
+	}
+
+	var best *ACLRule
+	var bestMatched int
+
+	for i := range p.rules {
+		rule := &p.rules[i]
+
+		if matched := rule.match(segments); matched < 0 {
+			continue
+		} else if best == nil || matched > bestMatched || (matched == bestMatched && rule.literals > best.literals) {
+			best, bestMatched = rule, matched
+		}
+	}
+
+	return best
+}
+
+// The file format of the ACL rules
+type aclRulesConfig struct {
+	Namespace string          `json:"namespace" yaml:"namespace"`
+	Default   []aclEntry      `json:"default" yaml:"default"`
+	Rules     []aclRuleConfig `json:"rules" yaml:"rules"`
+}
+
+type aclRuleConfig struct {
+	Path string     `json:"path" yaml:"path"`
+	ACL  []aclEntry `json:"acl" yaml:"acl"`
+}
+
+type aclEntry struct {
+	Scheme string `json:"scheme" yaml:"scheme"`
+	ID     string `json:"id" yaml:"id"`
+	Perms  string `json:"perms" yaml:"perms"` // any of "rwcda", "all" or the numeric value
+}
+
+func (e *aclEntry) toACL() (zk.ACL, error) {
+	perms, err := ParsePerms(e.Perms)
+
+	if err != nil {
+		return zk.ACL{}, err
+	}
+
+	if len(e.Scheme) == 0 {
+		return zk.ACL{}, fmt.Errorf("missing scheme of ACL")
+	}
+
+	return zk.ACL{Perms: perms, Scheme: e.Scheme, ID: e.ID}, nil
+}
+
+// Parse the permissions in the form of "rwcda", "all" or the numeric value
+func ParsePerms(s string) (int32, error) {
+	if s == "all" {
+		return zk.PermAll, nil
+	}
+
+	if n, err := strconv.ParseInt(s, 10, 32); err == nil {
+		return int32(n), nil
+	}
+
+	var perms int32
+
+	for _, c := range s {
+		switch c {
+		case 'r':
+			perms |= zk.PermRead
+		case 'w':
+			perms |= zk.PermWrite
+		case 'c':
+			perms |= zk.PermCreate
+		case 'd':
+			perms |= zk.PermDelete
+		case 'a':
+			perms |= zk.PermAdmin
+		default:
+			return 0, fmt.Errorf("invalid permission `%c` in `%s`", c, s)
+		}
+	}
+
+	return perms, nil
+}
+
+// Load the rules from a YAML (.yaml or .yml) or JSON file, e.g.
+//
+//	namespace: app
+//	default:
+//	  - {scheme: world, id: anyone, perms: r}
+//	rules:
+//	  - path: /secrets
+//	    acl:
+//	      - {scheme: digest, id: "admin:base64(sha1(admin:password))", perms: all}
+func LoadRuleACLProvider(filename string) (*RuleACLProvider, error) {
+	content, err := ioutil.ReadFile(filename)
+
+	if err != nil {
+		return nil, err
+	}
+
+	var config aclRulesConfig
+
+	switch strings.ToLower(filepath.Ext(filename)) {
+	case ".yaml", ".yml":
+		err = yaml.Unmarshal(content, &config)
+	default:
+		err = json.Unmarshal(content, &config)
+	}
+
+	if err != nil {
+		return nil, fmt.Errorf("fail to parse ACL rules from %s, %s", filename, err)
+	}
+
+	toACLs := func(entries []aclEntry) ([]zk.ACL, error) {
+		var acls []zk.ACL
+
+		for _, entry := range entries {
+			if acl, err := entry.toACL(); err != nil {
+				return nil, err
+			} else {
+				acls = append(acls, acl)
+			}
+		}
+
+		return acls, nil
+	}
+
+	defaultAcls, err := toACLs(config.Default)
+
+	if err != nil {
+		return nil, fmt.Errorf("invalid default ACL in %s, %s", filename, err)
+	}
+
+	var rules []ACLRule
+
+	for _, rule := range config.Rules {
+		if acls, err := toACLs(rule.ACL); err != nil {
+			return nil, fmt.Errorf("invalid ACL of rule `%s` in %s, %s", rule.Path, filename, err)
+		} else if len(acls) == 0 {
+			return nil, fmt.Errorf("missing ACL of rule `%s` in %s", rule.Path, filename)
+		} else {
+			rules = append(rules, NewACLRule(rule.Path, acls...))
+		}
+	}
+
+	return NewRuleACLProvider(defaultAcls, rules...).WithNamespace(config.Namespace), nil
+}
+
+// A node whose actual ACL list diverges from the policy
+type ACLViolation struct {
+	Path     string   // the path relative to the namespace of the client
+	Expected []zk.ACL // the ACL list required by the policy
+	Actual   []zk.ACL // the ACL list of the node
+}
+
+// Walk the subtree and report the nodes whose actual ACL list diverges from the policy.
+//
+// The "auth" scheme is expanded by the server when a node is created,
+// so an expected "auth" entry matches any authenticated entry with the same permissions.
+func AuditACLs(client CuratorFramework, root string, policy ACLProvider) ([]ACLViolation, error) {
+	var violations []ACLViolation
+
+	err := Walk(client, root, func(node *WalkNode) error {
+		nodePath := node.Path
+
+		actual, err := client.GetACL().ForPath(nodePath)
+
+		if err == zk.ErrNoNode {
+			return ErrSkipChildren
+		} else if err != nil {
+			return err
+		}
+
+		fullPath, _ := FixForNamespace(client.Namespace(), nodePath, false)
+
+		expected := policy.GetAclForPath(fullPath)
+
+		if len(expected) == 0 {
+			expected = policy.GetDefaultAcl()
+		}
+
+		if !aclsMatch(expected, actual) {
+			violations = append(violations, ACLViolation{nodePath, expected, actual})
+		}
+
+		return nil
+	}, nil)
+
+	return violations, err
+}
+
+func aclsMatch(expected, actual []zk.ACL) bool {
+	if len(expected) != len(actual) {
+		return false
+	}
+
+	used := make([]bool, len(actual))
+
+	for _, e := range expected {
+		found := false
+
+		for i, a := range actual {
+			if used[i] || e.Perms != a.Perms {
+				continue
+			}
+
+			if e.Scheme == a.Scheme && e.ID == a.ID || e.Scheme == "auth" && a.Scheme != "world" && a.Scheme != "ip" {
+				used[i], found = true, true
+
+				break
+			}
+		}
+
+		if !found {
+			return false
+		}
+	}
+
+	return true
+}
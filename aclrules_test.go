@@ -0,0 +1,210 @@
+package curator
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/suite"
+	"github.com/yxdrlitao/go-zookeeper/zk"
+)
+
+var (
+	adminACL = []zk.ACL{{Perms: zk.PermAll, Scheme: "digest", ID: "admin:hash"}}
+	lockACL  = []zk.ACL{{Perms: zk.PermRead | zk.PermCreate | zk.PermDelete, Scheme: "world", ID: "anyone"}}
+	appACL   = []zk.ACL{{Perms: zk.PermRead | zk.PermWrite, Scheme: "ip", ID: "10.0.0.0/8"}}
+)
+
+func TestRuleACLProvider(t *testing.T) {
+	p := NewRuleACLProvider(READ_ACL_UNSAFE,
+		NewACLRule("/app", appACL...),
+		NewACLRule("/app/secrets", adminACL...),
+		NewACLRule("/app/*/locks", lockACL...),
+		NewACLRule("/**/secrets", OPEN_ACL_UNSAFE...),
+	)
+
+	assert.Equal(t, READ_ACL_UNSAFE, p.GetDefaultAcl())
+	assert.Equal(t, READ_ACL_UNSAFE, p.GetAclForPath("/"))
+	assert.Equal(t, READ_ACL_UNSAFE, p.GetAclForPath("/application"))
+	assert.Equal(t, appACL, p.GetAclForPath("/app"))
+	assert.Equal(t, appACL, p.GetAclForPath("/app/config"))
+	assert.Equal(t, adminACL, p.GetAclForPath("/app/secrets"), "literal segments win")
+	assert.Equal(t, adminACL, p.GetAclForPath("/app/secrets/db"), "prefix applies to descendants")
+	assert.Equal(t, lockACL, p.GetAclForPath("/app/service/locks"))
+	assert.Equal(t, lockACL, p.GetAclForPath("/app/service/locks/lock-0001"))
+	assert.Equal(t, appACL, p.GetAclForPath("/app/service/leader"))
+	assert.Equal(t, OPEN_ACL_UNSAFE, p.GetAclForPath("/other/secrets"))
+	assert.Equal(t, OPEN_ACL_UNSAFE, p.GetAclForPath("/app/service/secrets"), "more matched segments win")
+
+	// the first rule wins when the rules are equally specific
+	p = NewRuleACLProvider(nil, NewACLRule("/app/lock-*", lockACL...), NewACLRule("/app/*", appACL...))
+
+	assert.Equal(t, OPEN_ACL_UNSAFE, p.GetDefaultAcl())
+	assert.Equal(t, lockACL, p.GetAclForPath("/app/lock-1"))
+	assert.Equal(t, appACL, p.GetAclForPath("/app/node"))
+}
+
+func TestRuleACLProviderNamespace(t *testing.T) {
+	p := NewRuleACLProvider(READ_ACL_UNSAFE, ACLRule{Path: "/", ACL: appACL}, ACLRule{Path: "/secrets", ACL: adminACL}).WithNamespace("app")
+
+	assert.Equal(t, appACL, p.GetAclForPath("/app"))
+	assert.Equal(t, appACL, p.GetAclForPath("/app/config"))
+	assert.Equal(t, adminACL, p.GetAclForPath("/app/secrets/db"))
+	assert.Equal(t, READ_ACL_UNSAFE, p.GetAclForPath("/secrets"))
+	assert.Equal(t, READ_ACL_UNSAFE, p.GetAclForPath("/application"))
+}
+
+func TestParsePerms(t *testing.T) {
+	for s, perms := range map[string]int32{
+		"all":   zk.PermAll,
+		"rwcda": zk.PermAll,
+		"r":     zk.PermRead,
+		"cd":    zk.PermCreate | zk.PermDelete,
+		"3":     zk.PermRead | zk.PermWrite,
+		"":      0,
+	} {
+		p, err := ParsePerms(s)
+
+		assert.NoError(t, err)
+		assert.Equal(t, perms, p, s)
+	}
+
+	_, err := ParsePerms("rx")
+
+	assert.Error(t, err)
+}
+
+func TestLoadRuleACLProvider(t *testing.T) {
+	dir, err := ioutil.TempDir("", "acl")
+
+	assert.NoError(t, err)
+
+	defer os.RemoveAll(dir)
+
+	yamlFile := filepath.Join(dir, "acl.yaml")
+
+	assert.NoError(t, ioutil.WriteFile(yamlFile, []byte(`
+namespace: app
+default:
+  - {scheme: world, id: anyone, perms: r}
+rules:
+  - path: /secrets
+    acl:
+      - {scheme: digest, id: "admin:hash", perms: all}
+`), 0644))
+
+	p, err := LoadRuleACLProvider(yamlFile)
+
+	assert.NoError(t, err)
+	assert.Equal(t, READ_ACL_UNSAFE, p.GetDefaultAcl())
+	assert.Equal(t, adminACL, p.GetAclForPath("/app/secrets"))
+	assert.Equal(t, READ_ACL_UNSAFE, p.GetAclForPath("/app/config"))
+
+	jsonFile := filepath.Join(dir, "acl.json")
+
+	assert.NoError(t, ioutil.WriteFile(jsonFile, []byte(`{
+		"rules": [{"path": "/app/*/locks", "acl": [{"scheme": "world", "id": "anyone", "perms": "rcd"}]}]
+	}`), 0644))
+
+	p, err = LoadRuleACLProvider(jsonFile)
+
+	assert.NoError(t, err)
+	assert.Equal(t, OPEN_ACL_UNSAFE, p.GetDefaultAcl())
+	assert.Equal(t, lockACL, p.GetAclForPath("/app/service/locks"))
+
+	assert.NoError(t, ioutil.WriteFile(jsonFile, []byte(`{"rules": [{"path": "/app", "acl": [{"scheme": "world", "id": "anyone", "perms": "x"}]}]}`), 0644))
+
+	_, err = LoadRuleACLProvider(jsonFile)
+
+	assert.Error(t, err)
+
+	assert.NoError(t, ioutil.WriteFile(jsonFile, []byte(`{"rules": [{"path": "/app"}]}`), 0644))
+
+	_, err = LoadRuleACLProvider(jsonFile)
+
+	assert.Error(t, err)
+
+	_, err = LoadRuleACLProvider(filepath.Join(dir, "missing.json"))
+
+	assert.True(t, os.IsNotExist(err))
+}
+
+type RuleACLProviderTestSuite struct {
+	mockContainerTestSuite
+}
+
+func TestRuleACLProviderWithClient(t *testing.T) {
+	suite.Run(t, new(RuleACLProviderTestSuite))
+}
+
+func (s *RuleACLProviderTestSuite) TestCreate() {
+	policy := NewRuleACLProvider(READ_ACL_UNSAFE, NewACLRule("/app/secrets", adminACL...))
+
+	s.WithPrepare(func(builder *CuratorFrameworkBuilder) {
+		builder.AclProvider = policy
+	}, func(client CuratorFramework, conn *mockConn, data []byte) {
+		conn.On("Create", "/app/secrets/db", data, int32(PERSISTENT), adminACL).Return("", zk.ErrNoNode).Once()
+		conn.On("Exists", "/app").Return(false, nil, nil).Once()
+		conn.On("Create", "/app", []byte{}, int32(PERSISTENT), READ_ACL_UNSAFE).Return("/app", nil).Once()
+		conn.On("Exists", "/app/secrets").Return(false, nil, nil).Once()
+		conn.On("Create", "/app/secrets", []byte{}, int32(PERSISTENT), adminACL).Return("/app/secrets", nil).Once()
+		conn.On("Create", "/app/secrets/db", data, int32(PERSISTENT), adminACL).Return("/app/secrets/db", nil).Once()
+
+		path, err := client.Create().CreatingParentsIfNeeded().ForPathWithData("/app/secrets/db", data)
+
+		s.Equal("/app/secrets/db", path)
+		s.NoError(err)
+	})
+}
+
+func (s *RuleACLProviderTestSuite) TestAudit() {
+	policy := NewRuleACLProvider(READ_ACL_UNSAFE, NewACLRule("/secrets", CREATOR_ALL_ACL...)).WithNamespace("app")
+
+	s.WithNamespace("app", func(client CuratorFramework, conn *mockConn, stat *zk.Stat) {
+		conn.On("Exists", "/app").Return(true, nil, nil).Once()
+		conn.On("GetACL", "/app/config").Return(READ_ACL_UNSAFE, stat, nil).Once()
+		conn.On("Children", "/app/config").Return([]string{"b", "a"}, stat, nil).Once()
+		conn.On("GetACL", "/app/config/a").Return(OPEN_ACL_UNSAFE, stat, nil).Once()
+		conn.On("Children", "/app/config/a").Return([]string{}, stat, nil).Once()
+		conn.On("Children", "/app/config/b").Return(nil, nil, zk.ErrNoNode).Once()
+
+		violations, err := AuditACLs(client, "/config", policy)
+
+		s.NoError(err)
+		s.Equal([]ACLViolation{{"/config/a", READ_ACL_UNSAFE, OPEN_ACL_UNSAFE}}, violations)
+
+		conn.On("GetACL", "/app/secrets").Return(adminACL, stat, nil).Once()
+		conn.On("Children", "/app/secrets").Return([]string{}, stat, nil).Once()
+
+		violations, err = AuditACLs(client, "/secrets", policy)
+
+		s.NoError(err)
+		s.Empty(violations, "auth entry matches the expanded digest entry")
+	})
+}
+
+func (s *RuleACLProviderTestSuite) TestTransaction() {
+	policy := NewRuleACLProvider(READ_ACL_UNSAFE, NewACLRule("/secrets", adminACL...)).WithNamespace("app")
+
+	s.WithPrepare(func(builder *CuratorFrameworkBuilder) {
+		builder.Namespace = "app"
+		builder.AclProvider = policy
+	}, func(client CuratorFramework, conn *mockConn, data []byte) {
+		conn.On("Exists", "/app").Return(true, nil, nil).Once()
+		conn.On("Multi", mock.Anything).Return([]zk.MultiResponse{{String: "/app/secrets/db"}, {String: "/app/config"}}, nil).Once()
+
+		_, err := client.InTransaction().
+			Create().ForPathWithData("/secrets/db", data).
+			Create().ForPathWithData("/config", data).
+			Commit()
+
+		s.NoError(err)
+		s.Equal([]interface{}{
+			&zk.CreateRequest{Path: "/app/secrets/db", Data: data, Acl: adminACL, Flags: int32(PERSISTENT)},
+			&zk.CreateRequest{Path: "/app/config", Data: data, Acl: READ_ACL_UNSAFE, Flags: int32(PERSISTENT)},
+		}, conn.operations)
+	})
+}
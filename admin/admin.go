@@ -0,0 +1,422 @@
+// Package admin queries the state of the ZooKeeper servers for the health checks,
+// with the four letter words over TCP or the commands of the AdminServer over HTTP.
+package admin
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"net"
+	"net/http"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	DEFAULT_TIMEOUT    = 5 * time.Second
+	DEFAULT_ADMIN_PORT = 8080 // the port of the AdminServer, admin.serverPort
+	DEFAULT_PORT       = 2181 // the client port, used when the server has no port
+)
+
+var ErrNotServing = errors.New("the server is not serving requests")
+
+// The client sending the admin commands to the ZooKeeper servers
+type Client struct {
+	Timeout    time.Duration // the timeout of each command
+	AdminPort  int           // the port of the AdminServer
+	HTTPClient *http.Client  // the client of the AdminServer
+}
+
+func NewClient() *Client {
+	return &Client{
+		Timeout:    DEFAULT_TIMEOUT,
+		AdminPort:  DEFAULT_ADMIN_PORT,
+		HTTPClient: &http.Client{Timeout: DEFAULT_TIMEOUT},
+	}
+}
+
+// Send the four letter word to the server, e.g. "mntr", and return the whole reply.
+// The command must be in the `4lw.commands.whitelist` of the server.
+func (c *Client) Command(server, command string) (string, error) {
+	conn, err := net.DialTimeout("tcp", withPort(server, DEFAULT_PORT), c.Timeout)
+
+	if err != nil {
+		return "", err
+	}
+
+	defer conn.Close()
+
+	if err := conn.SetDeadline(time.Now().Add(c.Timeout)); err != nil {
+		return "", err
+	}
+
+	if _, err := conn.Write([]byte(command)); err != nil {
+		return "", err
+	}
+
+	// the server closes the connection after the reply
+	reply, err := ioutil.ReadAll(conn)
+
+	if err != nil && len(reply) == 0 {
+		return "", err
+	}
+
+	if strings.Contains(string(reply), "is not executed because it is not in the whitelist") {
+		return "", fmt.Errorf("command %s is not in the whitelist of %s", command, server)
+	}
+
+	return string(reply), nil
+}
+
+// Test whether the server is running in a non-error state with `ruok`
+func (c *Client) Ruok(server string) (bool, error) {
+	reply, err := c.Command(server, "ruok")
+
+	if err != nil {
+		return false, err
+	}
+
+	return strings.TrimSpace(reply) == "imok", nil
+}
+
+// The statistics of the server from `mntr` or the `monitor` command of the AdminServer
+type Monitor struct {
+	Version             string
+	ServerState         string // leader, follower, observer, read-only or standalone
+	AvgLatency          float64
+	MinLatency          float64
+	MaxLatency          float64
+	PacketsReceived     int64
+	PacketsSent         int64
+	NumAliveConnections int64
+	OutstandingRequests int64
+	ZnodeCount          int64
+	WatchCount          int64
+	EphemeralsCount     int64
+	ApproximateDataSize int64
+	Followers           int64             // only available on the leader
+	SyncedFollowers     int64             // only available on the leader
+	PendingSyncs        int64             // only available on the leader
+	Values              map[string]string // all the values keyed without the `zk_` prefix
+}
+
+// Whether the server is the leader of the ensemble or a standalone server
+func (m *Monitor) IsLeader() bool { return m.ServerState == "leader" || m.ServerState == "standalone" }
+
+func newMonitor(values map[string]string) *Monitor {
+	m := &Monitor{
+		Version:     values["version"],
+		ServerState: values["server_state"],
+		Values:      values,
+	}
+
+	m.AvgLatency = parseFloat(values["avg_latency"])
+	m.MinLatency = parseFloat(values["min_latency"])
+	m.MaxLatency = parseFloat(values["max_latency"])
+	m.PacketsReceived = parseInt(values["packets_received"])
+	m.PacketsSent = parseInt(values["packets_sent"])
+	m.NumAliveConnections = parseInt(values["num_alive_connections"])
+	m.OutstandingRequests = parseInt(values["outstanding_requests"])
+	m.ZnodeCount = parseInt(values["znode_count"])
+	m.WatchCount = parseInt(values["watch_count"])
+	m.EphemeralsCount = parseInt(values["ephemerals_count"])
+	m.ApproximateDataSize = parseInt(values["approximate_data_size"])
+	m.Followers = parseInt(values["followers"])
+	m.SyncedFollowers = parseInt(values["synced_followers"])
+	m.PendingSyncs = parseInt(values["pending_syncs"])
+
+	return m
+}
+
+// Get the statistics of the server with `mntr`
+func (c *Client) Mntr(server string) (*Monitor, error) {
+	reply, err := c.Command(server, "mntr")
+
+	if err != nil {
+		return nil, err
+	}
+
+	return ParseMntr(reply)
+}
+
+// Parse the reply of `mntr`, which has a tab separated key and value in each line
+func ParseMntr(reply string) (*Monitor, error) {
+	if err := checkServing(reply); err != nil {
+		return nil, err
+	}
+
+	values := make(map[string]string)
+
+	for _, line := range strings.Split(reply, "\n") {
+		if idx := strings.IndexAny(line, "\t "); idx > 0 {
+			values[strings.TrimPrefix(line[:idx], "zk_")] = strings.TrimSpace(line[idx+1:])
+		}
+	}
+
+	if len(values) == 0 {
+		return nil, fmt.Errorf("unexpected reply of mntr: %s", reply)
+	}
+
+	return newMonitor(values), nil
+}
+
+// The statistics of the server from `srvr` or `stat`
+type ServerStats struct {
+	Version     string
+	MinLatency  float64
+	AvgLatency  float64
+	MaxLatency  float64
+	Received    int64
+	Sent        int64
+	Connections int64
+	Outstanding int64
+	Zxid        int64
+	Mode        string // leader, follower, observer, read-only or standalone
+	NodeCount   int64
+	Clients     []string // only available from `stat`
+}
+
+// Whether the server is the leader of the ensemble or a standalone server
+func (s *ServerStats) IsLeader() bool { return s.Mode == "leader" || s.Mode == "standalone" }
+
+// Get the statistics of the server with `srvr`
+func (c *Client) Srvr(server string) (*ServerStats, error) {
+	reply, err := c.Command(server, "srvr")
+
+	if err != nil {
+		return nil, err
+	}
+
+	return ParseStat(reply)
+}
+
+// Get the statistics and the clients of the server with `stat`
+func (c *Client) Stat(server string) (*ServerStats, error) {
+	reply, err := c.Command(server, "stat")
+
+	if err != nil {
+		return nil, err
+	}
+
+	return ParseStat(reply)
+}
+
+// Parse the reply of `srvr` or `stat`
+func ParseStat(reply string) (*ServerStats, error) {
+	if err := checkServing(reply); err != nil {
+		return nil, err
+	}
+
+	stats := &ServerStats{}
+	clients := false
+
+	for _, line := range strings.Split(reply, "\n") {
+		if clients {
+			if line = strings.TrimSpace(line); len(line) > 0 {
+				stats.Clients = append(stats.Clients, line)
+
+				continue
+			}
+
+			clients = false
+		}
+
+		idx := strings.Index(line, ":")
+
+		if idx < 0 {
+			continue
+		}
+
+		key, value := line[:idx], strings.TrimSpace(line[idx+1:])
+
+		switch key {
+		case "Zookeeper version":
+			stats.Version = value
+		case "Clients":
+			clients = true
+		case "Latency min/avg/max":
+			if latencies := strings.Split(value, "/"); len(latencies) == 3 {
+				stats.MinLatency = parseFloat(latencies[0])
+				stats.AvgLatency = parseFloat(latencies[1])
+				stats.MaxLatency = parseFloat(latencies[2])
+			}
+		case "Received":
+			stats.Received = parseInt(value)
+		case "Sent":
+			stats.Sent = parseInt(value)
+		case "Connections":
+			stats.Connections = parseInt(value)
+		case "Outstanding":
+			stats.Outstanding = parseInt(value)
+		case "Zxid":
+			stats.Zxid, _ = strconv.ParseInt(strings.TrimPrefix(value, "0x"), 16, 64)
+		case "Mode":
+			stats.Mode = value
+		case "Node count":
+			stats.NodeCount = parseInt(value)
+		}
+	}
+
+	if len(stats.Mode) == 0 {
+		return nil, fmt.Errorf("unexpected reply of stat: %s", reply)
+	}
+
+	return stats, nil
+}
+
+// A connection of a client, e.g. ` /127.0.0.1:56532[1](queued=0,recved=1,sent=1,sid=0x100000000000001)`
+type Connection struct {
+	Address  string
+	Interest int64             // the interest ops of the connection
+	Stats    map[string]string // e.g. queued, recved, sent, sid, lop, est, to, lzxid, lresp, llat, minlat, avglat, maxlat
+}
+
+var connectionPattern = regexp.MustCompile(`^/?(\S+)\[(\d+)\]\((.*)\)$`)
+
+// Get the connections of the clients with `cons`
+func (c *Client) Cons(server string) ([]*Connection, error) {
+	reply, err := c.Command(server, "cons")
+
+	if err != nil {
+		return nil, err
+	}
+
+	return ParseCons(reply)
+}
+
+// Parse the reply of `cons`, which has a connection in each line
+func ParseCons(reply string) ([]*Connection, error) {
+	if err := checkServing(reply); err != nil {
+		return nil, err
+	}
+
+	var connections []*Connection
+
+	for _, line := range strings.Split(reply, "\n") {
+		if line = strings.TrimSpace(line); len(line) == 0 {
+			continue
+		}
+
+		matches := connectionPattern.FindStringSubmatch(line)
+
+		if matches == nil {
+			return nil, fmt.Errorf("unexpected connection of cons: %s", line)
+		}
+
+		conn := &Connection{Address: matches[1], Interest: parseInt(matches[2]), Stats: make(map[string]string)}
+
+		for _, stat := range strings.Split(matches[3], ",") {
+			if idx := strings.Index(stat, "="); idx > 0 {
+				conn.Stats[stat[:idx]] = stat[idx+1:]
+			}
+		}
+
+		connections = append(connections, conn)
+	}
+
+	return connections, nil
+}
+
+// The summary of the watches from `wchs`
+type WatchSummary struct {
+	Connections int64
+	Paths       int64
+	Watches     int64
+}
+
+var watchSummaryPattern = regexp.MustCompile(`(\d+) connections watching (\d+) paths\s+Total watches:\s*(\d+)`)
+
+// Get the summary of the watches with `wchs`
+func (c *Client) Wchs(server string) (*WatchSummary, error) {
+	reply, err := c.Command(server, "wchs")
+
+	if err != nil {
+		return nil, err
+	}
+
+	return ParseWchs(reply)
+}
+
+// Parse the reply of `wchs`
+func ParseWchs(reply string) (*WatchSummary, error) {
+	if err := checkServing(reply); err != nil {
+		return nil, err
+	}
+
+	matches := watchSummaryPattern.FindStringSubmatch(reply)
+
+	if matches == nil {
+		return nil, fmt.Errorf("unexpected reply of wchs: %s", reply)
+	}
+
+	return &WatchSummary{
+		Connections: parseInt(matches[1]),
+		Paths:       parseInt(matches[2]),
+		Watches:     parseInt(matches[3]),
+	}, nil
+}
+
+// Get the environment of the server with `envi`, e.g. zookeeper.version, host.name and java.version
+func (c *Client) Envi(server string) (map[string]string, error) {
+	reply, err := c.Command(server, "envi")
+
+	if err != nil {
+		return nil, err
+	}
+
+	return ParseEnvi(reply)
+}
+
+// Parse the reply of `envi`, which has a key=value in each line after the `Environment:` header
+func ParseEnvi(reply string) (map[string]string, error) {
+	env := make(map[string]string)
+
+	scanner := bufio.NewScanner(strings.NewReader(reply))
+
+	for scanner.Scan() {
+		if line := scanner.Text(); strings.Contains(line, "=") {
+			idx := strings.Index(line, "=")
+
+			env[line[:idx]] = line[idx+1:]
+		}
+	}
+
+	if len(env) == 0 {
+		return nil, fmt.Errorf("unexpected reply of envi: %s", reply)
+	}
+
+	return env, nil
+}
+
+// The server replies it when it's not in the quorum
+func checkServing(reply string) error {
+	if strings.HasPrefix(reply, "This ZooKeeper instance is not currently serving requests") {
+		return ErrNotServing
+	}
+
+	return nil
+}
+
+// Append the default port to the server without a port
+func withPort(server string, port int) string {
+	if _, _, err := net.SplitHostPort(server); err != nil {
+		return net.JoinHostPort(server, strconv.Itoa(port))
+	}
+
+	return server
+}
+
+func parseInt(s string) int64 {
+	n, _ := strconv.ParseInt(s, 10, 64)
+
+	return n
+}
+
+func parseFloat(s string) float64 {
+	f, _ := strconv.ParseFloat(s, 64)
+
+	return f
+}
@@ -0,0 +1,187 @@
+package admin
+
+import (
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/yxdrlitao/curator"
+)
+
+// Listen on a local port and reply the four letter words like a ZooKeeper server
+func fakeServer(t *testing.T, replies map[string]string) string {
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+
+	assert.NoError(t, err)
+
+	t.Cleanup(func() { l.Close() })
+
+	go func() {
+		for {
+			conn, err := l.Accept()
+
+			if err != nil {
+				return
+			}
+
+			go func(conn net.Conn) {
+				defer conn.Close()
+
+				command := make([]byte, 4)
+
+				if _, err := conn.Read(command); err == nil {
+					conn.Write([]byte(replies[string(command)]))
+				}
+			}(conn)
+		}
+	}()
+
+	return l.Addr().String()
+}
+
+const mntrReply = "zk_version\t3.6.3--6401e4ad2087061bc6b9f80dec2d69f2e3c8660a, built on 04/08/2021 16:35 GMT\n" +
+	"zk_server_state\tleader\n" +
+	"zk_avg_latency\t0.5\n" +
+	"zk_max_latency\t12\n" +
+	"zk_min_latency\t0\n" +
+	"zk_outstanding_requests\t3\n" +
+	"zk_znode_count\t42\n" +
+	"zk_followers\t2\n"
+
+const statReply = "Zookeeper version: 3.6.3--6401e4ad2087061bc6b9f80dec2d69f2e3c8660a, built on 04/08/2021 16:35 GMT\n" +
+	"Clients:\n" +
+	" /127.0.0.1:56532[1](queued=0,recved=1,sent=1)\n" +
+	" /127.0.0.1:56534[0](queued=0,recved=1,sent=0)\n" +
+	"\n" +
+	"Latency min/avg/max: 0/0.5/12\n" +
+	"Received: 10\n" +
+	"Sent: 9\n" +
+	"Connections: 2\n" +
+	"Outstanding: 0\n" +
+	"Zxid: 0x10000002a\n" +
+	"Mode: follower\n" +
+	"Node count: 42\n"
+
+func TestFourLetterWords(t *testing.T) {
+	server := fakeServer(t, map[string]string{
+		"ruok": "imok",
+		"mntr": mntrReply,
+		"stat": statReply,
+		"cons": " /127.0.0.1:56532[1](queued=0,recved=1,sent=1,sid=0x100000000000001,lop=PING)\n\n",
+		"wchs": "1 connections watching 3 paths\nTotal watches:4\n",
+		"envi": "Environment:\nzookeeper.version=3.6.3\nhost.name=zk1\n",
+		"srvr": "This ZooKeeper instance is not currently serving requests\n",
+	})
+
+	c := NewClient()
+
+	ok, err := c.Ruok(server)
+
+	assert.NoError(t, err)
+	assert.True(t, ok)
+
+	monitor, err := c.Mntr(server)
+
+	assert.NoError(t, err)
+	assert.True(t, monitor.IsLeader())
+	assert.Equal(t, 0.5, monitor.AvgLatency)
+	assert.Equal(t, 12.0, monitor.MaxLatency)
+	assert.Equal(t, int64(3), monitor.OutstandingRequests)
+	assert.Equal(t, int64(42), monitor.ZnodeCount)
+	assert.Equal(t, int64(2), monitor.Followers)
+
+	stats, err := c.Stat(server)
+
+	assert.NoError(t, err)
+	assert.False(t, stats.IsLeader())
+	assert.Equal(t, "follower", stats.Mode)
+	assert.Equal(t, 0.5, stats.AvgLatency)
+	assert.Equal(t, int64(0x10000002a), stats.Zxid)
+	assert.Equal(t, int64(42), stats.NodeCount)
+	assert.Equal(t, []string{"/127.0.0.1:56532[1](queued=0,recved=1,sent=1)", "/127.0.0.1:56534[0](queued=0,recved=1,sent=0)"}, stats.Clients)
+
+	connections, err := c.Cons(server)
+
+	assert.NoError(t, err)
+	assert.Equal(t, []*Connection{{
+		Address:  "127.0.0.1:56532",
+		Interest: 1,
+		Stats:    map[string]string{"queued": "0", "recved": "1", "sent": "1", "sid": "0x100000000000001", "lop": "PING"},
+	}}, connections)
+
+	watches, err := c.Wchs(server)
+
+	assert.NoError(t, err)
+	assert.Equal(t, &WatchSummary{Connections: 1, Paths: 3, Watches: 4}, watches)
+
+	env, err := c.Envi(server)
+
+	assert.NoError(t, err)
+	assert.Equal(t, map[string]string{"zookeeper.version": "3.6.3", "host.name": "zk1"}, env)
+
+	_, err = c.Srvr(server)
+
+	assert.Equal(t, ErrNotServing, err)
+}
+
+func TestAdminServer(t *testing.T) {
+	s := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/commands/monitor":
+			w.Write([]byte(`{"command":"monitor","error":null,"server_state":"follower","avg_latency":1.5,"outstanding_requests":7,"znode_count":1000000}`))
+		case "/commands/ruok":
+			w.Write([]byte(`{"command":"ruok","error":null}`))
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+
+	defer s.Close()
+
+	host, port, _ := net.SplitHostPort(s.Listener.Addr().String())
+
+	c := NewClient()
+
+	c.AdminPort, _ = strconv.Atoi(port)
+
+	ok, err := c.AdminRuok(net.JoinHostPort(host, "2181"))
+
+	assert.NoError(t, err)
+	assert.True(t, ok)
+
+	monitor, err := c.AdminMonitor(host)
+
+	assert.NoError(t, err)
+	assert.Equal(t, "follower", monitor.ServerState)
+	assert.Equal(t, 1.5, monitor.AvgLatency)
+	assert.Equal(t, int64(7), monitor.OutstandingRequests)
+	assert.Equal(t, int64(1000000), monitor.ZnodeCount)
+	assert.NotContains(t, monitor.Values, "command")
+
+	_, err = c.AdminEnvironment(host)
+
+	assert.Error(t, err)
+}
+
+func TestQueryEnsemble(t *testing.T) {
+	server := fakeServer(t, map[string]string{"ruok": "imok"})
+
+	assert.Equal(t, []string{"zk1:2181", "zk2:2182"}, Servers("zk1, zk2:2182/app"))
+
+	// a closed port refuses the connection
+	l, _ := net.Listen("tcp", "127.0.0.1:0")
+	closed := l.Addr().String()
+	l.Close()
+
+	results := NewClient().RuokEnsemble(curator.NewFixedEnsembleProvider(server + "," + closed + "/chroot"))
+
+	assert.Len(t, results, 2)
+	assert.Equal(t, server, results[0].Server)
+	assert.Equal(t, true, results[0].Result)
+	assert.NoError(t, results[0].Err)
+	assert.Equal(t, closed, results[1].Server)
+	assert.Error(t, results[1].Err)
+}
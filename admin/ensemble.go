@@ -0,0 +1,70 @@
+package admin
+
+import (
+	"strings"
+	"sync"
+
+	"github.com/yxdrlitao/curator"
+)
+
+// The result of a command sent to a server of the ensemble
+type ServerResult struct {
+	Server string
+	Result interface{} // the result of the command, e.g. *Monitor
+	Err    error
+}
+
+// Split the connection string into the servers, without the chroot suffix
+func Servers(connectString string) []string {
+	if idx := strings.Index(connectString, "/"); idx >= 0 {
+		connectString = connectString[:idx]
+	}
+
+	var servers []string
+
+	for _, server := range strings.Split(connectString, ",") {
+		if server = strings.TrimSpace(server); len(server) > 0 {
+			servers = append(servers, withPort(server, DEFAULT_PORT))
+		}
+	}
+
+	return servers
+}
+
+// Query every server of the ensemble concurrently, the results are in the order of the connection string
+func (c *Client) QueryEnsemble(provider curator.EnsembleProvider, query func(server string) (interface{}, error)) []*ServerResult {
+	servers := Servers(provider.ConnectionString())
+	results := make([]*ServerResult, len(servers))
+
+	var wg sync.WaitGroup
+
+	for i, server := range servers {
+		wg.Add(1)
+
+		go func(i int, server string) {
+			defer wg.Done()
+
+			result, err := query(server)
+
+			results[i] = &ServerResult{Server: server, Result: result, Err: err}
+		}(i, server)
+	}
+
+	wg.Wait()
+
+	return results
+}
+
+// Get the statistics of every server of the ensemble with `mntr`
+func (c *Client) MntrEnsemble(provider curator.EnsembleProvider) []*ServerResult {
+	return c.QueryEnsemble(provider, func(server string) (interface{}, error) {
+		return c.Mntr(server)
+	})
+}
+
+// Test every server of the ensemble with `ruok`
+func (c *Client) RuokEnsemble(provider curator.EnsembleProvider) []*ServerResult {
+	return c.QueryEnsemble(provider, func(server string) (interface{}, error) {
+		return c.Ruok(server)
+	})
+}
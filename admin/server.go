@@ -0,0 +1,107 @@
+package admin
+
+import (
+	"encoding/json"
+	"fmt"
+	"net"
+	"net/http"
+	"strconv"
+)
+
+// The reply of the AdminServer, which has the `command` and `error` besides the values of the command
+type CommandResponse map[string]interface{}
+
+// Send the command to the AdminServer of the server, e.g. "monitor", "ruok" or "connections",
+// and decode the JSON reply into the result.
+func (c *Client) AdminCommand(server, command string, result interface{}) error {
+	host := server
+
+	if h, _, err := net.SplitHostPort(server); err == nil {
+		host = h
+	}
+
+	url := fmt.Sprintf("http://%s/commands/%s", net.JoinHostPort(host, strconv.Itoa(c.AdminPort)), command)
+
+	res, err := c.HTTPClient.Get(url)
+
+	if err != nil {
+		return err
+	}
+
+	defer res.Body.Close()
+
+	if res.StatusCode != http.StatusOK {
+		return fmt.Errorf("unexpected status `%s` from %s", res.Status, url)
+	}
+
+	decoder := json.NewDecoder(res.Body)
+
+	decoder.UseNumber()
+
+	if err := decoder.Decode(result); err != nil {
+		return fmt.Errorf("fail to decode response from %s, %s", url, err)
+	}
+
+	return nil
+}
+
+func (c *Client) adminCommand(server, command string) (CommandResponse, error) {
+	var res CommandResponse
+
+	if err := c.AdminCommand(server, command, &res); err != nil {
+		return nil, err
+	}
+
+	if msg, ok := res["error"]; ok && msg != nil {
+		return nil, fmt.Errorf("command %s failed on %s, %v", command, server, msg)
+	}
+
+	return res, nil
+}
+
+// Test whether the server is running with the `ruok` command of the AdminServer
+func (c *Client) AdminRuok(server string) (bool, error) {
+	if _, err := c.adminCommand(server, "ruok"); err != nil {
+		return false, err
+	}
+
+	return true, nil
+}
+
+// Get the statistics of the server with the `monitor` command of the AdminServer
+func (c *Client) AdminMonitor(server string) (*Monitor, error) {
+	res, err := c.adminCommand(server, "monitor")
+
+	if err != nil {
+		return nil, err
+	}
+
+	values := make(map[string]string, len(res))
+
+	for key, value := range res {
+		if key != "command" && key != "error" && value != nil {
+			values[key] = fmt.Sprint(value)
+		}
+	}
+
+	return newMonitor(values), nil
+}
+
+// Get the environment of the server with the `environment` command of the AdminServer
+func (c *Client) AdminEnvironment(server string) (map[string]string, error) {
+	res, err := c.adminCommand(server, "environment")
+
+	if err != nil {
+		return nil, err
+	}
+
+	env := make(map[string]string, len(res))
+
+	for key, value := range res {
+		if key != "command" && key != "error" && value != nil {
+			env[key] = fmt.Sprint(value)
+		}
+	}
+
+	return env, nil
+}
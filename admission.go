@@ -0,0 +1,451 @@
+package curator
+
+import (
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/yxdrlitao/go-zookeeper/zk"
+)
+
+const (
+	ALL_REQUESTS                          = "*" // the rate limit shared by all the requests
+	DEFAULT_CIRCUIT_BREAKER_RESET_TIMEOUT = 5 * time.Second
+)
+
+var (
+	ErrRateLimited = errors.New("the rate limit is exceeded")
+	ErrCircuitOpen = errors.New("the circuit breaker is open")
+)
+
+// The request is rejected by the admission layer before it's sent, which is not retried
+type AdmissionError struct {
+	Method string // the method of ZookeeperConnection, e.g. "Get"
+	Err    error  // ErrRateLimited or ErrCircuitOpen
+}
+
+func (e *AdmissionError) Error() string {
+	return fmt.Sprintf("request %s is rejected, %s", e.Method, e.Err)
+}
+
+func (e *AdmissionError) Unwrap() error { return e.Err }
+
+// A token bucket refilled with Rate tokens per second up to Burst tokens
+type RateLimit struct {
+	Rate  float64
+	Burst int
+}
+
+// The admission of the requests of CuratorZookeeperClient
+type AdmissionPolicy struct {
+	// The rate limits keyed by the methods of ZookeeperConnection, e.g. "Get", or ALL_REQUESTS
+	RateLimits map[string]RateLimit
+
+	// Open the circuit breaker after the consecutive connection failures, 0 to disable it
+	FailureThreshold int
+
+	// Let a trial request through after the circuit breaker has been open for it
+	ResetTimeout time.Duration
+}
+
+type tokenBucket struct {
+	lock   sync.Mutex
+	rate   float64
+	burst  float64
+	tokens float64
+	last   time.Time
+}
+
+func newTokenBucket(limit RateLimit) *tokenBucket {
+	return &tokenBucket{rate: limit.Rate, burst: float64(limit.Burst), tokens: float64(limit.Burst), last: time.Now()}
+}
+
+func (b *tokenBucket) take(now time.Time) bool {
+	b.lock.Lock()
+	defer b.lock.Unlock()
+
+	if elapsed := now.Sub(b.last); elapsed > 0 {
+		b.tokens += elapsed.Seconds() * b.rate
+
+		if b.tokens > b.burst {
+			b.tokens = b.burst
+		}
+
+		b.last = now
+	}
+
+	if b.tokens < 1 {
+		return false
+	}
+
+	b.tokens--
+
+	return true
+}
+
+type circuitBreaker struct {
+	lock         sync.Mutex
+	threshold    int
+	resetTimeout time.Duration
+	tracer       TracerDriver
+	failures     int
+	open         bool
+	openedAt     time.Time
+	trial        bool
+}
+
+func (b *circuitBreaker) allow(now time.Time) bool {
+	b.lock.Lock()
+	defer b.lock.Unlock()
+
+	if !b.open {
+		return true
+	}
+
+	if !b.trial && now.Sub(b.openedAt) >= b.resetTimeout {
+		b.trial = true
+
+		return true
+	}
+
+	return false
+}
+
+func (b *circuitBreaker) record(err error) {
+	b.lock.Lock()
+	defer b.lock.Unlock()
+
+	if isConnectionError(err) {
+		b.failures++
+
+		if b.open {
+			b.openedAt, b.trial = time.Now(), false
+		} else if b.failures >= b.threshold {
+			b.tripLocked()
+		}
+	} else {
+		b.failures = 0
+
+		if b.open {
+			b.resetLocked()
+		}
+	}
+}
+
+// Open the breaker regardless of the failures, e.g. while the connection is SUSPENDED or LOST
+func (b *circuitBreaker) trip() {
+	b.lock.Lock()
+	defer b.lock.Unlock()
+
+	if !b.open {
+		b.tripLocked()
+	}
+}
+
+// Close the breaker, e.g. after the connection is RECONNECTED
+func (b *circuitBreaker) reset() {
+	b.lock.Lock()
+	defer b.lock.Unlock()
+
+	b.failures = 0
+
+	if b.open {
+		b.resetLocked()
+	}
+}
+
+func (b *circuitBreaker) tripLocked() {
+	b.open, b.openedAt, b.trial = true, time.Now(), false
+
+	b.tracer.AddCount("circuit-breaker-opened", 1)
+}
+
+func (b *circuitBreaker) resetLocked() {
+	b.open, b.trial = false, false
+
+	b.tracer.AddCount("circuit-breaker-closed", 1)
+}
+
+func (b *circuitBreaker) StateChanged(client CuratorFramework, newState ConnectionState) {
+	switch newState {
+	case SUSPENDED, LOST:
+		b.trip()
+	case CONNECTED, RECONNECTED, READ_ONLY:
+		b.reset()
+	}
+}
+
+type admission struct {
+	limiters map[string]*tokenBucket
+	breaker  *circuitBreaker
+	tracer   TracerDriver
+}
+
+func newAdmission(policy *AdmissionPolicy, tracer TracerDriver) *admission {
+	a := &admission{limiters: make(map[string]*tokenBucket), tracer: tracer}
+
+	for method, limit := range policy.RateLimits {
+		a.limiters[method] = newTokenBucket(limit)
+	}
+
+	if policy.FailureThreshold > 0 {
+		a.breaker = &circuitBreaker{threshold: policy.FailureThreshold, resetTimeout: policy.ResetTimeout, tracer: tracer}
+
+		if a.breaker.resetTimeout <= 0 {
+			a.breaker.resetTimeout = DEFAULT_CIRCUIT_BREAKER_RESET_TIMEOUT
+		}
+	}
+
+	return a
+}
+
+func (a *admission) admit(method string) error {
+	now := time.Now()
+
+	for _, key := range []string{ALL_REQUESTS, method} {
+		if limiter, ok := a.limiters[key]; ok && !limiter.take(now) {
+			a.tracer.AddCount("admission-rejected-rate-limit", 1)
+
+			return &AdmissionError{method, ErrRateLimited}
+		}
+	}
+
+	// claim the trial request of the open breaker only after the limiters, so a rejected request never holds it
+	if a.breaker != nil && !a.breaker.allow(now) {
+		a.tracer.AddCount("admission-rejected-circuit-open", 1)
+
+		return &AdmissionError{method, ErrCircuitOpen}
+	}
+
+	return nil
+}
+
+func (a *admission) done(err error) {
+	if a.breaker != nil {
+		a.breaker.record(err)
+	}
+}
+
+// A ZookeeperConnection which admits the requests before sending them
+type admittedConnection struct {
+	ZookeeperConnection
+
+	admission *admission
+}
+
+func (c *admittedConnection) Create(path string, data []byte, flags int32, acl []zk.ACL) (string, error) {
+	if err := c.admission.admit("Create"); err != nil {
+		return "", err
+	}
+
+	createdPath, err := c.ZookeeperConnection.Create(path, data, flags, acl)
+
+	c.admission.done(err)
+
+	return createdPath, err
+}
+
+func (c *admittedConnection) Exists(path string) (bool, *zk.Stat, error) {
+	if err := c.admission.admit("Exists"); err != nil {
+		return false, nil, err
+	}
+
+	exists, stat, err := c.ZookeeperConnection.Exists(path)
+
+	c.admission.done(err)
+
+	return exists, stat, err
+}
+
+func (c *admittedConnection) ExistsW(path string) (bool, *zk.Stat, <-chan zk.Event, error) {
+	if err := c.admission.admit("ExistsW"); err != nil {
+		return false, nil, nil, err
+	}
+
+	exists, stat, events, err := c.ZookeeperConnection.ExistsW(path)
+
+	c.admission.done(err)
+
+	return exists, stat, events, err
+}
+
+func (c *admittedConnection) Delete(path string, version int32) error {
+	if err := c.admission.admit("Delete"); err != nil {
+		return err
+	}
+
+	err := c.ZookeeperConnection.Delete(path, version)
+
+	c.admission.done(err)
+
+	return err
+}
+
+func (c *admittedConnection) Get(path string) ([]byte, *zk.Stat, error) {
+	if err := c.admission.admit("Get"); err != nil {
+		return nil, nil, err
+	}
+
+	data, stat, err := c.ZookeeperConnection.Get(path)
+
+	c.admission.done(err)
+
+	return data, stat, err
+}
+
+func (c *admittedConnection) GetW(path string) ([]byte, *zk.Stat, <-chan zk.Event, error) {
+	if err := c.admission.admit("GetW"); err != nil {
+		return nil, nil, nil, err
+	}
+
+	data, stat, events, err := c.ZookeeperConnection.GetW(path)
+
+	c.admission.done(err)
+
+	return data, stat, events, err
+}
+
+func (c *admittedConnection) Set(path string, data []byte, version int32) (*zk.Stat, error) {
+	if err := c.admission.admit("Set"); err != nil {
+		return nil, err
+	}
+
+	stat, err := c.ZookeeperConnection.Set(path, data, version)
+
+	c.admission.done(err)
+
+	return stat, err
+}
+
+func (c *admittedConnection) Children(path string) ([]string, *zk.Stat, error) {
+	if err := c.admission.admit("Children"); err != nil {
+		return nil, nil, err
+	}
+
+	children, stat, err := c.ZookeeperConnection.Children(path)
+
+	c.admission.done(err)
+
+	return children, stat, err
+}
+
+func (c *admittedConnection) ChildrenW(path string) ([]string, *zk.Stat, <-chan zk.Event, error) {
+	if err := c.admission.admit("ChildrenW"); err != nil {
+		return nil, nil, nil, err
+	}
+
+	children, stat, events, err := c.ZookeeperConnection.ChildrenW(path)
+
+	c.admission.done(err)
+
+	return children, stat, events, err
+}
+
+func (c *admittedConnection) GetACL(path string) ([]zk.ACL, *zk.Stat, error) {
+	if err := c.admission.admit("GetACL"); err != nil {
+		return nil, nil, err
+	}
+
+	acls, stat, err := c.ZookeeperConnection.GetACL(path)
+
+	c.admission.done(err)
+
+	return acls, stat, err
+}
+
+func (c *admittedConnection) SetACL(path string, acl []zk.ACL, version int32) (*zk.Stat, error) {
+	if err := c.admission.admit("SetACL"); err != nil {
+		return nil, err
+	}
+
+	stat, err := c.ZookeeperConnection.SetACL(path, acl, version)
+
+	c.admission.done(err)
+
+	return stat, err
+}
+
+func (c *admittedConnection) Multi(ops ...interface{}) ([]zk.MultiResponse, error) {
+	if err := c.admission.admit("Multi"); err != nil {
+		return nil, err
+	}
+
+	responses, err := c.ZookeeperConnection.Multi(ops...)
+
+	c.admission.done(err)
+
+	return responses, err
+}
+
+func (c *admittedConnection) Sync(path string) (string, error) {
+	if err := c.admission.admit("Sync"); err != nil {
+		return "", err
+	}
+
+	syncPath, err := c.ZookeeperConnection.Sync(path)
+
+	c.admission.done(err)
+
+	return syncPath, err
+}
+
+func (c *admittedConnection) IncrementalReconfig(joining, leaving []string, version int64) (*zk.Stat, error) {
+	if err := c.admission.admit("IncrementalReconfig"); err != nil {
+		return nil, err
+	}
+
+	stat, err := c.ZookeeperConnection.IncrementalReconfig(joining, leaving, version)
+
+	c.admission.done(err)
+
+	return stat, err
+}
+
+func (c *admittedConnection) Reconfig(members []string, version int64) (*zk.Stat, error) {
+	if err := c.admission.admit("Reconfig"); err != nil {
+		return nil, err
+	}
+
+	stat, err := c.ZookeeperConnection.Reconfig(members, version)
+
+	c.admission.done(err)
+
+	return stat, err
+}
+
+func (c *admittedConnection) MultiRead(ops ...interface{}) ([]MultiReadResponse, error) {
+	if err := c.admission.admit("MultiRead"); err != nil {
+		return nil, err
+	}
+
+	responses, err := c.ZookeeperConnection.MultiRead(ops...)
+
+	c.admission.done(err)
+
+	return responses, err
+}
+
+func (c *admittedConnection) GetEphemerals(prefix string) ([]string, error) {
+	if err := c.admission.admit("GetEphemerals"); err != nil {
+		return nil, err
+	}
+
+	paths, err := c.ZookeeperConnection.GetEphemerals(prefix)
+
+	c.admission.done(err)
+
+	return paths, err
+}
+
+func (c *admittedConnection) GetAllChildrenNumber(path string) (int32, error) {
+	if err := c.admission.admit("GetAllChildrenNumber"); err != nil {
+		return 0, err
+	}
+
+	number, err := c.ZookeeperConnection.GetAllChildrenNumber(path)
+
+	c.admission.done(err)
+
+	return number, err
+}
@@ -0,0 +1,144 @@
+package curator
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/suite"
+	"github.com/yxdrlitao/go-zookeeper/zk"
+)
+
+func TestTokenBucket(t *testing.T) {
+	bucket := newTokenBucket(RateLimit{Rate: 1, Burst: 2})
+	now := bucket.last
+
+	assert.True(t, bucket.take(now))
+	assert.True(t, bucket.take(now))
+	assert.False(t, bucket.take(now))
+	assert.False(t, bucket.take(now.Add(500*time.Millisecond)))
+	assert.True(t, bucket.take(now.Add(time.Second)))
+	assert.False(t, bucket.take(now.Add(time.Second)))
+}
+
+func TestCircuitBreaker(t *testing.T) {
+	breaker := &circuitBreaker{threshold: 2, resetTimeout: time.Minute, tracer: newDefaultTracerDriver()}
+
+	breaker.record(zk.ErrConnectionClosed)
+
+	assert.True(t, breaker.allow(time.Now()))
+
+	breaker.record(zk.ErrNoNode)
+	breaker.record(zk.ErrConnectionClosed)
+
+	assert.True(t, breaker.allow(time.Now()))
+
+	breaker.record(zk.ErrSessionExpired)
+
+	assert.False(t, breaker.allow(time.Now()))
+
+	// a single trial request after the reset timeout
+	assert.True(t, breaker.allow(time.Now().Add(time.Minute)))
+	assert.False(t, breaker.allow(time.Now().Add(time.Minute)))
+
+	breaker.record(nil)
+
+	assert.True(t, breaker.allow(time.Now()))
+
+	breaker.StateChanged(nil, SUSPENDED)
+
+	assert.False(t, breaker.allow(time.Now()))
+
+	breaker.StateChanged(nil, RECONNECTED)
+
+	assert.True(t, breaker.allow(time.Now()))
+}
+
+type AdmissionTestSuite struct {
+	mockContainerTestSuite
+}
+
+func TestAdmission(t *testing.T) {
+	suite.Run(t, new(AdmissionTestSuite))
+}
+
+func (s *AdmissionTestSuite) counter(client CuratorFramework, name string) int {
+	driver := client.ZookeeperClient().(*curatorZookeeperClient).TracerDriver.(*defaultTracerDriver)
+
+	driver.lock.Lock()
+	defer driver.lock.Unlock()
+
+	return driver.counters[name]
+}
+
+func (s *AdmissionTestSuite) TestRateLimit() {
+	s.WithPrepare(func(builder *CuratorFrameworkBuilder) {
+		builder.LimitRate("Get", 0, 1)
+	}, func(client CuratorFramework, conn *mockConn, data []byte, stat *zk.Stat) {
+		conn.On("Get", "/node").Return(data, stat, nil).Once()
+		conn.On("Exists", "/node").Return(true, stat, nil).Once()
+
+		_, err := client.GetData().ForPath("/node")
+
+		s.NoError(err)
+
+		_, err = client.GetData().ForPath("/node")
+
+		s.True(errors.Is(err, ErrRateLimited))
+		s.Equal(&AdmissionError{"Get", ErrRateLimited}, err)
+		s.Equal(1, s.counter(client, "admission-rejected-rate-limit"))
+
+		// the other methods are not limited
+		_, err = client.CheckExists().ForPath("/node")
+
+		s.NoError(err)
+	})
+}
+
+func (s *AdmissionTestSuite) TestCircuitBreaker() {
+	s.WithPrepare(func(builder *CuratorFrameworkBuilder) {
+		builder.EnableCircuitBreaker(1, time.Hour)
+	}, func(client CuratorFramework, conn *mockConn) {
+		conn.On("Get", "/node").Return(nil, nil, zk.ErrConnectionClosed).Once()
+
+		_, err := client.GetData().ForPath("/node")
+
+		s.Equal(zk.ErrConnectionClosed, err)
+
+		_, err = client.GetData().ForPath("/node")
+
+		s.True(errors.Is(err, ErrCircuitOpen))
+		s.Equal(1, s.counter(client, "circuit-breaker-opened"))
+		s.Equal(1, s.counter(client, "admission-rejected-circuit-open"))
+	})
+}
+
+func (s *AdmissionTestSuite) TestTrialRateLimited() {
+	s.WithPrepare(func(builder *CuratorFrameworkBuilder) {
+		builder.LimitRate("Get", 0, 1).EnableCircuitBreaker(1, time.Millisecond)
+	}, func(client CuratorFramework, conn *mockConn, data []byte, stat *zk.Stat) {
+		conn.On("Get", "/node").Return(data, stat, nil).Once()
+		conn.On("Exists", "/node").Return(false, nil, zk.ErrConnectionClosed).Once()
+		conn.On("Exists", "/node").Return(true, stat, nil).Once()
+
+		_, err := client.GetData().ForPath("/node")
+
+		s.NoError(err)
+
+		_, err = client.CheckExists().ForPath("/node")
+
+		s.Equal(zk.ErrConnectionClosed, err)
+
+		time.Sleep(10 * time.Millisecond)
+
+		_, err = client.GetData().ForPath("/node")
+
+		s.Equal(&AdmissionError{"Get", ErrRateLimited}, err)
+
+		// the request rejected by the limiter doesn't hold the trial of the breaker
+		_, err = client.CheckExists().ForPath("/node")
+
+		s.NoError(err)
+	})
+}
@@ -38,10 +38,12 @@ const (
 	PERSISTENT_SEQUENTIAL            = zk.FlagSequence
 	EPHEMERAL                        = zk.FlagEphemeral
 	EPHEMERAL_SEQUENTIAL             = zk.FlagEphemeral + zk.FlagSequence
+	CONTAINER                        = 4 // deleted by the server once its last child is deleted, since ZooKeeper 3.5
 )
 
 func (m CreateMode) IsSequential() bool { return (m & zk.FlagSequence) == zk.FlagSequence }
 func (m CreateMode) IsEphemeral() bool  { return (m & zk.FlagEphemeral) == zk.FlagEphemeral }
+func (m CreateMode) IsContainer() bool  { return m == CONTAINER }
 
 // Called when the async background operation completes
 type BackgroundCallback func(client CuratorFramework, event CuratorEvent) error
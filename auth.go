@@ -1,6 +1,85 @@
 package curator
 
+import (
+	"fmt"
+	"io/ioutil"
+	"strings"
+
+	"github.com/yxdrlitao/go-zookeeper/zk"
+)
+
+const DIGEST_SCHEME = "digest"
+
 type AuthInfo struct {
 	Scheme string
 	Auth   []byte
 }
+
+// Create the authorization of the digest scheme for the user and password
+func NewDigestAuthInfo(user, password string) AuthInfo {
+	return AuthInfo{DIGEST_SCHEME, []byte(user + ":" + password)}
+}
+
+// Provides the credentials to authenticate a connection.
+//
+// It is consulted every time a new connection is dialed, so that rotated credentials are picked up on reconnect.
+// Note: only the schemes supported by ZooKeeper AddAuth (e.g. digest) could be used, SASL is not supported by the client.
+type CredentialsProvider interface {
+	Credentials() ([]AuthInfo, error)
+}
+
+type CredentialsProviderFunc func() ([]AuthInfo, error)
+
+func (f CredentialsProviderFunc) Credentials() ([]AuthInfo, error) { return f() }
+
+// Credentials of the digest scheme
+type DigestCredentials struct {
+	User     string
+	Password string
+}
+
+func NewDigestCredentials(user, password string) *DigestCredentials {
+	return &DigestCredentials{user, password}
+}
+
+// Return the authorization to add to a connection
+func (c *DigestCredentials) AuthInfo() AuthInfo {
+	return NewDigestAuthInfo(c.User, c.Password)
+}
+
+// Return the ACL which grants the permissions to the connections authenticated with the credentials
+func (c *DigestCredentials) ACL(perms int32) []zk.ACL {
+	acls, _ := zk.DigestACL(perms, c.User, c.Password)
+
+	return acls
+}
+
+func (c *DigestCredentials) Credentials() ([]AuthInfo, error) {
+	return []AuthInfo{c.AuthInfo()}, nil
+}
+
+// Provides the digest credentials read from a file containing "user:password",
+// the file is read for every new connection so the password could be rotated without restarting.
+type DigestFileCredentialsProvider struct {
+	filename string
+}
+
+func NewDigestFileCredentialsProvider(filename string) *DigestFileCredentialsProvider {
+	return &DigestFileCredentialsProvider{filename}
+}
+
+func (p *DigestFileCredentialsProvider) Credentials() ([]AuthInfo, error) {
+	content, err := ioutil.ReadFile(p.filename)
+
+	if err != nil {
+		return nil, err
+	}
+
+	parts := strings.SplitN(strings.TrimSpace(string(content)), ":", 2)
+
+	if len(parts) != 2 || len(parts[0]) == 0 {
+		return nil, fmt.Errorf("invalid digest credentials in %s, expect user:password", p.filename)
+	}
+
+	return []AuthInfo{NewDigestAuthInfo(parts[0], parts[1])}, nil
+}
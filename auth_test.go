@@ -0,0 +1,142 @@
+package curator
+
+import (
+	"errors"
+	"io/ioutil"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/yxdrlitao/go-zookeeper/zk"
+)
+
+func TestDigestCredentials(t *testing.T) {
+	c := NewDigestCredentials("user", "password")
+
+	assert.Equal(t, AuthInfo{"digest", []byte("user:password")}, c.AuthInfo())
+	assert.Equal(t, NewDigestAuthInfo("user", "password"), c.AuthInfo())
+
+	// echo -n user:password | openssl dgst -binary -sha1 | openssl base64
+	assert.Equal(t, []zk.ACL{{Perms: zk.PermAll, Scheme: "digest", ID: "user:tpUq/4Pn5A64fVZyQ0gOJ8ZWqkY="}}, c.ACL(zk.PermAll))
+
+	credentials, err := c.Credentials()
+
+	assert.NoError(t, err)
+	assert.Equal(t, []AuthInfo{c.AuthInfo()}, credentials)
+}
+
+func TestDigestFileCredentialsProvider(t *testing.T) {
+	file, err := ioutil.TempFile("", "credentials")
+
+	assert.NoError(t, err)
+
+	file.Close()
+
+	defer os.Remove(file.Name())
+
+	p := NewDigestFileCredentialsProvider(file.Name())
+
+	_, err = p.Credentials()
+
+	assert.Error(t, err, "empty file")
+
+	assert.NoError(t, ioutil.WriteFile(file.Name(), []byte("user:pass:word\n"), 0600))
+
+	credentials, err := p.Credentials()
+
+	assert.NoError(t, err)
+	assert.Equal(t, []AuthInfo{NewDigestAuthInfo("user", "pass:word")}, credentials)
+
+	assert.NoError(t, os.Remove(file.Name()))
+
+	_, err = p.Credentials()
+
+	assert.True(t, os.IsNotExist(err))
+}
+
+func TestCredentialsOnEveryDial(t *testing.T) {
+	ensembleProvider := &mockEnsembleProvider{log: t.Logf}
+	zookeeperDialer := &mockZookeeperDialer{log: t.Logf}
+	conn := &mockConn{log: t.Logf}
+
+	passwords := []string{"pass1", "pass2"}
+
+	client := NewCuratorZookeeperClient(zookeeperDialer, ensembleProvider, DEFAULT_SESSION_TIMEOUT, DEFAULT_CONNECTION_TIMEOUT, nil, nil, false,
+		[]AuthInfo{{"ip", []byte("127.0.0.1")}})
+
+	client.credentialsProvider = CredentialsProviderFunc(func() ([]AuthInfo, error) {
+		if len(passwords) == 0 {
+			return nil, errors.New("no more password")
+		}
+
+		password := passwords[0]
+		passwords = passwords[1:]
+
+		return []AuthInfo{NewDigestAuthInfo("user", password)}, nil
+	})
+
+	ensembleProvider.On("Start").Return(nil).Once()
+	ensembleProvider.On("ConnectionString").Return("connStr")
+	ensembleProvider.On("Close").Return(nil).Once()
+	zookeeperDialer.On("Dial", "connStr", DEFAULT_SESSION_TIMEOUT, false).Return(conn, nil, nil).Times(3)
+	conn.On("AddAuth", "ip", []byte("127.0.0.1")).Return(nil).Times(2)
+	conn.On("AddAuth", "digest", []byte("user:pass1")).Return(nil).Once()
+	conn.On("AddAuth", "digest", []byte("user:pass2")).Return(nil).Once()
+	conn.On("Close").Return().Times(3)
+
+	assert.NoError(t, client.Start())
+
+	// the rotated password is used by the new connection
+	assert.NoError(t, client.state.reset())
+
+	// fail to dial without credentials
+	assert.EqualError(t, client.state.reset(), "fail to get credentials, no more password")
+
+	assert.NoError(t, client.Close())
+
+	ensembleProvider.AssertExpectations(t)
+	zookeeperDialer.AssertExpectations(t)
+	conn.AssertExpectations(t)
+}
+
+func TestAuthFailed(t *testing.T) {
+	ensembleProvider := &mockEnsembleProvider{log: t.Logf}
+	zookeeperDialer := &mockZookeeperDialer{log: t.Logf}
+	conn := &mockConn{log: t.Logf}
+
+	client := (&CuratorFrameworkBuilder{
+		ZookeeperDialer:  zookeeperDialer,
+		EnsembleProvider: ensembleProvider,
+	}).DigestAuthorization("user", "wrong").Build()
+
+	states := make(chan ConnectionState, 1)
+
+	client.ConnectionStateListenable().AddListener(NewConnectionStateListener(func(client CuratorFramework, newState ConnectionState) {
+		states <- newState
+	}))
+
+	ensembleProvider.On("Start").Return(nil).Once()
+	ensembleProvider.On("ConnectionString").Return("connStr")
+	ensembleProvider.On("Close").Return(nil).Once()
+	zookeeperDialer.On("Dial", "connStr", DEFAULT_SESSION_TIMEOUT, false).Return(conn, nil, nil).Once()
+	conn.On("AddAuth", "digest", []byte("user:wrong")).Return(zk.ErrAuthFailed).Once()
+	conn.On("Close").Return().Once()
+
+	assert.Error(t, client.Start())
+
+	select {
+	case state := <-states:
+		assert.Equal(t, AUTH_FAILED, state)
+		assert.False(t, state.Connected())
+		assert.Equal(t, "AUTH_FAILED", state.String())
+	case <-time.After(time.Second):
+		assert.Fail(t, "AUTH_FAILED not reported")
+	}
+
+	assert.NoError(t, client.Close())
+
+	ensembleProvider.AssertExpectations(t)
+	zookeeperDialer.AssertExpectations(t)
+	conn.AssertExpectations(t)
+}
@@ -0,0 +1,189 @@
+package curator
+
+import (
+	"sync"
+
+	"github.com/yxdrlitao/go-zookeeper/zk"
+)
+
+// The max number of reads of a batch in flight over the connection
+const MAX_BATCH_READS = 128
+
+// The result of reading a node in a batch
+type NodeData struct {
+	Data []byte   // the data of the node, decrypted and decompressed as GetData()
+	Stat *zk.Stat // the stat of the node
+	Err  error    // the error of reading the node, e.g. zk.ErrNoNode if it's deleted
+}
+
+type batchGetBuilder struct {
+	client         *curatorFramework
+	decompress     bool
+	skipDecompress bool
+	watching       watching
+}
+
+// Read the nodes concurrently over the connection, each read is retried as GetData().
+//
+// The results are keyed by the given paths,
+// the first error other than zk.ErrNoNode is returned along with the results of all the nodes.
+func (b *batchGetBuilder) ForPaths(paths ...string) (map[string]*NodeData, error) {
+	tracer := b.client.ZookeeperClient().StartTracer("batchGetBuilder.ForPaths")
+
+	defer tracer.Commit()
+
+	results := make([]*NodeData, len(paths))
+	limit := make(chan struct{}, MAX_BATCH_READS)
+
+	var wg sync.WaitGroup
+
+	for i, givenPath := range paths {
+		wg.Add(1)
+
+		limit <- struct{}{}
+
+		go func(i int, givenPath string) {
+			defer func() {
+				<-limit
+
+				wg.Done()
+			}()
+
+			results[i] = b.get(givenPath)
+		}(i, givenPath)
+	}
+
+	wg.Wait()
+
+	var err error
+
+	nodes := make(map[string]*NodeData, len(paths))
+
+	for i, path := range paths {
+		nodes[path] = results[i]
+
+		if err == nil && results[i].Err != nil && results[i].Err != zk.ErrNoNode {
+			err = results[i].Err
+		}
+	}
+
+	return nodes, err
+}
+
+func (b *batchGetBuilder) get(givenPath string) *NodeData {
+	var stat zk.Stat
+
+	builder := &getDataBuilder{
+		client:         b.client,
+		decompress:     b.decompress,
+		skipDecompress: b.skipDecompress,
+		watching:       b.watching,
+		stat:           &stat,
+	}
+
+	adjustedPath, err := b.client.fixForNamespace(givenPath, false)
+
+	if err != nil {
+		return &NodeData{Err: err}
+	}
+
+	data, err := builder.pathInForeground(adjustedPath, givenPath)
+
+	if err != nil {
+		return &NodeData{Err: err}
+	}
+
+	return &NodeData{Data: data, Stat: &stat}
+}
+
+func (b *batchGetBuilder) Decompressed() BatchGetBuilder {
+	b.decompress = true
+
+	return b
+}
+
+func (b *batchGetBuilder) SkipDecompression() BatchGetBuilder {
+	b.skipDecompress = true
+
+	return b
+}
+
+func (b *batchGetBuilder) Watched() BatchGetBuilder {
+	b.watching.watched = true
+
+	return b
+}
+
+func (b *batchGetBuilder) UsingWatcher(watcher Watcher) BatchGetBuilder {
+	b.watching.watcher = b.client.getNamespaceWatcher(watcher)
+
+	return b
+}
+
+type getChildrenWithDataBuilder struct {
+	children *getChildrenBuilder
+	batch    *batchGetBuilder
+}
+
+// Read the children and their data, the results are keyed by the names of the children
+func (b *getChildrenWithDataBuilder) ForPath(givenPath string) (map[string]*NodeData, error) {
+	adjustedPath, err := b.children.client.fixForNamespace(givenPath, false)
+
+	if err != nil {
+		return nil, err
+	}
+
+	children, err := b.children.pathInForeground(adjustedPath)
+
+	if err != nil {
+		return nil, err
+	}
+
+	paths := make([]string, len(children))
+
+	for i, child := range children {
+		paths[i] = JoinPath(givenPath, child)
+	}
+
+	results, err := b.batch.ForPaths(paths...)
+
+	nodes := make(map[string]*NodeData, len(children))
+
+	for i, child := range children {
+		nodes[child] = results[paths[i]]
+	}
+
+	return nodes, err
+}
+
+func (b *getChildrenWithDataBuilder) Decompressed() GetChildrenWithDataBuilder {
+	b.batch.Decompressed()
+
+	return b
+}
+
+func (b *getChildrenWithDataBuilder) SkipDecompression() GetChildrenWithDataBuilder {
+	b.batch.SkipDecompression()
+
+	return b
+}
+
+func (b *getChildrenWithDataBuilder) StoringStatIn(stat *zk.Stat) GetChildrenWithDataBuilder {
+	b.children.StoringStatIn(stat)
+
+	return b
+}
+
+func (b *getChildrenWithDataBuilder) Watched() GetChildrenWithDataBuilder {
+	b.children.Watched()
+	b.batch.Watched()
+
+	return b
+}
+
+func (b *getChildrenWithDataBuilder) UsingWatcher(watcher Watcher) GetChildrenWithDataBuilder {
+	b.children.UsingWatcher(watcher)
+	b.batch.UsingWatcher(watcher)
+
+	return b
+}
@@ -0,0 +1,90 @@
+package curator
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/suite"
+	"github.com/yxdrlitao/go-zookeeper/zk"
+)
+
+type BatchGetBuilderTestSuite struct {
+	mockContainerTestSuite
+}
+
+func TestBatchGetBuilder(t *testing.T) {
+	suite.Run(t, new(BatchGetBuilderTestSuite))
+}
+
+func (s *BatchGetBuilderTestSuite) TestBatchGet() {
+	s.With(func(client CuratorFramework, conn *mockConn, data []byte, stat *zk.Stat) {
+		conn.On("Get", "/node1").Return(data, stat, nil).Once()
+		conn.On("Get", "/node2").Return(nil, nil, zk.ErrNoNode).Once()
+
+		nodes, err := client.BatchGet().ForPaths("/node1", "/node2")
+
+		s.NoError(err)
+		s.Len(nodes, 2)
+		s.Equal(data, nodes["/node1"].Data)
+		s.Equal(stat, nodes["/node1"].Stat)
+		s.NoError(nodes["/node1"].Err)
+		s.Equal(zk.ErrNoNode, nodes["/node2"].Err)
+	})
+}
+
+func (s *BatchGetBuilderTestSuite) TestDecompressed() {
+	s.With(func(client CuratorFramework, conn *mockConn, compress *mockCompressionProvider, data []byte, stat *zk.Stat) {
+		conn.On("Get", "/node").Return([]byte("compressed(data)"), stat, nil).Once()
+		compress.On("Decompress", "/node", []byte("compressed(data)")).Return(data, nil).Once()
+
+		nodes, err := client.BatchGet().Decompressed().ForPaths("/node")
+
+		s.NoError(err)
+		s.Equal(data, nodes["/node"].Data)
+	})
+}
+
+func (s *BatchGetBuilderTestSuite) TestNamespace() {
+	s.WithNamespace("parent", func(client CuratorFramework, conn *mockConn, data []byte, stat *zk.Stat) {
+		conn.On("Exists", "/parent").Return(true, nil, nil).Once()
+		conn.On("Get", "/parent/child").Return(data, stat, nil).Once()
+
+		nodes, err := client.BatchGet().ForPaths("/child")
+
+		s.NoError(err)
+		s.Equal(data, nodes["/child"].Data)
+	})
+}
+
+func (s *BatchGetBuilderTestSuite) TestChildrenWithData() {
+	s.With(func(client CuratorFramework, conn *mockConn, data []byte, stat *zk.Stat) {
+		conn.On("Children", "/parent").Return([]string{"child1", "child2"}, stat, nil).Once()
+		conn.On("Get", "/parent/child1").Return(data, stat, nil).Once()
+		conn.On("Get", "/parent/child2").Return(nil, nil, zk.ErrNoNode).Once()
+
+		var stat2 zk.Stat
+
+		nodes, err := client.GetChildren().WithData().StoringStatIn(&stat2).ForPath("/parent")
+
+		s.NoError(err)
+		s.Equal(stat, &stat2)
+		s.Len(nodes, 2)
+		s.Equal(data, nodes["child1"].Data)
+		s.Equal(zk.ErrNoNode, nodes["child2"].Err)
+	})
+}
+
+func (s *BatchGetBuilderTestSuite) TestWatched() {
+	s.With(func(client CuratorFramework, conn *mockConn, data []byte, stat *zk.Stat) {
+		events := make(chan zk.Event)
+
+		defer close(events)
+
+		conn.On("ChildrenW", "/parent").Return([]string{"child"}, stat, events, nil).Once()
+		conn.On("GetW", "/parent/child").Return(data, stat, events, nil).Once()
+
+		nodes, err := client.GetChildren().WithData().UsingWatcher(NewWatcher(func(event *zk.Event) {})).ForPath("/parent")
+
+		s.NoError(err)
+		s.Equal(data, nodes["child"].Data)
+	})
+}
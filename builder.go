@@ -33,6 +33,14 @@ type CreateBuilder interface {
 	// Cause the data to be compressed using the configured compression provider
 	Compressed() CreateBuilder
 
+	// Don't compress the data even if the compression is enabled for all the operations
+	SkipCompression() CreateBuilder
+
+	// Encodable[T]
+	//
+	// Encode the value with the codec of the client as the data, which takes the place of the data of ForPathWithData()
+	Encoded(v interface{}) CreateBuilder
+
 	// Backgroundable[T]
 	//
 	// Perform the action in the background
@@ -54,6 +62,11 @@ type CheckExistsBuilder interface {
 	// Commit the currently building operation using the given path
 	ForPath(path string) (*zk.Stat, error)
 
+	// Staleable[T]
+	//
+	// Have the operation tell whether the result may be stale as the connection is read-only
+	StoringStaleIn(stale *bool) CheckExistsBuilder
+
 	// Watchable[T]
 	//
 	// Have the operation set a watch
@@ -119,11 +132,24 @@ type GetDataBuilder interface {
 	// Cause the data to be de-compressed using the configured compression provider
 	Decompressed() GetDataBuilder
 
+	// Don't de-compress the data even if the compression is enabled for all the operations
+	SkipDecompression() GetDataBuilder
+
+	// Decodable[T]
+	//
+	// Decode the data with the codec of the client into the value, return *CodecError if failed
+	Into(v interface{}) GetDataBuilder
+
 	// Statable[T]
 	//
 	// Have the operation fill the provided stat object
 	StoringStatIn(stat *zk.Stat) GetDataBuilder
 
+	// Staleable[T]
+	//
+	// Have the operation tell whether the result may be stale as the connection is read-only
+	StoringStaleIn(stale *bool) GetDataBuilder
+
 	// Watchable[T]
 	//
 	// Have the operation set a watch
@@ -147,6 +173,133 @@ type GetDataBuilder interface {
 	InBackgroundWithCallbackAndContext(callback BackgroundCallback, context interface{}) GetDataBuilder
 }
 
+type GetChildrenWithDataBuilder interface {
+	// Pathable[T]
+	//
+	// Commit the currently building operation using the given path,
+	// return the data of the children keyed by their names
+	ForPath(path string) (map[string]*NodeData, error)
+
+	// Decompressible[T]
+	//
+	// Cause the data to be de-compressed using the configured compression provider
+	Decompressed() GetChildrenWithDataBuilder
+
+	// Don't de-compress the data even if the compression is enabled for all the operations
+	SkipDecompression() GetChildrenWithDataBuilder
+
+	// Statable[T]
+	//
+	// Have the operation fill the provided stat object of the parent
+	StoringStatIn(stat *zk.Stat) GetChildrenWithDataBuilder
+
+	// Watchable[T]
+	//
+	// Have the operation set watches on the children list and the data of every child
+	Watched() GetChildrenWithDataBuilder
+
+	// Set a watcher for the children list and the data of every child
+	UsingWatcher(watcher Watcher) GetChildrenWithDataBuilder
+}
+
+type BatchGetBuilder interface {
+	// Commit the currently building operation using the given paths,
+	// return the data of the nodes keyed by the given paths
+	ForPaths(paths ...string) (map[string]*NodeData, error)
+
+	// Decompressible[T]
+	//
+	// Cause the data to be de-compressed using the configured compression provider
+	Decompressed() BatchGetBuilder
+
+	// Don't de-compress the data even if the compression is enabled for all the operations
+	SkipDecompression() BatchGetBuilder
+
+	// Watchable[T]
+	//
+	// Have the operation set watches on all the nodes
+	Watched() BatchGetBuilder
+
+	// Set a watcher for all the nodes
+	UsingWatcher(watcher Watcher) BatchGetBuilder
+}
+
+type MultiReadBuilder interface {
+	// Add a read of the data of the node of the given path
+	GetData(path string) MultiReadBuilder
+
+	// Add a read of the children of the node of the given path
+	GetChildren(path string) MultiReadBuilder
+
+	// Commit the reads as a consistent snapshot, return the results in the order the reads were added.
+	//
+	// The error of reading a node, e.g. zk.ErrNoNode, is returned in its result.
+	Commit() ([]*MultiReadResult, error)
+
+	// Decompressible[T]
+	//
+	// Cause the data to be de-compressed using the configured compression provider
+	Decompressed() MultiReadBuilder
+
+	// Don't de-compress the data even if the compression is enabled for all the operations
+	SkipDecompression() MultiReadBuilder
+
+	// Backgroundable[T]
+	//
+	// Perform the action in the background
+	InBackground() MultiReadBuilder
+
+	// Perform the action in the background
+	InBackgroundWithContext(context interface{}) MultiReadBuilder
+
+	// Perform the action in the background
+	InBackgroundWithCallback(callback BackgroundCallback) MultiReadBuilder
+
+	// Perform the action in the background
+	InBackgroundWithCallbackAndContext(callback BackgroundCallback, context interface{}) MultiReadBuilder
+}
+
+type GetEphemeralsBuilder interface {
+	// Commit the currently building operation using the given prefix,
+	// return the paths of the ephemeral nodes created by this session which start with the prefix
+	ForPrefix(prefix string) ([]string, error)
+
+	// Backgroundable[T]
+	//
+	// Perform the action in the background
+	InBackground() GetEphemeralsBuilder
+
+	// Perform the action in the background
+	InBackgroundWithContext(context interface{}) GetEphemeralsBuilder
+
+	// Perform the action in the background
+	InBackgroundWithCallback(callback BackgroundCallback) GetEphemeralsBuilder
+
+	// Perform the action in the background
+	InBackgroundWithCallbackAndContext(callback BackgroundCallback, context interface{}) GetEphemeralsBuilder
+}
+
+type GetAllChildrenNumberBuilder interface {
+	// Pathable[T]
+	//
+	// Commit the currently building operation using the given path
+	ForPath(path string) (int32, error)
+
+	// Backgroundable[T]
+	//
+	// Perform the action in the background
+	InBackground() GetAllChildrenNumberBuilder
+
+	// Perform the action in the background
+	InBackgroundWithContext(context interface{}) GetAllChildrenNumberBuilder
+
+	// Perform the action in the background
+	InBackgroundWithCallback(callback BackgroundCallback) GetAllChildrenNumberBuilder
+
+	// Perform the action in the background
+	InBackgroundWithCallbackAndContext(callback BackgroundCallback, context interface{}) GetAllChildrenNumberBuilder
+}
+
 type SetDataBuilder interface {
 	// PathAndBytesable[T]
 	//
@@ -166,6 +319,14 @@ type SetDataBuilder interface {
 	// Cause the data to be compressed using the configured compression provider
 	Compressed() SetDataBuilder
 
+	// Don't compress the data even if the compression is enabled for all the operations
+	SkipCompression() SetDataBuilder
+
+	// Encodable[T]
+	//
+	// Encode the value with the codec of the client as the data, which takes the place of the data of ForPathWithData()
+	Encoded(v interface{}) SetDataBuilder
+
 	// Backgroundable[T]
 	//
 	// Perform the action in the background
@@ -192,6 +353,14 @@ type GetChildrenBuilder interface {
 	// Have the operation fill the provided stat object
 	StoringStatIn(stat *zk.Stat) GetChildrenBuilder
 
+	// Read the data of the children concurrently as well
+	WithData() GetChildrenWithDataBuilder
+
+	// Staleable[T]
+	//
+	// Have the operation tell whether the result may be stale as the connection is read-only
+	StoringStaleIn(stale *bool) GetChildrenBuilder
+
 	// Watchable[T]
 	//
 	// Have the operation set a watch
@@ -293,6 +462,79 @@ type SyncBuilder interface {
 	InBackgroundWithCallbackAndContext(callback BackgroundCallback, context interface{}) SyncBuilder
 }
 
+type GetConfigBuilder interface {
+	// Ensembleable[T]
+	//
+	// Commit the currently building operation on the ensemble configuration
+	ForEnsemble() ([]byte, error)
+
+	// Statable[T]
+	//
+	// Have the operation fill the provided stat object
+	StoringStatIn(stat *zk.Stat) GetConfigBuilder
+
+	// Watchable[T]
+	//
+	// Have the operation set a watch
+	Watched() GetConfigBuilder
+
+	// Set a watcher for the operation
+	UsingWatcher(watcher Watcher) GetConfigBuilder
+
+	// Backgroundable[T]
+	//
+	// Perform the action in the background
+	InBackground() GetConfigBuilder
+
+	// Perform the action in the background
+	InBackgroundWithContext(context interface{}) GetConfigBuilder
+
+	// Perform the action in the background
+	InBackgroundWithCallback(callback BackgroundCallback) GetConfigBuilder
+
+	// Perform the action in the background
+	InBackgroundWithCallbackAndContext(callback BackgroundCallback, context interface{}) GetConfigBuilder
+}
+
+type ReconfigBuilder interface {
+	// Ensembleable[T]
+	//
+	// Commit the currently building operation on the ensemble configuration
+	ForEnsemble() (*zk.Stat, error)
+
+	// Incremental reconfiguration: add the servers to the ensemble,
+	// in the form of "server.<id>=<host>:<quorum port>:<election port>[:role];[<client host>:]<client port>"
+	Joining(servers ...string) ReconfigBuilder
+
+	// Incremental reconfiguration: remove the servers with the given ids from the ensemble
+	Leaving(servers ...string) ReconfigBuilder
+
+	// Non-incremental reconfiguration: replace the members of the ensemble with the servers
+	WithNewMembers(servers ...string) ReconfigBuilder
+
+	// Only apply the reconfiguration if the current config version matches, the default is -1
+	FromConfig(version int64) ReconfigBuilder
+
+	// Statable[T]
+	//
+	// Have the operation fill the provided stat object
+	StoringStatIn(stat *zk.Stat) ReconfigBuilder
+
+	// Backgroundable[T]
+	//
+	// Perform the action in the background
+	InBackground() ReconfigBuilder
+
+	// Perform the action in the background
+	InBackgroundWithContext(context interface{}) ReconfigBuilder
+
+	// Perform the action in the background
+	InBackgroundWithCallback(callback BackgroundCallback) ReconfigBuilder
+
+	// Perform the action in the background
+	InBackgroundWithCallbackAndContext(callback BackgroundCallback, context interface{}) ReconfigBuilder
+}
+
 type TransactionCreateBuilder interface {
 	// PathAndBytesable[T]
 	//
@@ -316,6 +558,9 @@ type TransactionCreateBuilder interface {
 	//
 	// Cause the data to be compressed using the configured compression provider
 	Compressed() TransactionCreateBuilder
+
+	// Don't compress the data even if the compression is enabled for all the operations
+	SkipCompression() TransactionCreateBuilder
 }
 
 type TransactionDeleteBuilder interface {
@@ -348,6 +593,9 @@ type TransactionSetDataBuilder interface {
 	//
 	// Cause the data to be compressed using the configured compression provider
 	Compressed() TransactionSetDataBuilder
+
+	// Don't compress the data even if the compression is enabled for all the operations
+	SkipCompression() TransactionSetDataBuilder
 }
 
 type TransactionCheckBuilder interface {
@@ -9,13 +9,18 @@ type getChildrenBuilder struct {
 	backgrounding backgrounding
 	stat          *zk.Stat
 	watching      watching
+	stale         *bool
 }
 
 func (b *getChildrenBuilder) ForPath(givenPath string) ([]string, error) {
-	adjustedPath := b.client.fixForNamespace(givenPath, false)
+	adjustedPath, err := b.client.fixForNamespace(givenPath, false)
+
+	if err != nil {
+		return nil, err
+	}
 
 	if b.backgrounding.inBackground {
-		go b.pathInBackground(adjustedPath, givenPath)
+		b.client.client.inBackground(func() { b.pathInBackground(adjustedPath, givenPath) })
 		return nil, nil
 	}
 
@@ -40,6 +45,7 @@ func (b *getChildrenBuilder) pathInBackground(adjustedPath, givenPath string) {
 			children:  children,
 			stat:      b.stat,
 			context:   b.backgrounding.context,
+			stale:     b.client.ReadOnly(),
 		}
 
 		if err != nil {
@@ -65,15 +71,29 @@ func (b *getChildrenBuilder) pathInForeground(path string) ([]string, error) {
 			var err error
 
 			if b.watching.watched || b.watching.watcher != nil {
+				if err := b.client.schemaSet.ValidateWatch(path); err != nil {
+					return nil, err
+				}
+
 				children, stat, events, err = conn.ChildrenW(path)
 				if events != nil && b.watching.watcher != nil {
-					go NewWatchers(b.watching.watcher).Watch(events)
+					if b.client.chunkSize > 0 {
+						events = b.client.filterChunkEvents(conn, path, visibleChildren(children), events)
+					}
+
+					b.client.client.watch(b.watching.watcher, events)
 				}
 			} else {
 				children, stat, err = conn.Children(path)
 			}
 
+			if b.client.chunkSize > 0 {
+				children = visibleChildren(children)
+			}
+
 			if stat != nil {
+				b.client.client.recordStat(stat)
+
 				if b.stat != nil {
 					*b.stat = *stat
 				} else {
@@ -87,9 +107,22 @@ func (b *getChildrenBuilder) pathInForeground(path string) ([]string, error) {
 
 	children, _ := result.([]string)
 
+	if b.stale != nil {
+		*b.stale = b.client.ReadOnly()
+	}
+
 	return children, err
 }
 
+func (b *getChildrenBuilder) WithData() GetChildrenWithDataBuilder {
+	return &getChildrenWithDataBuilder{children: b, batch: &batchGetBuilder{client: b.client}}
+}
+
+func (b *getChildrenBuilder) StoringStaleIn(stale *bool) GetChildrenBuilder {
+	b.stale = stale
+	return b
+}
+
 func (b *getChildrenBuilder) StoringStatIn(stat *zk.Stat) GetChildrenBuilder {
 	b.stat = stat
 	return b
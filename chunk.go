@@ -0,0 +1,399 @@
+package curator
+
+import (
+	"bytes"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/yxdrlitao/go-zookeeper/zk"
+)
+
+const (
+	DEFAULT_CHUNK_SIZE = 512 * 1024 // well below the default jute.maxbuffer (1MB)
+
+	// The chunked data up to it is created with its chunks in a single transaction, below the default jute.maxbuffer (1MB)
+	MAX_CHUNKED_MULTI_SIZE = 768 * 1024
+
+	CHUNK_PREFIX = "_chunk-" // the prefix of the chunk nodes, which are hidden from GetChildren()
+)
+
+var (
+	// The magic header of the manifest of the chunked data, followed by the manifest in JSON
+	CHUNK_MANIFEST_MAGIC = []byte{0xC7, 'C', 'K'}
+
+	ErrChunkedEphemeral   = errors.New("ephemeral node can't hold the chunks of large data")
+	ErrChunkedTransaction = errors.New("large data can't be chunked in a transaction")
+)
+
+// The manifest stored in the node whose data is split into the chunk nodes,
+// which are the children of the node named as CHUNK_PREFIX + generation + "-" + index.
+//
+// The chunks are created before the manifest is switched to them, the manifest is updated
+// and the chunks of the previous generations are deleted in a single transaction,
+// so the readers always see the complete data of a generation. The chunks replaced by
+// the unchunked data are deleted after it's written, as its readers never look for them.
+type chunkManifest struct {
+	Generation string `json:"generation"`
+	Chunks     int    `json:"chunks"`
+	Size       int    `json:"size"`
+	Checksum   string `json:"sha256"`
+	Pending    bool   `json:"pending,omitempty"` // the node is created, but the chunks are not written yet
+}
+
+func newChunkManifest(payload []byte, chunkSize int) (*chunkManifest, error) {
+	generation := make([]byte, 8)
+
+	if _, err := rand.Read(generation); err != nil {
+		return nil, err
+	}
+
+	checksum := sha256.Sum256(payload)
+
+	return &chunkManifest{
+		Generation: hex.EncodeToString(generation),
+		Chunks:     (len(payload) + chunkSize - 1) / chunkSize,
+		Size:       len(payload),
+		Checksum:   hex.EncodeToString(checksum[:]),
+	}, nil
+}
+
+func parseChunkManifest(data []byte) (*chunkManifest, bool) {
+	if !bytes.HasPrefix(data, CHUNK_MANIFEST_MAGIC) {
+		return nil, false
+	}
+
+	var manifest chunkManifest
+
+	if err := json.Unmarshal(data[len(CHUNK_MANIFEST_MAGIC):], &manifest); err != nil {
+		return nil, false
+	}
+
+	return &manifest, true
+}
+
+func (m *chunkManifest) encode() []byte {
+	data, _ := json.Marshal(m)
+
+	return append(append([]byte{}, CHUNK_MANIFEST_MAGIC...), data...)
+}
+
+func (m *chunkManifest) chunkPath(path string, index int) string {
+	return JoinPath(path, fmt.Sprintf("%s%s-%d", CHUNK_PREFIX, m.Generation, index))
+}
+
+func isChunkNode(name string) bool {
+	return strings.HasPrefix(name, CHUNK_PREFIX)
+}
+
+// Return the children other than the chunk nodes
+func visibleChildren(children []string) []string {
+	visible := make([]string, 0, len(children))
+
+	for _, child := range children {
+		if !isChunkNode(child) {
+			visible = append(visible, child)
+		}
+	}
+
+	return visible
+}
+
+func sameChildren(children, others []string) bool {
+	if len(children) != len(others) {
+		return false
+	}
+
+	names := make(map[string]bool, len(children))
+
+	for _, child := range children {
+		names[child] = true
+	}
+
+	for _, child := range others {
+		if !names[child] {
+			return false
+		}
+	}
+
+	return true
+}
+
+// Swallow the child events of the node fired by its chunks, which are hidden from GetChildren(),
+// and watch the node again until its visible children change.
+func (c *curatorFramework) filterChunkEvents(conn ZookeeperConnection, path string, children []string, events <-chan zk.Event) <-chan zk.Event {
+	filtered := make(chan zk.Event, 1)
+
+	go func() {
+		defer close(filtered)
+
+		for {
+			event, ok := <-events
+
+			if !ok {
+				return
+			}
+
+			if event.Type == zk.EventNodeChildrenChanged {
+				if latest, _, next, err := conn.ChildrenW(path); err == nil && next != nil && sameChildren(children, visibleChildren(latest)) {
+					events = next
+
+					continue
+				}
+			}
+
+			filtered <- event
+
+			return
+		}
+	}()
+
+	return filtered
+}
+
+// Return true if the data should be split into chunks
+func (c *curatorFramework) shouldChunk(payload []byte) bool {
+	return c.chunkSize > 0 && len(payload) > c.chunkSize
+}
+
+// Return the create operations of the chunk nodes of the payload with the ACL of the node
+func (m *chunkManifest) chunkRequests(path string, payload []byte, chunkSize int, acls []zk.ACL) []interface{} {
+	ops := make([]interface{}, m.Chunks)
+
+	for i := range ops {
+		end := (i + 1) * chunkSize
+
+		if end > len(payload) {
+			end = len(payload)
+		}
+
+		ops[i] = &zk.CreateRequest{Path: m.chunkPath(path, i), Data: payload[i*chunkSize : end], Acl: acls, Flags: int32(PERSISTENT)}
+	}
+
+	return ops
+}
+
+// Create the chunk nodes of the payload with the ACL of the node
+func (c *curatorFramework) writeChunks(conn ZookeeperConnection, path string, payload []byte, manifest *chunkManifest, acls []zk.ACL) error {
+	for _, op := range manifest.chunkRequests(path, payload, c.chunkSize, acls) {
+		req := op.(*zk.CreateRequest)
+
+		if _, err := conn.Create(req.Path, req.Data, req.Flags, req.Acl); err != nil {
+			c.deleteChunks(conn, path, manifest)
+
+			return err
+		}
+	}
+
+	return nil
+}
+
+// Delete the chunks of the manifest, e.g. when fail to switch the manifest to them
+func (c *curatorFramework) deleteChunks(conn ZookeeperConnection, path string, manifest *chunkManifest) {
+	for i := 0; i < manifest.Chunks; i++ {
+		if err := conn.Delete(manifest.chunkPath(path, i), AnyVersion); err != nil && err != zk.ErrNoNode {
+			c.logError(fmt.Errorf("fail to delete chunk of %s, %s", path, err))
+		}
+	}
+}
+
+// Reassemble the chunks of the manifest and verify the checksum.
+//
+// The chunks may be replaced by a writer while reading them, then the manifest is read again
+// and the stat is updated to the one of the latest data.
+func (c *curatorFramework) readChunks(conn ZookeeperConnection, path string, manifest *chunkManifest, stat *zk.Stat) ([]byte, error) {
+	for {
+		// the node is being created
+		if manifest.Pending {
+			return nil, zk.ErrNoNode
+		}
+
+		payload := make([]byte, 0, manifest.Size)
+
+		var err error
+
+		for i := 0; i < manifest.Chunks && err == nil; i++ {
+			var data []byte
+
+			if data, _, err = conn.Get(manifest.chunkPath(path, i)); err == nil {
+				payload = append(payload, data...)
+			} else if err != zk.ErrNoNode {
+				return nil, fmt.Errorf("fail to read chunk #%d of %s, %s", i, path, err)
+			}
+		}
+
+		if err == nil {
+			checksum := sha256.Sum256(payload)
+
+			if len(payload) != manifest.Size || hex.EncodeToString(checksum[:]) != manifest.Checksum {
+				return nil, fmt.Errorf("checksum mismatch of the chunked data of %s", path)
+			}
+
+			return payload, nil
+		}
+
+		data, latest, err := conn.Get(path)
+
+		if err != nil {
+			return nil, err
+		}
+
+		if stat != nil && latest != nil {
+			*stat = *latest
+		}
+
+		latestManifest, ok := parseChunkManifest(data)
+
+		if !ok {
+			return data, nil
+		} else if latestManifest.Generation == manifest.Generation {
+			return nil, fmt.Errorf("missing chunks of the chunked data of %s", path)
+		}
+
+		manifest = latestManifest
+	}
+}
+
+// Return the delete operations of the chunks not belonging to the manifest, including the orphans of the failed writers
+func staleChunks(conn ZookeeperConnection, path string, manifest *chunkManifest) ([]interface{}, error) {
+	children, _, err := conn.Children(path)
+
+	if err != nil {
+		return nil, err
+	}
+
+	var ops []interface{}
+
+	for _, child := range children {
+		if isChunkNode(child) && (manifest == nil || !strings.HasPrefix(child, CHUNK_PREFIX+manifest.Generation+"-")) {
+			ops = append(ops, &zk.DeleteRequest{Path: JoinPath(path, child), Version: AnyVersion})
+		}
+	}
+
+	return ops, nil
+}
+
+// Create the node with its chunks in a single transaction if the payload fits in it.
+//
+// Otherwise, create the node with the pending manifest, write the chunks and then complete the manifest.
+func (c *curatorFramework) createChunked(conn ZookeeperConnection, path string, payload []byte, mode CreateMode, acls []zk.ACL, create func(data []byte, chunks ...interface{}) (string, error)) (string, error) {
+	manifest, err := newChunkManifest(payload, c.chunkSize)
+
+	if err != nil {
+		return "", err
+	}
+
+	// the path of the sequential node is unknown before it's created
+	if !mode.IsSequential() && len(payload) <= MAX_CHUNKED_MULTI_SIZE {
+		return create(manifest.encode(), manifest.chunkRequests(path, payload, c.chunkSize, acls)...)
+	}
+
+	manifest.Pending = true
+
+	createdPath, err := create(manifest.encode())
+
+	if err != nil {
+		return createdPath, err
+	}
+
+	if err = c.writeChunks(conn, createdPath, payload, manifest, acls); err == nil {
+		manifest.Pending = false
+
+		if _, err = conn.Set(createdPath, manifest.encode(), 0); err != nil {
+			c.deleteChunks(conn, createdPath, manifest)
+		}
+	}
+
+	if err != nil {
+		conn.Delete(createdPath, AnyVersion)
+	}
+
+	return createdPath, err
+}
+
+// Set the data of the node, split the payload into chunks with the ACL of the node if needed, and delete the stale chunks.
+//
+// Only the node with children may have the stale chunks, so the children are listed only if the stat of the node has any.
+func (c *curatorFramework) setChunked(conn ZookeeperConnection, path string, payload []byte, version int32) (*zk.Stat, error) {
+	if !c.shouldChunk(payload) {
+		stat, err := conn.Set(path, payload, version)
+
+		// the stale chunks are ignored by the readers of the unchunked data, delete them unless the data is replaced again
+		if err == nil && stat != nil && stat.NumChildren > 0 {
+			if ops, err := staleChunks(conn, path, nil); err == nil && len(ops) > 0 {
+				if _, err := conn.Multi(append([]interface{}{&zk.CheckVersionRequest{Path: path, Version: stat.Version}}, ops...)...); err != nil && err != zk.ErrBadVersion {
+					c.logError(fmt.Errorf("fail to delete stale chunks of %s, %s", path, err))
+				}
+			}
+		}
+
+		return stat, err
+	}
+
+	acls, current, err := conn.GetACL(path)
+
+	if err != nil {
+		return nil, err
+	}
+
+	manifest, err := newChunkManifest(payload, c.chunkSize)
+
+	if err != nil {
+		return nil, err
+	} else if err = c.writeChunks(conn, path, payload, manifest, acls); err != nil {
+		return nil, err
+	}
+
+	var stat *zk.Stat
+	var ops []interface{}
+	var responses []zk.MultiResponse
+
+	if current != nil && current.NumChildren == 0 {
+		stat, err = conn.Set(path, manifest.encode(), version)
+	} else if ops, err = staleChunks(conn, path, manifest); err != nil {
+		// the node doesn't exist or is inaccessible
+	} else if len(ops) == 0 {
+		stat, err = conn.Set(path, manifest.encode(), version)
+	} else if responses, err = conn.Multi(append([]interface{}{&zk.SetDataRequest{Path: path, Data: manifest.encode(), Version: version}}, ops...)...); err == nil {
+		stat = responses[0].Stat
+	}
+
+	if err != nil {
+		c.deleteChunks(conn, path, manifest)
+	}
+
+	return stat, err
+}
+
+// Delete the node with its chunks if it holds the manifest of the chunked data
+func (c *curatorFramework) deleteChunked(conn ZookeeperConnection, path string, version int32) error {
+	if data, _, err := conn.Get(path); err != nil {
+		return err
+	} else if _, ok := parseChunkManifest(data); !ok {
+		return zk.ErrNotEmpty
+	}
+
+	children, _, err := conn.Children(path)
+
+	if err != nil {
+		return err
+	}
+
+	var ops []interface{}
+
+	for _, child := range children {
+		if !isChunkNode(child) {
+			return zk.ErrNotEmpty
+		}
+
+		ops = append(ops, &zk.DeleteRequest{Path: JoinPath(path, child), Version: AnyVersion})
+	}
+
+	_, err = conn.Multi(append(ops, &zk.DeleteRequest{Path: path, Version: version})...)
+
+	return err
+}
@@ -0,0 +1,341 @@
+package curator
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/suite"
+	"github.com/yxdrlitao/go-zookeeper/zk"
+)
+
+func TestChunkManifest(t *testing.T) {
+	m, err := newChunkManifest([]byte("0123456789"), 4)
+
+	assert.NoError(t, err)
+	assert.Equal(t, 3, m.Chunks)
+	assert.Equal(t, 10, m.Size)
+	assert.Len(t, m.Generation, 16)
+	assert.Equal(t, "/node/"+CHUNK_PREFIX+m.Generation+"-2", m.chunkPath("/node", 2))
+
+	m2, ok := parseChunkManifest(m.encode())
+
+	assert.True(t, ok)
+	assert.Equal(t, m, m2)
+
+	_, ok = parseChunkManifest([]byte("data"))
+
+	assert.False(t, ok)
+
+	_, ok = parseChunkManifest(append(append([]byte{}, CHUNK_MANIFEST_MAGIC...), "broken"...))
+
+	assert.False(t, ok)
+}
+
+type ChunkingTestSuite struct {
+	mockContainerTestSuite
+
+	nodes map[string][]byte // the data written by the client
+}
+
+func TestChunking(t *testing.T) {
+	suite.Run(t, new(ChunkingTestSuite))
+}
+
+func (s *ChunkingTestSuite) SetupTest() {
+	s.nodes = make(map[string][]byte)
+}
+
+func (s *ChunkingTestSuite) enableChunking(builder *CuratorFrameworkBuilder) {
+	builder.EnableChunking(4)
+}
+
+func (s *ChunkingTestSuite) chunkPath(prefix string) interface{} {
+	return mock.MatchedBy(func(path string) bool {
+		return strings.HasPrefix(path, prefix+"/"+CHUNK_PREFIX)
+	})
+}
+
+func (s *ChunkingTestSuite) store(args mock.Arguments) {
+	s.nodes[args.String(0)] = args.Get(1).([]byte)
+}
+
+func (s *ChunkingTestSuite) TestCreateAndGetData() {
+	s.WithPrepare(s.enableChunking, func(client CuratorFramework, conn *mockConn, aclProvider *mockACLProvider, acls []zk.ACL, stat *zk.Stat) {
+		// the chunks have the ACL of the node instead of the one from the provider
+		aclProvider.On("GetAclForPath", "/node").Return(zk.WorldACL(zk.PermAll)).Maybe()
+		conn.On("Multi", mock.Anything).Return([]zk.MultiResponse{{String: "/node"}, {}, {}, {}}, nil).Once()
+
+		path, err := client.Create().WithACL(acls...).ForPathWithData("/node", []byte("0123456789"))
+
+		s.NoError(err)
+		s.Equal("/node", path)
+
+		// the node is created with its chunks in a single transaction
+		s.Len(conn.operations, 4)
+
+		for _, op := range conn.operations {
+			req := op.(*zk.CreateRequest)
+
+			s.Equal(acls, req.Acl)
+			s.Equal(int32(PERSISTENT), req.Flags)
+
+			s.nodes[req.Path] = req.Data
+		}
+
+		manifest, ok := parseChunkManifest(s.nodes["/node"])
+
+		s.True(ok)
+		s.False(manifest.Pending)
+		s.Equal("/node", conn.operations[0].(*zk.CreateRequest).Path)
+		s.Equal("0123", string(s.nodes[manifest.chunkPath("/node", 0)]))
+		s.Equal("89", string(s.nodes[manifest.chunkPath("/node", 2)]))
+
+		conn.On("Get", "/node").Return(s.nodes["/node"], stat, nil).Once()
+
+		for i := 0; i < manifest.Chunks; i++ {
+			conn.On("Get", manifest.chunkPath("/node", i)).Return(s.nodes[manifest.chunkPath("/node", i)], stat, nil).Once()
+		}
+
+		data, err := client.GetData().ForPath("/node")
+
+		s.NoError(err)
+		s.Equal("0123456789", string(data))
+
+		// the sequential node is created with the pending manifest before the chunks
+		conn.On("Create", "/seq-", mock.Anything, int32(PERSISTENT_SEQUENTIAL), acls).Return("/seq-0000000001", nil).Run(s.store).Once()
+		conn.On("Create", s.chunkPath("/seq-0000000001"), mock.Anything, int32(PERSISTENT), acls).Return("", nil).Run(s.store).Times(3)
+		conn.On("Set", "/seq-0000000001", mock.Anything, int32(0)).Return(stat, nil).Run(s.store).Once()
+
+		path, err = client.Create().WithMode(PERSISTENT_SEQUENTIAL).WithACL(acls...).ForPathWithData("/seq-", []byte("0123456789"))
+
+		s.NoError(err)
+		s.Equal("/seq-0000000001", path)
+
+		manifest, ok = parseChunkManifest(s.nodes["/seq-"])
+
+		s.True(ok)
+		s.True(manifest.Pending)
+
+		manifest, ok = parseChunkManifest(s.nodes["/seq-0000000001"])
+
+		s.True(ok)
+		s.False(manifest.Pending)
+		s.Equal("0123", string(s.nodes[manifest.chunkPath("/seq-0000000001", 0)]))
+
+		// the small data is stored as it is
+		conn.On("Create", "/small", []byte("data"), int32(PERSISTENT), acls).Return("/small", nil).Once()
+
+		_, err = client.Create().WithACL(acls...).ForPathWithData("/small", []byte("data"))
+
+		s.NoError(err)
+	})
+}
+
+func (s *ChunkingTestSuite) TestGetCorruptedData() {
+	s.WithPrepare(s.enableChunking, func(client CuratorFramework, conn *mockConn, stat *zk.Stat) {
+		manifest, err := newChunkManifest([]byte("01234567"), 4)
+
+		s.NoError(err)
+
+		conn.On("Get", "/node").Return(manifest.encode(), stat, nil).Once()
+		conn.On("Get", manifest.chunkPath("/node", 0)).Return([]byte("0123"), stat, nil).Once()
+		conn.On("Get", manifest.chunkPath("/node", 1)).Return([]byte("4560"), stat, nil).Once()
+
+		_, err = client.GetData().ForPath("/node")
+
+		s.EqualError(err, "checksum mismatch of the chunked data of /node")
+
+		// the node is being created
+		manifest.Pending = true
+
+		conn.On("Get", "/pending").Return(manifest.encode(), stat, nil).Once()
+
+		_, err = client.GetData().ForPath("/pending")
+
+		s.Equal(zk.ErrNoNode, err)
+	})
+}
+
+func (s *ChunkingTestSuite) TestGetReplacedData() {
+	s.WithPrepare(s.enableChunking, func(client CuratorFramework, conn *mockConn) {
+		oldManifest, _ := newChunkManifest([]byte("01234567"), 4)
+		newManifest, _ := newChunkManifest([]byte("abcdefgh"), 4)
+		oldStat, newStat := &zk.Stat{Version: 1}, &zk.Stat{Version: 2}
+
+		// the chunks are replaced by a writer while reading them
+		conn.On("Get", "/node").Return(oldManifest.encode(), oldStat, nil).Once()
+		conn.On("Get", oldManifest.chunkPath("/node", 0)).Return([]byte("0123"), oldStat, nil).Once()
+		conn.On("Get", oldManifest.chunkPath("/node", 1)).Return(nil, nil, zk.ErrNoNode).Once()
+		conn.On("Get", "/node").Return(newManifest.encode(), newStat, nil).Once()
+		conn.On("Get", newManifest.chunkPath("/node", 0)).Return([]byte("abcd"), newStat, nil).Once()
+		conn.On("Get", newManifest.chunkPath("/node", 1)).Return([]byte("efgh"), newStat, nil).Once()
+
+		var stat zk.Stat
+
+		data, err := client.GetData().StoringStatIn(&stat).ForPath("/node")
+
+		s.NoError(err)
+		s.Equal("abcdefgh", string(data))
+		s.Equal(int32(2), stat.Version)
+
+		// the chunks are replaced by the small data
+		conn.On("Get", "/small").Return(oldManifest.encode(), oldStat, nil).Once()
+		conn.On("Get", oldManifest.chunkPath("/small", 0)).Return(nil, nil, zk.ErrNoNode).Once()
+		conn.On("Get", "/small").Return([]byte("data"), newStat, nil).Once()
+
+		data, err = client.GetData().ForPath("/small")
+
+		s.NoError(err)
+		s.Equal("data", string(data))
+
+		// the chunks of the current manifest are missing
+		conn.On("Get", "/broken").Return(oldManifest.encode(), oldStat, nil).Twice()
+		conn.On("Get", oldManifest.chunkPath("/broken", 0)).Return(nil, nil, zk.ErrNoNode).Once()
+
+		_, err = client.GetData().ForPath("/broken")
+
+		s.EqualError(err, "missing chunks of the chunked data of /broken")
+	})
+}
+
+func (s *ChunkingTestSuite) TestSetData() {
+	s.WithPrepare(s.enableChunking, func(client CuratorFramework, conn *mockConn, aclProvider *mockACLProvider, acls []zk.ACL, stat *zk.Stat) {
+		aclProvider.On("GetAclForPath", mock.Anything).Return(zk.WorldACL(zk.PermAll)).Maybe()
+		conn.On("GetACL", "/node").Return(acls, &zk.Stat{NumChildren: 3}, nil).Once()
+		conn.On("Create", s.chunkPath("/node"), mock.Anything, int32(PERSISTENT), acls).Return("", nil).Run(s.store).Twice()
+		conn.On("Children", "/node").Return([]string{"child", CHUNK_PREFIX + "old-0", CHUNK_PREFIX + "old-1"}, stat, nil).Once()
+		conn.On("Multi", mock.Anything).Return([]zk.MultiResponse{{Stat: stat}, {}, {}}, nil).Once()
+
+		stat2, err := client.SetData().WithVersion(3).ForPathWithData("/node", []byte("01234567"))
+
+		s.NoError(err)
+		s.Equal(stat, stat2)
+		s.Len(conn.operations, 3)
+
+		manifest, ok := parseChunkManifest(conn.operations[0].(*zk.SetDataRequest).Data)
+
+		s.True(ok)
+		s.Equal(int32(3), conn.operations[0].(*zk.SetDataRequest).Version)
+		s.Equal(2, manifest.Chunks)
+		s.Contains(s.nodes, manifest.chunkPath("/node", 1))
+		s.Equal(&zk.DeleteRequest{Path: "/node/" + CHUNK_PREFIX + "old-0", Version: AnyVersion}, conn.operations[1])
+		s.Equal(&zk.DeleteRequest{Path: "/node/" + CHUNK_PREFIX + "old-1", Version: AnyVersion}, conn.operations[2])
+
+		// the new chunks are deleted if fail to switch the manifest
+		conn.On("Create", s.chunkPath("/other"), mock.Anything, int32(PERSISTENT), acls).Return("", nil).Twice()
+		conn.On("GetACL", "/other").Return(acls, stat, nil).Once()
+		conn.On("Set", "/other", mock.Anything, int32(3)).Return(nil, zk.ErrBadVersion).Once()
+		conn.On("Delete", s.chunkPath("/other"), int32(AnyVersion)).Return(nil).Twice()
+
+		_, err = client.SetData().WithVersion(3).ForPathWithData("/other", []byte("01234567"))
+
+		s.Equal(zk.ErrBadVersion, err)
+
+		// the small data replaces the chunks, which are deleted after it's written
+		conn.On("Set", "/small", []byte("data"), int32(AnyVersion)).Return(&zk.Stat{Version: 5, NumChildren: 1}, nil).Once()
+		conn.On("Children", "/small").Return([]string{CHUNK_PREFIX + "old-0"}, stat, nil).Once()
+		conn.On("Multi", mock.Anything).Return([]zk.MultiResponse{{}, {}}, nil).Once()
+
+		_, err = client.SetData().ForPathWithData("/small", []byte("data"))
+
+		s.NoError(err)
+		s.Equal([]interface{}{
+			&zk.CheckVersionRequest{Path: "/small", Version: 5},
+			&zk.DeleteRequest{Path: "/small/" + CHUNK_PREFIX + "old-0", Version: AnyVersion},
+		}, conn.operations[3:])
+
+		// the children of the node without children are never listed
+		conn.On("Set", "/plain", []byte("data"), int32(AnyVersion)).Return(stat, nil).Once()
+
+		_, err = client.SetData().ForPathWithData("/plain", []byte("data"))
+
+		s.NoError(err)
+	})
+}
+
+func (s *ChunkingTestSuite) TestChildrenAndDelete() {
+	s.WithPrepare(s.enableChunking, func(client CuratorFramework, conn *mockConn, stat *zk.Stat) {
+		conn.On("Children", "/node").Return([]string{CHUNK_PREFIX + "gen-0", "child"}, stat, nil).Once()
+
+		children, err := client.GetChildren().ForPath("/node")
+
+		s.NoError(err)
+		s.Equal([]string{"child"}, children)
+
+		manifest := (&chunkManifest{Generation: "gen", Chunks: 1}).encode()
+
+		// the children of the node without the manifest are never listed
+		conn.On("Delete", "/node", int32(AnyVersion)).Return(zk.ErrNotEmpty).Times(3)
+		conn.On("Get", "/node").Return([]byte("data"), stat, nil).Once()
+
+		s.Equal(zk.ErrNotEmpty, client.Delete().ForPath("/node"))
+
+		conn.On("Get", "/node").Return(manifest, stat, nil).Twice()
+		conn.On("Children", "/node").Return([]string{CHUNK_PREFIX + "gen-0", "child"}, stat, nil).Once()
+
+		s.Equal(zk.ErrNotEmpty, client.Delete().ForPath("/node"))
+
+		conn.On("Children", "/node").Return([]string{CHUNK_PREFIX + "gen-0"}, stat, nil).Once()
+		conn.On("Multi", mock.Anything).Return([]zk.MultiResponse{{}, {}}, nil).Once()
+
+		s.NoError(client.Delete().ForPath("/node"))
+		s.Equal([]interface{}{
+			&zk.DeleteRequest{Path: "/node/" + CHUNK_PREFIX + "gen-0", Version: AnyVersion},
+			&zk.DeleteRequest{Path: "/node", Version: AnyVersion},
+		}, conn.operations)
+	})
+}
+
+func (s *ChunkingTestSuite) TestWatchChildren() {
+	s.WithPrepare(s.enableChunking, func(client CuratorFramework, conn *mockConn, stat *zk.Stat) {
+		events := []chan zk.Event{make(chan zk.Event, 1), make(chan zk.Event, 1), make(chan zk.Event, 1)}
+		fired := make(chan *zk.Event, 2)
+
+		conn.On("ChildrenW", "/node").Return([]string{"child"}, stat, events[0], nil).Once()
+		conn.On("ChildrenW", "/node").Return([]string{CHUNK_PREFIX + "gen-0", "child"}, stat, events[1], nil).Once()
+		conn.On("ChildrenW", "/node").Return([]string{CHUNK_PREFIX + "gen-0", "child", "other"}, stat, events[2], nil).Once()
+
+		children, err := client.GetChildren().UsingWatcher(NewWatcher(func(event *zk.Event) {
+			fired <- event
+		})).ForPath("/node")
+
+		s.NoError(err)
+		s.Equal([]string{"child"}, children)
+
+		// the event fired by the chunk is swallowed, and the node is watched again
+		events[0] <- zk.Event{Type: zk.EventNodeChildrenChanged, Path: "/node"}
+		events[1] <- zk.Event{Type: zk.EventNodeChildrenChanged, Path: "/node"}
+
+		select {
+		case event := <-fired:
+			s.Equal(zk.EventNodeChildrenChanged, event.Type)
+		case <-time.After(time.Second):
+			s.Fail("event not fired")
+		}
+
+		s.Empty(fired)
+		conn.AssertNumberOfCalls(s.T(), "ChildrenW", 3)
+	})
+}
+
+func (s *ChunkingTestSuite) TestUnsupported() {
+	s.WithPrepare(s.enableChunking, func(client CuratorFramework, acls []zk.ACL) {
+		_, err := client.Create().WithMode(EPHEMERAL).WithACL(acls...).ForPathWithData("/node", []byte("0123456789"))
+
+		s.Equal(ErrChunkedEphemeral, err)
+
+		_, err = client.InTransaction().SetData().ForPathWithData("/node", []byte("0123456789")).Commit()
+
+		s.Equal(ErrChunkedTransaction, err)
+	})
+}
+
+func TestEnableChunking(t *testing.T) {
+	builder := &CuratorFrameworkBuilder{}
+
+	assert.Equal(t, DEFAULT_CHUNK_SIZE, builder.EnableChunking(0).ChunkSize)
+	assert.Equal(t, 1024, builder.EnableChunking(1024).ChunkSize)
+}
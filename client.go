@@ -6,6 +6,7 @@ import (
 	"log"
 	"net"
 	"strings"
+	"sync/atomic"
 	"time"
 
 	"github.com/yxdrlitao/go-zookeeper/zk"
@@ -63,6 +64,23 @@ type ZookeeperConnection interface {
 
 	// Flushes channel between process and leader.
 	Sync(path string) (string, error)
+
+	// Add and remove servers of the ensemble, the version -1 matches any config version.
+	IncrementalReconfig(joining, leaving []string, version int64) (*zk.Stat, error)
+
+	// Replace the members of the ensemble, the version -1 matches any config version.
+	Reconfig(members []string, version int64) (*zk.Stat, error)
+
+	// Executes multiple read operations as a consistent snapshot, the operations are *GetDataRequest or *GetChildrenRequest.
+	//
+	// The error of each operation is returned in its response.
+	MultiRead(ops ...interface{}) ([]MultiReadResponse, error)
+
+	// Return the paths of the ephemeral nodes created by this session which start with the given prefix.
+	GetEphemerals(prefix string) ([]string, error)
+
+	// Return the number of all the descendants of the node of the given path.
+	GetAllChildrenNumber(path string) (int32, error)
 }
 
 // Allocate a new ZooKeeper connection
@@ -90,7 +108,35 @@ type DefaultZookeeperDialer struct {
 
 func (d *DefaultZookeeperDialer) Dial(connString string, sessionTimeout time.Duration, canBeReadOnly bool) (ZookeeperConnection, <-chan zk.Event, error) {
 	fmt.Printf("Dialing: %s, %+v, %+v", connString, sessionTimeout, d.Dialer)
-	return zk.ConnectWithDialer(strings.Split(connString, ","), sessionTimeout, d.Dialer)
+	conn, events, err := zk.ConnectWithDialer(strings.Split(connString, ","), sessionTimeout, d.Dialer)
+
+	if err != nil {
+		return nil, events, err
+	}
+
+	return NewZookeeperConnection(conn), events, nil
+}
+
+// A ZookeeperConnection over *zk.Conn, emulating the reads of ZooKeeper 3.6 which *zk.Conn doesn't support
+type zookeeperConnection struct {
+	*zk.Conn
+}
+
+// Wrap a *zk.Conn as ZookeeperConnection, e.g. in a ZookeeperDialFunc
+func NewZookeeperConnection(conn *zk.Conn) ZookeeperConnection {
+	return &zookeeperConnection{conn}
+}
+
+func (c *zookeeperConnection) MultiRead(ops ...interface{}) ([]MultiReadResponse, error) {
+	return emulateMultiRead(c, ops...)
+}
+
+func (c *zookeeperConnection) GetEphemerals(prefix string) ([]string, error) {
+	return emulateGetEphemerals(c, c.SessionID(), prefix)
+}
+
+func (c *zookeeperConnection) GetAllChildrenNumber(path string) (int32, error) {
+	return emulateGetAllChildrenNumber(c, path)
 }
 
 // A wrapper around Zookeeper that takes care of some low-level housekeeping
@@ -118,14 +164,23 @@ type CuratorZookeeperClient interface {
 
 	// Start a new tracer
 	StartTracer(name string) Tracer
+
+	// Return a snapshot of the session and connection
+	Info() *ZookeeperClientInfo
 }
 
 type curatorZookeeperClient struct {
-	state        *connectionState
-	watcher      Watcher
-	started      AtomicBool
-	TracerDriver TracerDriver
-	retryPolicy  RetryPolicy
+	outstandingWatches   int64
+	backgroundOperations int64
+	lastZxid             int64
+	state                *connectionState
+	watcher              Watcher
+	started              AtomicBool
+	TracerDriver         TracerDriver
+	retryPolicy          RetryPolicy
+	authInfos            []AuthInfo
+	credentialsProvider  CredentialsProvider
+	admission            *admission
 }
 
 func NewCuratorZookeeperClient(zookeeperDialer ZookeeperDialer, ensembleProvider EnsembleProvider, sessionTimeout, connectionTimeout time.Duration,
@@ -139,29 +194,58 @@ func NewCuratorZookeeperClient(zookeeperDialer ZookeeperDialer, ensembleProvider
 		zookeeperDialer = &DefaultZookeeperDialer{Dialer: net.DialTimeout}
 	}
 
+	tracer := newDefaultTracerDriver()
+
+	c := &curatorZookeeperClient{
+		TracerDriver: tracer,
+		retryPolicy:  retryPolicy,
+		authInfos:    authInfos,
+	}
+
 	dialer := NewZookeeperDialer(func(connString string, sessionTimeout time.Duration, canBeReadOnly bool) (conn ZookeeperConnection, events <-chan zk.Event, err error) {
 		conn, events, err = zookeeperDialer.Dial(connString, sessionTimeout, canBeReadOnly)
 
 		if err == nil && conn != nil {
-			for _, authInfo := range authInfos {
-				if err := conn.AddAuth(authInfo.Scheme, authInfo.Auth); err != nil {
-					conn.Close()
+			if err := c.authenticate(conn); err != nil {
+				conn.Close()
 
-					return nil, nil, err
-				}
+				return nil, nil, err
 			}
 		}
 
 		return
 	})
 
-	tracer := newDefaultTracerDriver()
+	c.state = newConnectionState(dialer, ensembleProvider, sessionTimeout, connectionTimeout, watcher, tracer, canReadOnly)
 
-	return &curatorZookeeperClient{
-		state:        newConnectionState(dialer, ensembleProvider, sessionTimeout, connectionTimeout, watcher, tracer, canReadOnly),
-		TracerDriver: tracer,
-		retryPolicy:  retryPolicy,
+	return c
+}
+
+// Add the static and the provided credentials to a new connection
+func (c *curatorZookeeperClient) authenticate(conn ZookeeperConnection) error {
+	authInfos := c.authInfos
+
+	if c.credentialsProvider != nil {
+		if credentials, err := c.credentialsProvider.Credentials(); err != nil {
+			return fmt.Errorf("fail to get credentials, %s", err)
+		} else {
+			authInfos = append(authInfos[:len(authInfos):len(authInfos)], credentials...)
+		}
 	}
+
+	for _, authInfo := range authInfos {
+		if err := conn.AddAuth(authInfo.Scheme, authInfo.Auth); err != nil {
+			if err == zk.ErrAuthFailed {
+				c.TracerDriver.AddCount("auth-failed", 1)
+
+				c.state.parentWatchers.Fire(&zk.Event{Type: zk.EventSession, State: zk.StateAuthFailed, Err: err})
+			}
+
+			return err
+		}
+	}
+
+	return nil
 }
 
 func (c *curatorZookeeperClient) Start() error {
@@ -203,13 +287,70 @@ func (c *curatorZookeeperClient) Conn() (ZookeeperConnection, error) {
 		return nil, errors.New("Client is not started")
 	}
 
-	return c.state.Conn()
+	conn, err := c.state.Conn()
+
+	if err == nil && conn != nil && c.admission != nil {
+		return &admittedConnection{conn, c.admission}, nil
+	}
+
+	return conn, err
 }
 
 func (c *curatorZookeeperClient) InstanceIndex() int64 {
 	return c.state.InstanceIndex()
 }
 
+// Return the number of watchers registered by the framework which have not fired yet
+func (c *curatorZookeeperClient) OutstandingWatches() int64 {
+	return atomic.LoadInt64(&c.outstandingWatches)
+}
+
+// Return the number of background operations which have not completed yet
+func (c *curatorZookeeperClient) BackgroundOperations() int64 {
+	return atomic.LoadInt64(&c.backgroundOperations)
+}
+
+// Keep track of the last zxid seen in the stats received from ZooKeeper
+func (c *curatorZookeeperClient) recordStat(stat *zk.Stat) {
+	if stat == nil {
+		return
+	}
+
+	zxid := stat.Mzxid
+
+	if stat.Pzxid > zxid {
+		zxid = stat.Pzxid
+	}
+
+	for last := atomic.LoadInt64(&c.lastZxid); zxid > last; last = atomic.LoadInt64(&c.lastZxid) {
+		if atomic.CompareAndSwapInt64(&c.lastZxid, last, zxid) {
+			return
+		}
+	}
+}
+
+// Run the operation in the background and keep track of it until it completes
+func (c *curatorZookeeperClient) inBackground(operation func()) {
+	atomic.AddInt64(&c.backgroundOperations, 1)
+
+	go func() {
+		defer atomic.AddInt64(&c.backgroundOperations, -1)
+
+		operation()
+	}()
+}
+
+// Deliver the watch events to the watcher and keep track of it until the watch fires
+func (c *curatorZookeeperClient) watch(watcher Watcher, events <-chan zk.Event) {
+	atomic.AddInt64(&c.outstandingWatches, 1)
+
+	go func() {
+		defer atomic.AddInt64(&c.outstandingWatches, -1)
+
+		NewWatchers(watcher).Watch(events)
+	}()
+}
+
 func (c *curatorZookeeperClient) BlockUntilConnectedOrTimedOut() error {
 	if !c.started.Load() {
 		return errors.New("Client is not started")
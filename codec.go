@@ -0,0 +1,140 @@
+package curator
+
+import (
+	"bytes"
+	"encoding/gob"
+	"encoding/json"
+	"fmt"
+)
+
+// Encode the values into the data of znodes and decode them back,
+// which is used by Encoded() and Into() of the builders and by the typed accessors of the caches.
+//
+// The encoded data is compressed and encrypted as the other data.
+type Codec interface {
+	Marshal(v interface{}) ([]byte, error)
+
+	Unmarshal(data []byte, v interface{}) error
+}
+
+// Adapt the functions to Codec, e.g. proto.Marshal and proto.Unmarshal
+type CodecFuncs struct {
+	MarshalFunc   func(v interface{}) ([]byte, error)
+	UnmarshalFunc func(data []byte, v interface{}) error
+}
+
+func NewCodec(marshal func(v interface{}) ([]byte, error), unmarshal func(data []byte, v interface{}) error) *CodecFuncs {
+	return &CodecFuncs{marshal, unmarshal}
+}
+
+func (c *CodecFuncs) Marshal(v interface{}) ([]byte, error) {
+	return c.MarshalFunc(v)
+}
+
+func (c *CodecFuncs) Unmarshal(data []byte, v interface{}) error {
+	return c.UnmarshalFunc(data, v)
+}
+
+type JSONCodec struct{}
+
+func NewJSONCodec() *JSONCodec {
+	return &JSONCodec{}
+}
+
+func (c *JSONCodec) Marshal(v interface{}) ([]byte, error) {
+	return json.Marshal(v)
+}
+
+func (c *JSONCodec) Unmarshal(data []byte, v interface{}) error {
+	return json.Unmarshal(data, v)
+}
+
+type GobCodec struct{}
+
+func NewGobCodec() *GobCodec {
+	return &GobCodec{}
+}
+
+func (c *GobCodec) Marshal(v interface{}) ([]byte, error) {
+	var buf bytes.Buffer
+
+	if err := gob.NewEncoder(&buf).Encode(v); err != nil {
+		return nil, err
+	}
+
+	return buf.Bytes(), nil
+}
+
+func (c *GobCodec) Unmarshal(data []byte, v interface{}) error {
+	return gob.NewDecoder(bytes.NewReader(data)).Decode(v)
+}
+
+// The message marshals itself, e.g. the protobuf messages generated by gogo/protobuf
+type ProtoMessage interface {
+	Marshal() ([]byte, error)
+
+	Unmarshal(data []byte) error
+}
+
+// Codec of the values implementing ProtoMessage,
+// use NewCodec() to adapt the other protobuf libraries, e.g. NewCodec(proto.Marshal, proto.Unmarshal) with wrappers.
+type ProtoCodec struct{}
+
+func NewProtoCodec() *ProtoCodec {
+	return &ProtoCodec{}
+}
+
+func (c *ProtoCodec) Marshal(v interface{}) ([]byte, error) {
+	if msg, ok := v.(ProtoMessage); ok {
+		return msg.Marshal()
+	}
+
+	return nil, fmt.Errorf("%T is not a proto message", v)
+}
+
+func (c *ProtoCodec) Unmarshal(data []byte, v interface{}) error {
+	if msg, ok := v.(ProtoMessage); ok {
+		return msg.Unmarshal(data)
+	}
+
+	return fmt.Errorf("%T is not a proto message", v)
+}
+
+// The error of encoding or decoding the data of a node
+type CodecError struct {
+	Path   string // the path of the node
+	Decode bool   // decoding or encoding
+	Err    error  // the error returned by the codec
+}
+
+func (e *CodecError) Error() string {
+	if e.Decode {
+		return fmt.Sprintf("fail to decode data of %s, %s", e.Path, e.Err)
+	}
+
+	return fmt.Sprintf("fail to encode data of %s, %s", e.Path, e.Err)
+}
+
+func (e *CodecError) Unwrap() error {
+	return e.Err
+}
+
+// Decode the data of the node into v, return *CodecError if failed
+func DecodeData(codec Codec, path string, data []byte, v interface{}) error {
+	if err := codec.Unmarshal(data, v); err != nil {
+		return &CodecError{path, true, err}
+	}
+
+	return nil
+}
+
+// Encode v into the data of the node, return *CodecError if failed
+func EncodeData(codec Codec, path string, v interface{}) ([]byte, error) {
+	data, err := codec.Marshal(v)
+
+	if err != nil {
+		return nil, &CodecError{path, false, err}
+	}
+
+	return data, nil
+}
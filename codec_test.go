@@ -0,0 +1,156 @@
+package curator
+
+import (
+	"encoding/json"
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/suite"
+	"github.com/yxdrlitao/go-zookeeper/zk"
+)
+
+type testConfig struct {
+	Host string
+	Port int
+}
+
+// a message which marshals itself like the generated protobuf messages
+type testMessage struct {
+	value string
+}
+
+func (m *testMessage) Marshal() ([]byte, error) {
+	return []byte("msg:" + m.value), nil
+}
+
+func (m *testMessage) Unmarshal(data []byte) error {
+	if len(data) < 4 || string(data[:4]) != "msg:" {
+		return errors.New("invalid message")
+	}
+
+	m.value = string(data[4:])
+
+	return nil
+}
+
+func TestCodecs(t *testing.T) {
+	config := testConfig{"localhost", 2181}
+
+	for _, codec := range []Codec{NewJSONCodec(), NewGobCodec(), NewCodec(json.Marshal, json.Unmarshal)} {
+		data, err := codec.Marshal(&config)
+
+		assert.NoError(t, err)
+
+		var decoded testConfig
+
+		assert.NoError(t, codec.Unmarshal(data, &decoded))
+		assert.Equal(t, config, decoded)
+	}
+
+	codec := NewProtoCodec()
+
+	data, err := codec.Marshal(&testMessage{"hello"})
+
+	assert.NoError(t, err)
+	assert.Equal(t, "msg:hello", string(data))
+
+	var msg testMessage
+
+	assert.NoError(t, codec.Unmarshal(data, &msg))
+	assert.Equal(t, "hello", msg.value)
+
+	_, err = codec.Marshal(&config)
+
+	assert.EqualError(t, err, "*curator.testConfig is not a proto message")
+	assert.EqualError(t, codec.Unmarshal(data, &config), "*curator.testConfig is not a proto message")
+}
+
+func TestCodecError(t *testing.T) {
+	var config testConfig
+
+	err := DecodeData(NewJSONCodec(), "/config", []byte("{"), &config)
+
+	assert.EqualError(t, err, "fail to decode data of /config, unexpected end of JSON input")
+	assert.IsType(t, (*CodecError)(nil), err)
+	assert.Equal(t, "/config", err.(*CodecError).Path)
+	assert.True(t, err.(*CodecError).Decode)
+	assert.NotNil(t, errors.Unwrap(err))
+
+	_, err = EncodeData(NewJSONCodec(), "/config", make(chan int))
+
+	assert.EqualError(t, err, "fail to encode data of /config, json: unsupported type: chan int")
+}
+
+type CodecTestSuite struct {
+	mockContainerTestSuite
+}
+
+func TestCodecWithClient(t *testing.T) {
+	suite.Run(t, new(CodecTestSuite))
+}
+
+func (s *CodecTestSuite) TestEncoded() {
+	s.With(func(client CuratorFramework, conn *mockConn, acls []zk.ACL, stat *zk.Stat) {
+		s.IsType((*JSONCodec)(nil), client.Codec())
+
+		conn.On("Create", "/config", []byte(`{"Host":"localhost","Port":2181}`), int32(PERSISTENT), acls).Return("/config", nil).Once()
+		conn.On("Set", "/config", []byte(`{"Host":"localhost","Port":2182}`), int32(AnyVersion)).Return(stat, nil).Once()
+
+		_, err := client.Create().WithACL(acls...).Encoded(testConfig{"localhost", 2181}).ForPath("/config")
+
+		s.NoError(err)
+
+		_, err = client.SetData().Encoded(&testConfig{"localhost", 2182}).ForPath("/config")
+
+		s.NoError(err)
+
+		_, err = client.SetData().Encoded(make(chan int)).ForPath("/config")
+
+		s.IsType((*CodecError)(nil), err)
+	})
+}
+
+func (s *CodecTestSuite) TestInto() {
+	s.WithNamespace("app", func(client CuratorFramework, conn *mockConn, stat *zk.Stat) {
+		conn.On("Exists", "/app").Return(true, nil, nil).Once()
+		conn.On("Get", "/app/config").Return([]byte(`{"Host":"localhost","Port":2181}`), stat, nil).Once()
+		conn.On("Get", "/app/broken").Return([]byte(`{`), stat, nil).Once()
+
+		var config testConfig
+
+		data, err := client.GetData().Into(&config).ForPath("/config")
+
+		s.NoError(err)
+		s.Equal(`{"Host":"localhost","Port":2181}`, string(data))
+		s.Equal(testConfig{"localhost", 2181}, config)
+
+		_, err = client.GetData().Into(&config).ForPath("/broken")
+
+		s.EqualError(err, "fail to decode data of /broken, unexpected end of JSON input")
+	})
+}
+
+func (s *CodecTestSuite) TestCompressed() {
+	s.WithPrepare(func(builder *CuratorFrameworkBuilder) {
+		builder.Codec(NewGobCodec())
+	}, func(client CuratorFramework, conn *mockConn, compress *mockCompressionProvider, acls []zk.ACL, stat *zk.Stat) {
+		encoded, _ := NewGobCodec().Marshal(testConfig{"localhost", 2181})
+
+		compress.On("Compress", "/config", encoded).Return([]byte("compressed"), nil).Once()
+		compress.On("Decompress", "/config", []byte("compressed")).Return(encoded, nil).Once()
+		conn.On("Create", "/config", []byte("compressed"), int32(PERSISTENT), acls).Return("/config", nil).Once()
+		conn.On("Get", "/config").Return([]byte("compressed"), stat, nil).Once()
+
+		_, err := client.Create().Compressed().WithACL(acls...).Encoded(testConfig{"localhost", 2181}).ForPath("/config")
+
+		s.NoError(err)
+
+		var config testConfig
+
+		_, err = client.GetData().Decompressed().Into(&config).ForPath("/config")
+
+		s.NoError(err)
+		s.Equal(testConfig{"localhost", 2181}, config)
+	})
+}
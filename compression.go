@@ -3,15 +3,22 @@ package curator
 import (
 	"bytes"
 	"compress/gzip"
+	"fmt"
 	"io/ioutil"
+	"sync"
 
 	"github.com/bkaradzic/go-lz4"
+	"github.com/golang/snappy"
+	"github.com/klauspost/compress/zstd"
 )
 
 var (
 	CompressionProviders = map[string]CompressionProvider{
-		"gzip": NewGzipCompressionProvider(),
-		"lz4":  NewLZ4CompressionProvider(),
+		"gzip":     NewGzipCompressionProvider(),
+		"lz4":      NewLZ4CompressionProvider(),
+		"snappy":   NewSnappyCompressionProvider(),
+		"zstd":     NewZstdCompressionProvider(),
+		"envelope": NewEnvelopeCompressionProvider(CODEC_GZIP),
 	}
 )
 
@@ -21,6 +28,28 @@ type CompressionProvider interface {
 	Decompress(path string, compressedData []byte) ([]byte, error)
 }
 
+// Optional interface of CompressionProvider, which decides the paths to compress
+// when the compression is enabled for all the operations with CuratorFrameworkBuilder.EnableCompression()
+type CompressionPolicy interface {
+	// Return true if the data of the path (relative to the namespace) should be compressed
+	ShouldCompress(path string) bool
+}
+
+// Compression provider which only compresses the paths accepted by the policy
+type SelectiveCompressionProvider struct {
+	CompressionProvider
+
+	shouldCompress func(path string) bool
+}
+
+func NewSelectiveCompressionProvider(provider CompressionProvider, shouldCompress func(path string) bool) *SelectiveCompressionProvider {
+	return &SelectiveCompressionProvider{provider, shouldCompress}
+}
+
+func (c *SelectiveCompressionProvider) ShouldCompress(path string) bool {
+	return c.shouldCompress(path)
+}
+
 type GzipCompressionProvider struct {
 	level int
 }
@@ -72,3 +101,152 @@ func (c *LZ4CompressionProvider) Compress(path string, data []byte) ([]byte, err
 func (c *LZ4CompressionProvider) Decompress(path string, compressedData []byte) ([]byte, error) {
 	return lz4.Decode(nil, compressedData)
 }
+
+type SnappyCompressionProvider struct{}
+
+func NewSnappyCompressionProvider() *SnappyCompressionProvider {
+	return &SnappyCompressionProvider{}
+}
+
+func (c *SnappyCompressionProvider) Compress(path string, data []byte) ([]byte, error) {
+	return snappy.Encode(nil, data), nil
+}
+
+func (c *SnappyCompressionProvider) Decompress(path string, compressedData []byte) ([]byte, error) {
+	return snappy.Decode(nil, compressedData)
+}
+
+// The encoder and decoder are shared, EncodeAll and DecodeAll could be called concurrently
+var (
+	zstdEncoder, _ = zstd.NewWriter(nil)
+	zstdDecoder, _ = zstd.NewReader(nil)
+)
+
+type ZstdCompressionProvider struct{}
+
+func NewZstdCompressionProvider() *ZstdCompressionProvider {
+	return &ZstdCompressionProvider{}
+}
+
+func (c *ZstdCompressionProvider) Compress(path string, data []byte) ([]byte, error) {
+	return zstdEncoder.EncodeAll(data, nil), nil
+}
+
+func (c *ZstdCompressionProvider) Decompress(path string, compressedData []byte) ([]byte, error) {
+	return zstdDecoder.DecodeAll(compressedData, nil)
+}
+
+// The codec id written in the header of EnvelopeCompressionProvider, which must never change once used
+type CompressionCodec byte
+
+const (
+	CODEC_NONE   CompressionCodec = iota // the data is stored uncompressed
+	CODEC_GZIP                           // GzipCompressionProvider
+	CODEC_LZ4                            // LZ4CompressionProvider
+	CODEC_SNAPPY                         // SnappyCompressionProvider
+	CODEC_ZSTD                           // ZstdCompressionProvider
+)
+
+var (
+	// The magic header of EnvelopeCompressionProvider, followed by one byte of codec id
+	ENVELOPE_MAGIC = []byte{0xC7, 'Z', 'K'}
+
+	gzipMagic = []byte{0x1f, 0x8b}
+
+	compressionCodecsLock sync.RWMutex
+	compressionCodecs     = map[CompressionCodec]CompressionProvider{
+		CODEC_GZIP:   NewGzipCompressionProvider(),
+		CODEC_LZ4:    NewLZ4CompressionProvider(),
+		CODEC_SNAPPY: NewSnappyCompressionProvider(),
+		CODEC_ZSTD:   NewZstdCompressionProvider(),
+	}
+)
+
+// Register the provider of a codec, so that EnvelopeCompressionProvider could write and read it.
+func RegisterCompressionCodec(codec CompressionCodec, provider CompressionProvider) {
+	compressionCodecsLock.Lock()
+	defer compressionCodecsLock.Unlock()
+
+	compressionCodecs[codec] = provider
+}
+
+func compressionCodec(codec CompressionCodec) (CompressionProvider, error) {
+	compressionCodecsLock.RLock()
+	defer compressionCodecsLock.RUnlock()
+
+	if provider, exists := compressionCodecs[codec]; exists {
+		return provider, nil
+	}
+
+	return nil, fmt.Errorf("unknown compression codec #%d", codec)
+}
+
+// Compression provider which writes a self-describing header (magic + codec id) before the compressed data,
+// and detects the codec on read, so the codec could be migrated gradually across a live tree.
+//
+// Data without the header is legacy data: gzip data written by Compressed() is decompressed,
+// and anything else is passed through unchanged.
+type EnvelopeCompressionProvider struct {
+	codec CompressionCodec
+}
+
+// Create an envelope provider which writes with the codec
+func NewEnvelopeCompressionProvider(codec CompressionCodec) *EnvelopeCompressionProvider {
+	return &EnvelopeCompressionProvider{codec}
+}
+
+func (c *EnvelopeCompressionProvider) Compress(path string, data []byte) ([]byte, error) {
+	codec := c.codec
+
+	payload := data
+
+	if codec != CODEC_NONE {
+		provider, err := compressionCodec(codec)
+
+		if err != nil {
+			return nil, err
+		}
+
+		if payload, err = provider.Compress(path, data); err != nil {
+			return nil, err
+		}
+
+		// not worth it, store the data as it is
+		if len(payload) >= len(data) {
+			codec, payload = CODEC_NONE, data
+		}
+	}
+
+	buf := make([]byte, 0, len(ENVELOPE_MAGIC)+1+len(payload))
+	buf = append(buf, ENVELOPE_MAGIC...)
+	buf = append(buf, byte(codec))
+
+	return append(buf, payload...), nil
+}
+
+func (c *EnvelopeCompressionProvider) Decompress(path string, compressedData []byte) ([]byte, error) {
+	if !bytes.HasPrefix(compressedData, ENVELOPE_MAGIC) || len(compressedData) < len(ENVELOPE_MAGIC)+1 {
+		if bytes.HasPrefix(compressedData, gzipMagic) {
+			if data, err := NewGzipCompressionProvider().Decompress(path, compressedData); err == nil {
+				return data, nil
+			}
+		}
+
+		return compressedData, nil
+	}
+
+	codec := CompressionCodec(compressedData[len(ENVELOPE_MAGIC)])
+	payload := compressedData[len(ENVELOPE_MAGIC)+1:]
+
+	if codec == CODEC_NONE {
+		return payload, nil
+	}
+
+	provider, err := compressionCodec(codec)
+
+	if err != nil {
+		return nil, err
+	}
+
+	return provider.Decompress(path, payload)
+}
@@ -1,9 +1,14 @@
 package curator
 
 import (
+	"bytes"
+	"strings"
 	"testing"
 
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/suite"
+	"github.com/yxdrlitao/go-zookeeper/zk"
 )
 
 func TestGzipCompressionProvider(t *testing.T) {
@@ -37,3 +42,248 @@ func TestLZ4CompressionProvider(t *testing.T) {
 	assert.Equal(t, "data", string(data))
 	assert.NoError(t, err)
 }
+
+func TestSnappyCompressionProvider(t *testing.T) {
+	p := NewSnappyCompressionProvider()
+
+	assert.NotNil(t, p)
+
+	data, err := p.Compress("/node", []byte("data"))
+
+	assert.Equal(t, 6, len(data))
+	assert.NoError(t, err)
+
+	data, err = p.Decompress("/node", data)
+
+	assert.Equal(t, "data", string(data))
+	assert.NoError(t, err)
+}
+
+func TestZstdCompressionProvider(t *testing.T) {
+	p := NewZstdCompressionProvider()
+
+	assert.NotNil(t, p)
+
+	payload := bytes.Repeat([]byte("data"), 100)
+
+	data, err := p.Compress("/node", payload)
+
+	assert.NoError(t, err)
+	assert.True(t, len(data) < len(payload))
+
+	data, err = p.Decompress("/node", data)
+
+	assert.Equal(t, payload, data)
+	assert.NoError(t, err)
+}
+
+func TestEnvelopeCompressionProvider(t *testing.T) {
+	payload := bytes.Repeat([]byte("data"), 100)
+
+	for _, codec := range []CompressionCodec{CODEC_NONE, CODEC_GZIP, CODEC_LZ4, CODEC_SNAPPY, CODEC_ZSTD} {
+		p := NewEnvelopeCompressionProvider(codec)
+
+		data, err := p.Compress("/node", payload)
+
+		assert.NoError(t, err)
+		assert.Equal(t, ENVELOPE_MAGIC, data[:len(ENVELOPE_MAGIC)])
+		assert.Equal(t, byte(codec), data[len(ENVELOPE_MAGIC)])
+
+		// any envelope provider could read the data written with another codec
+		data, err = NewEnvelopeCompressionProvider(CODEC_SNAPPY).Decompress("/node", data)
+
+		assert.NoError(t, err)
+		assert.Equal(t, payload, data)
+	}
+
+	p := NewEnvelopeCompressionProvider(CODEC_GZIP)
+
+	// store the data uncompressed if it doesn't shrink
+	data, err := p.Compress("/node", []byte("data"))
+
+	assert.NoError(t, err)
+	assert.Equal(t, append(append([]byte{}, ENVELOPE_MAGIC...), byte(CODEC_NONE), 'd', 'a', 't', 'a'), data)
+
+	// legacy gzip data
+	data, err = NewGzipCompressionProvider().Compress("/node", []byte("data"))
+
+	assert.NoError(t, err)
+
+	data, err = p.Decompress("/node", data)
+
+	assert.NoError(t, err)
+	assert.Equal(t, "data", string(data))
+
+	// legacy plain data
+	data, err = p.Decompress("/node", []byte("data"))
+
+	assert.NoError(t, err)
+	assert.Equal(t, "data", string(data))
+
+	// codec without provider
+	_, err = NewEnvelopeCompressionProvider(CompressionCodec(99)).Compress("/node", payload)
+
+	assert.EqualError(t, err, "unknown compression codec #99")
+
+	_, err = p.Decompress("/node", append(append([]byte{}, ENVELOPE_MAGIC...), 99))
+
+	assert.EqualError(t, err, "unknown compression codec #99")
+
+	// plug a provider in
+	RegisterCompressionCodec(CompressionCodec(99), NewSnappyCompressionProvider())
+
+	defer func() {
+		compressionCodecsLock.Lock()
+		delete(compressionCodecs, CompressionCodec(99))
+		compressionCodecsLock.Unlock()
+	}()
+
+	data, err = NewEnvelopeCompressionProvider(CompressionCodec(99)).Compress("/node", payload)
+
+	assert.NoError(t, err)
+
+	data, err = p.Decompress("/node", data)
+
+	assert.NoError(t, err)
+	assert.Equal(t, payload, data)
+}
+
+func TestSelectiveCompressionProvider(t *testing.T) {
+	p := NewSelectiveCompressionProvider(NewSnappyCompressionProvider(), func(path string) bool {
+		return strings.HasPrefix(path, "/blobs/")
+	})
+
+	assert.True(t, p.ShouldCompress("/blobs/node"))
+	assert.False(t, p.ShouldCompress("/config"))
+
+	data, err := p.Compress("/config", []byte("data"))
+
+	assert.NoError(t, err)
+
+	data, err = p.Decompress("/config", data)
+
+	assert.NoError(t, err)
+	assert.Equal(t, "data", string(data))
+}
+
+type CompressionEnabledTestSuite struct {
+	mockContainerTestSuite
+}
+
+func TestCompressionEnabled(t *testing.T) {
+	suite.Run(t, new(CompressionEnabledTestSuite))
+}
+
+func (s *CompressionEnabledTestSuite) enableCompression(builder *CuratorFrameworkBuilder) {
+	builder.EnableCompression()
+}
+
+func (s *CompressionEnabledTestSuite) TestCreateAndSetData() {
+	s.WithPrepare(s.enableCompression, func(client CuratorFramework, conn *mockConn, compress *mockCompressionProvider, data []byte, acls []zk.ACL, stat *zk.Stat) {
+		compress.On("Compress", "/node", data).Return([]byte("compressed(data)"), nil).Twice()
+		conn.On("Create", "/node", []byte("compressed(data)"), int32(PERSISTENT), acls).Return("/node", nil).Once()
+		conn.On("Set", "/node", []byte("compressed(data)"), int32(AnyVersion)).Return(stat, nil).Once()
+
+		_, err := client.Create().WithACL(acls...).ForPathWithData("/node", data)
+
+		s.NoError(err)
+
+		_, err = client.SetData().ForPathWithData("/node", data)
+
+		s.NoError(err)
+
+		// opt-out per call
+		conn.On("Create", "/raw", data, int32(PERSISTENT), acls).Return("/raw", nil).Once()
+		conn.On("Set", "/raw", data, int32(AnyVersion)).Return(stat, nil).Once()
+
+		_, err = client.Create().SkipCompression().WithACL(acls...).ForPathWithData("/raw", data)
+
+		s.NoError(err)
+
+		_, err = client.SetData().SkipCompression().ForPathWithData("/raw", data)
+
+		s.NoError(err)
+	})
+}
+
+func (s *CompressionEnabledTestSuite) TestGetData() {
+	s.WithPrepare(func(builder *CuratorFrameworkBuilder) {
+		builder.Namespace = "parent"
+		builder.EnableCompression()
+	}, func(client CuratorFramework, conn *mockConn, compress *mockCompressionProvider, data []byte, stat *zk.Stat) {
+		conn.On("Exists", "/parent").Return(true, nil, nil).Once()
+		conn.On("Get", "/parent/node").Return([]byte("compressed(data)"), stat, nil).Twice()
+		compress.On("Decompress", "/node", []byte("compressed(data)")).Return(data, nil).Once()
+
+		data2, err := client.GetData().ForPath("/node")
+
+		s.NoError(err)
+		s.Equal(data, data2)
+
+		data2, err = client.GetData().SkipDecompression().ForPath("/node")
+
+		s.NoError(err)
+		s.Equal([]byte("compressed(data)"), data2)
+
+		// nothing to decompress
+		conn.On("Get", "/parent/missing").Return(nil, nil, zk.ErrNoNode).Once()
+
+		_, err = client.GetData().ForPath("/missing")
+
+		s.Equal(zk.ErrNoNode, err)
+	})
+}
+
+func (s *CompressionEnabledTestSuite) TestTransaction() {
+	s.WithPrepare(s.enableCompression, func(client CuratorFramework, conn *mockConn, compress *mockCompressionProvider, acls []zk.ACL) {
+		compress.On("Compress", "/node1", []byte("data1")).Return([]byte("compressed(data1)"), nil).Once()
+		conn.On("Multi", mock.Anything).Return([]zk.MultiResponse{{String: "/node1"}, {Stat: &zk.Stat{}}}, nil).Once()
+
+		_, err := client.InTransaction().
+			Create().WithACL(acls...).ForPathWithData("/node1", []byte("data1")).
+			SetData().SkipCompression().ForPathWithData("/node2", []byte("data2")).
+			Commit()
+
+		s.NoError(err)
+		s.Equal([]interface{}{
+			&zk.CreateRequest{Path: "/node1", Data: []byte("compressed(data1)"), Acl: acls, Flags: int32(PERSISTENT)},
+			&zk.SetDataRequest{Path: "/node2", Data: []byte("data2"), Version: AnyVersion},
+		}, conn.operations)
+	})
+}
+
+func (s *CompressionEnabledTestSuite) TestPolicy() {
+	provider := NewSelectiveCompressionProvider(NewGzipCompressionProvider(), func(path string) bool {
+		return strings.HasPrefix(path, "/blobs/")
+	})
+
+	s.WithPrepare(func(builder *CuratorFrameworkBuilder) {
+		builder.CompressionProvider = provider
+		builder.EnableCompression()
+	}, func(client CuratorFramework, conn *mockConn, acls []zk.ACL, stat *zk.Stat) {
+		compressed, _ := NewGzipCompressionProvider().Compress("/blobs/node", []byte("data"))
+
+		conn.On("Create", "/blobs/node", compressed, int32(PERSISTENT), acls).Return("/blobs/node", nil).Once()
+		conn.On("Create", "/config", []byte("data"), int32(PERSISTENT), acls).Return("/config", nil).Once()
+		conn.On("Get", "/blobs/node").Return(compressed, stat, nil).Once()
+		conn.On("Get", "/config").Return([]byte("data"), stat, nil).Once()
+
+		_, err := client.Create().WithACL(acls...).ForPathWithData("/blobs/node", []byte("data"))
+
+		s.NoError(err)
+
+		_, err = client.Create().WithACL(acls...).ForPathWithData("/config", []byte("data"))
+
+		s.NoError(err)
+
+		data, err := client.GetData().ForPath("/blobs/node")
+
+		s.NoError(err)
+		s.Equal("data", string(data))
+
+		data, err = client.GetData().ForPath("/config")
+
+		s.NoError(err)
+		s.Equal("data", string(data))
+	})
+}
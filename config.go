@@ -0,0 +1,571 @@
+package curator
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"log"
+	"net"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
+
+	"github.com/yxdrlitao/go-zookeeper/zk"
+)
+
+// The node where ZooKeeper 3.5+ publishes the dynamic configuration of the ensemble
+const ZOOKEEPER_CONFIG_NODE = "/zookeeper/config"
+
+type getConfigBuilder struct {
+	client        *curatorFramework
+	backgrounding backgrounding
+	stat          *zk.Stat
+	watching      watching
+}
+
+func (b *getConfigBuilder) ForEnsemble() ([]byte, error) {
+	if b.backgrounding.inBackground {
+		b.client.client.inBackground(b.pathInBackground)
+
+		return nil, nil
+	}
+
+	return b.pathInForeground()
+}
+
+func (b *getConfigBuilder) pathInBackground() {
+	tracer := b.client.ZookeeperClient().StartTracer("getConfigBuilder.pathInBackground")
+
+	defer tracer.Commit()
+
+	data, err := b.pathInForeground()
+
+	if b.backgrounding.callback != nil {
+		b.backgrounding.callback(b.client, &curatorEvent{
+			eventType: GET_CONFIG,
+			err:       err,
+			path:      ZOOKEEPER_CONFIG_NODE,
+			name:      GetNodeFromPath(ZOOKEEPER_CONFIG_NODE),
+			data:      data,
+			stat:      b.stat,
+			context:   b.backgrounding.context,
+		})
+	}
+}
+
+func (b *getConfigBuilder) pathInForeground() ([]byte, error) {
+	zkClient := b.client.ZookeeperClient()
+
+	result, err := zkClient.NewRetryLoop().CallWithRetry(func() (interface{}, error) {
+		if conn, err := zkClient.Conn(); err != nil {
+			return nil, err
+		} else {
+			var data []byte
+			var stat *zk.Stat
+			var events <-chan zk.Event
+			var err error
+
+			if b.watching.watched || b.watching.watcher != nil {
+				data, stat, events, err = conn.GetW(ZOOKEEPER_CONFIG_NODE)
+
+				if events != nil && b.watching.watcher != nil {
+					b.client.client.watch(b.watching.watcher, events)
+				}
+			} else {
+				data, stat, err = conn.Get(ZOOKEEPER_CONFIG_NODE)
+			}
+
+			if stat != nil {
+				b.client.client.recordStat(stat)
+
+				if b.stat != nil {
+					*b.stat = *stat
+				} else {
+					b.stat = stat
+				}
+			}
+
+			return data, err
+		}
+	})
+
+	data, _ := result.([]byte)
+
+	return data, err
+}
+
+func (b *getConfigBuilder) StoringStatIn(stat *zk.Stat) GetConfigBuilder {
+	b.stat = stat
+	return b
+}
+
+func (b *getConfigBuilder) Watched() GetConfigBuilder {
+	b.watching.watched = true
+	return b
+}
+
+func (b *getConfigBuilder) UsingWatcher(watcher Watcher) GetConfigBuilder {
+	b.watching.watcher = watcher
+	return b
+}
+
+func (b *getConfigBuilder) InBackground() GetConfigBuilder {
+	b.backgrounding = backgrounding{inBackground: true}
+	return b
+}
+
+func (b *getConfigBuilder) InBackgroundWithContext(context interface{}) GetConfigBuilder {
+	b.backgrounding = backgrounding{inBackground: true, context: context}
+	return b
+}
+
+func (b *getConfigBuilder) InBackgroundWithCallback(callback BackgroundCallback) GetConfigBuilder {
+	b.backgrounding = backgrounding{inBackground: true, callback: callback}
+	return b
+}
+
+func (b *getConfigBuilder) InBackgroundWithCallbackAndContext(callback BackgroundCallback, context interface{}) GetConfigBuilder {
+	b.backgrounding = backgrounding{inBackground: true, context: context, callback: callback}
+	return b
+}
+
+type reconfigBuilder struct {
+	client        *curatorFramework
+	backgrounding backgrounding
+	joining       []string
+	leaving       []string
+	members       []string
+	fromConfig    int64
+	stat          *zk.Stat
+}
+
+func (b *reconfigBuilder) ForEnsemble() (*zk.Stat, error) {
+	if len(b.members) > 0 && (len(b.joining) > 0 || len(b.leaving) > 0) {
+		return nil, fmt.Errorf("cannot mix new members with joining or leaving servers")
+	}
+
+	if b.backgrounding.inBackground {
+		b.client.client.inBackground(b.pathInBackground)
+
+		return nil, nil
+	}
+
+	return b.pathInForeground()
+}
+
+func (b *reconfigBuilder) pathInBackground() {
+	tracer := b.client.ZookeeperClient().StartTracer("reconfigBuilder.pathInBackground")
+
+	defer tracer.Commit()
+
+	stat, err := b.pathInForeground()
+
+	if b.backgrounding.callback != nil {
+		b.backgrounding.callback(b.client, &curatorEvent{
+			eventType: RECONFIG,
+			err:       err,
+			path:      ZOOKEEPER_CONFIG_NODE,
+			name:      GetNodeFromPath(ZOOKEEPER_CONFIG_NODE),
+			stat:      stat,
+			context:   b.backgrounding.context,
+		})
+	}
+}
+
+func (b *reconfigBuilder) pathInForeground() (*zk.Stat, error) {
+	if err := b.client.ensureWritable(); err != nil {
+		return nil, err
+	}
+
+	zkClient := b.client.ZookeeperClient()
+
+	result, err := zkClient.NewRetryLoop().CallWithRetry(func() (interface{}, error) {
+		if conn, err := zkClient.Conn(); err != nil {
+			return nil, err
+		} else if len(b.members) > 0 {
+			return conn.Reconfig(b.members, b.fromConfig)
+		} else {
+			return conn.IncrementalReconfig(b.joining, b.leaving, b.fromConfig)
+		}
+	})
+
+	stat, _ := result.(*zk.Stat)
+
+	b.client.client.recordStat(stat)
+
+	if stat != nil && b.stat != nil {
+		*b.stat = *stat
+	}
+
+	return stat, err
+}
+
+func (b *reconfigBuilder) Joining(servers ...string) ReconfigBuilder {
+	b.joining = append(b.joining, servers...)
+	return b
+}
+
+func (b *reconfigBuilder) Leaving(servers ...string) ReconfigBuilder {
+	b.leaving = append(b.leaving, servers...)
+	return b
+}
+
+func (b *reconfigBuilder) WithNewMembers(servers ...string) ReconfigBuilder {
+	b.members = append(b.members, servers...)
+	return b
+}
+
+func (b *reconfigBuilder) FromConfig(version int64) ReconfigBuilder {
+	b.fromConfig = version
+	return b
+}
+
+func (b *reconfigBuilder) StoringStatIn(stat *zk.Stat) ReconfigBuilder {
+	b.stat = stat
+	return b
+}
+
+func (b *reconfigBuilder) InBackground() ReconfigBuilder {
+	b.backgrounding = backgrounding{inBackground: true}
+	return b
+}
+
+func (b *reconfigBuilder) InBackgroundWithContext(context interface{}) ReconfigBuilder {
+	b.backgrounding = backgrounding{inBackground: true, context: context}
+	return b
+}
+
+func (b *reconfigBuilder) InBackgroundWithCallback(callback BackgroundCallback) ReconfigBuilder {
+	b.backgrounding = backgrounding{inBackground: true, callback: callback}
+	return b
+}
+
+func (b *reconfigBuilder) InBackgroundWithCallbackAndContext(callback BackgroundCallback, context interface{}) ReconfigBuilder {
+	b.backgrounding = backgrounding{inBackground: true, context: context, callback: callback}
+	return b
+}
+
+// A member of the ensemble, as published in /zookeeper/config
+type QuorumServer struct {
+	ID           int64  // the server id
+	Host         string // the address used by the quorum
+	QuorumPort   int    // the port used by followers to connect to the leader
+	ElectionPort int    // the port used for leader election
+	Role         string // participant or observer
+	ClientHost   string // the address clients connect to, empty if it is the wildcard address
+	ClientPort   int    // the port clients connect to, 0 if the server doesn't accept clients
+}
+
+// Return the host:port clients should connect to
+func (s *QuorumServer) ClientAddress() string {
+	host := s.ClientHost
+
+	if len(host) == 0 {
+		host = s.Host
+	}
+
+	return net.JoinHostPort(host, strconv.Itoa(s.ClientPort))
+}
+
+// The dynamic configuration of the ensemble
+type EnsembleConfig struct {
+	Servers []*QuorumServer
+	Version int64
+}
+
+// Parse the content of /zookeeper/config
+func ParseEnsembleConfig(data []byte) (*EnsembleConfig, error) {
+	config := &EnsembleConfig{}
+
+	scanner := bufio.NewScanner(bytes.NewReader(data))
+
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+
+		if len(line) == 0 {
+			continue
+		}
+
+		kv := strings.SplitN(line, "=", 2)
+
+		if len(kv) != 2 {
+			return nil, fmt.Errorf("invalid config line `%s`", line)
+		}
+
+		key, value := strings.TrimSpace(kv[0]), strings.TrimSpace(kv[1])
+
+		switch {
+		case key == "version":
+			version, err := strconv.ParseInt(value, 16, 64)
+
+			if err != nil {
+				return nil, fmt.Errorf("invalid config version `%s`, %s", value, err)
+			}
+
+			config.Version = version
+
+		case strings.HasPrefix(key, "server."):
+			id, err := strconv.ParseInt(strings.TrimPrefix(key, "server."), 10, 64)
+
+			if err != nil {
+				return nil, fmt.Errorf("invalid server id `%s`, %s", key, err)
+			}
+
+			server, err := parseQuorumServer(value)
+
+			if err != nil {
+				return nil, fmt.Errorf("invalid server `%s`, %s", line, err)
+			}
+
+			server.ID = id
+
+			config.Servers = append(config.Servers, server)
+		}
+	}
+
+	return config, scanner.Err()
+}
+
+// host:quorumPort:electionPort[:role][;[clientHost:]clientPort]
+func parseQuorumServer(value string) (*QuorumServer, error) {
+	var server QuorumServer
+
+	parts := strings.SplitN(value, ";", 2)
+
+	host, fields := splitHost(parts[0])
+
+	if len(fields) < 2 {
+		return nil, fmt.Errorf("missing quorum or election port")
+	}
+
+	var err error
+
+	server.Host = host
+
+	if server.QuorumPort, err = strconv.Atoi(fields[0]); err != nil {
+		return nil, err
+	}
+	if server.ElectionPort, err = strconv.Atoi(fields[1]); err != nil {
+		return nil, err
+	}
+	if len(fields) > 2 {
+		server.Role = fields[2]
+	} else {
+		server.Role = "participant"
+	}
+
+	if len(parts) == 2 {
+		clientHost, fields := splitHost(parts[1])
+
+		if len(fields) == 0 {
+			fields, clientHost = []string{clientHost}, ""
+		}
+
+		if server.ClientPort, err = strconv.Atoi(fields[0]); err != nil {
+			return nil, err
+		}
+
+		if ip := net.ParseIP(clientHost); ip == nil || !ip.IsUnspecified() {
+			server.ClientHost = clientHost
+		}
+	}
+
+	return &server, nil
+}
+
+// Split "host:a:b" or "[ipv6]:a:b" into the host and the remaining fields
+func splitHost(s string) (string, []string) {
+	if strings.HasPrefix(s, "[") {
+		if end := strings.Index(s, "]"); end > 0 {
+			return s[1:end], strings.Split(strings.TrimPrefix(s[end+1:], ":"), ":")
+		}
+	}
+
+	fields := strings.Split(s, ":")
+
+	return fields[0], fields[1:]
+}
+
+// Return the connection string of the servers which accept clients
+func (c *EnsembleConfig) ConnectionString() string {
+	var servers []string
+
+	for _, server := range c.Servers {
+		if server.ClientPort > 0 {
+			servers = append(servers, server.ClientAddress())
+		}
+	}
+
+	return strings.Join(servers, ",")
+}
+
+// Implemented by ensemble providers which need the framework to track the ensemble
+type ensembleTracker interface {
+	track(client CuratorFramework)
+}
+
+// Ensemble provider that starts with the given connection string,
+// and then follows the membership changes published in /zookeeper/config.
+//
+// A new connection string takes effect the next time the client reconnects,
+// through the same path as any other connection string change.
+type ConfigEnsembleProvider struct {
+	chroot        string
+	connectString atomic.Value
+	state         State
+	lock          sync.Mutex
+	client        CuratorFramework
+	config        *EnsembleConfig
+	watcher       Watcher
+	watching      bool // whether the watcher is registered and not fired yet
+	listener      ConnectionStateListener
+}
+
+func NewConfigEnsembleProvider(connectString string) *ConfigEnsembleProvider {
+	p := &ConfigEnsembleProvider{}
+
+	if idx := strings.Index(connectString, "/"); idx >= 0 {
+		p.chroot = connectString[idx:]
+	}
+
+	p.connectString.Store(connectString)
+
+	p.watcher = NewWatcher(func(event *zk.Event) {
+		switch event.Type {
+		case zk.EventNodeDataChanged, zk.EventNodeCreated, zk.EventNotWatching:
+			// the watch is consumed, so the reload registers it again
+			p.lock.Lock()
+			p.watching = false
+			p.lock.Unlock()
+
+			p.reload()
+		}
+	})
+
+	// the server keeps the watch across the reconnection, so the config is only read again
+	p.listener = NewConnectionStateListener(func(client CuratorFramework, newState ConnectionState) {
+		if newState == CONNECTED || newState == RECONNECTED {
+			p.reload()
+		}
+	})
+
+	return p
+}
+
+func (p *ConfigEnsembleProvider) Start() error {
+	if !p.state.Change(LATENT, STARTED) {
+		return fmt.Errorf("Cannot be started more than once")
+	}
+
+	return nil
+}
+
+func (p *ConfigEnsembleProvider) Close() error {
+	if !p.state.Change(STARTED, STOPPED) {
+		return nil
+	}
+
+	p.lock.Lock()
+	defer p.lock.Unlock()
+
+	if p.client != nil {
+		p.client.ConnectionStateListenable().RemoveListener(p.listener)
+	}
+
+	return nil
+}
+
+func (p *ConfigEnsembleProvider) ConnectionString() string {
+	return p.connectString.Load().(string)
+}
+
+// Return the last ensemble configuration received, or nil
+func (p *ConfigEnsembleProvider) Config() *EnsembleConfig {
+	p.lock.Lock()
+	defer p.lock.Unlock()
+
+	return p.config
+}
+
+func (p *ConfigEnsembleProvider) track(client CuratorFramework) {
+	p.lock.Lock()
+	p.client = client
+	p.lock.Unlock()
+
+	client.ConnectionStateListenable().AddListener(p.listener)
+
+	p.reload()
+}
+
+// Read the config, and register the watcher only if it isn't registered yet, so the watches never pile up
+func (p *ConfigEnsembleProvider) reload() {
+	p.lock.Lock()
+
+	client := p.client
+
+	if client == nil || p.state.Value() != STARTED || !client.Started() {
+		p.lock.Unlock()
+
+		return
+	}
+
+	watch := !p.watching
+
+	p.watching = true
+
+	p.lock.Unlock()
+
+	builder := client.GetConfig()
+
+	if watch {
+		builder = builder.UsingWatcher(p.watcher)
+	}
+
+	builder.InBackgroundWithCallback(func(client CuratorFramework, event CuratorEvent) error {
+		if err := event.Err(); err != nil {
+			log.Printf("fail to get ensemble config, %s", err)
+
+			if watch {
+				p.lock.Lock()
+				p.watching = false
+				p.lock.Unlock()
+			}
+
+			return err
+		}
+
+		config, err := ParseEnsembleConfig(event.Data())
+
+		if err != nil {
+			log.Printf("fail to parse ensemble config, %s", err)
+
+			return err
+		}
+
+		p.update(config)
+
+		return nil
+	}).ForEnsemble()
+}
+
+func (p *ConfigEnsembleProvider) update(config *EnsembleConfig) {
+	p.lock.Lock()
+	defer p.lock.Unlock()
+
+	if p.config != nil && config.Version < p.config.Version {
+		return
+	}
+
+	p.config = config
+
+	if connectString := config.ConnectionString(); len(connectString) > 0 {
+		connectString += p.chroot
+
+		if old := p.ConnectionString(); old != connectString {
+			log.Printf("ensemble config #%x changed connection string from `%s` to `%s`", config.Version, old, connectString)
+
+			p.connectString.Store(connectString)
+		}
+	}
+}
@@ -0,0 +1,228 @@
+package curator
+
+import (
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/suite"
+	"github.com/yxdrlitao/go-zookeeper/zk"
+)
+
+const testEnsembleConfig = `server.1=10.0.0.1:2888:3888:participant;0.0.0.0:2181
+server.2=10.0.0.2:2888:3888:participant;10.0.1.2:2182
+server.3=[fe80::3]:2888:3888:observer;2181
+server.4=10.0.0.4:2888:3888
+version=100000002
+`
+
+func TestParseEnsembleConfig(t *testing.T) {
+	config, err := ParseEnsembleConfig([]byte(testEnsembleConfig))
+
+	assert.NoError(t, err)
+	assert.NotNil(t, config)
+	assert.Equal(t, int64(0x100000002), config.Version)
+	assert.Equal(t, []*QuorumServer{
+		{ID: 1, Host: "10.0.0.1", QuorumPort: 2888, ElectionPort: 3888, Role: "participant", ClientPort: 2181},
+		{ID: 2, Host: "10.0.0.2", QuorumPort: 2888, ElectionPort: 3888, Role: "participant", ClientHost: "10.0.1.2", ClientPort: 2182},
+		{ID: 3, Host: "fe80::3", QuorumPort: 2888, ElectionPort: 3888, Role: "observer", ClientPort: 2181},
+		{ID: 4, Host: "10.0.0.4", QuorumPort: 2888, ElectionPort: 3888, Role: "participant"},
+	}, config.Servers)
+	assert.Equal(t, "10.0.0.1:2181,10.0.1.2:2182,[fe80::3]:2181", config.ConnectionString())
+
+	_, err = ParseEnsembleConfig([]byte("server.x=10.0.0.1:2888:3888"))
+
+	assert.Error(t, err)
+
+	_, err = ParseEnsembleConfig([]byte("server.1=10.0.0.1"))
+
+	assert.Error(t, err)
+
+	_, err = ParseEnsembleConfig([]byte("version"))
+
+	assert.Error(t, err)
+}
+
+type GetConfigBuilderTestSuite struct {
+	mockContainerTestSuite
+}
+
+func TestGetConfigBuilder(t *testing.T) {
+	suite.Run(t, new(GetConfigBuilderTestSuite))
+}
+
+func (s *GetConfigBuilderTestSuite) TestGetConfig() {
+	s.WithNamespace("parent", func(client CuratorFramework, conn *mockConn, stat *zk.Stat) {
+		conn.On("Get", ZOOKEEPER_CONFIG_NODE).Return([]byte(testEnsembleConfig), stat, nil).Once()
+
+		var stat2 zk.Stat
+
+		data, err := client.GetConfig().StoringStatIn(&stat2).ForEnsemble()
+
+		assert.Equal(s.T(), []byte(testEnsembleConfig), data)
+		assert.Equal(s.T(), stat, &stat2)
+		assert.NoError(s.T(), err)
+	})
+}
+
+func (s *GetConfigBuilderTestSuite) TestWatcher() {
+	s.With(func(client CuratorFramework, conn *mockConn, wg *sync.WaitGroup, stat *zk.Stat) {
+		events := make(chan zk.Event)
+
+		defer close(events)
+
+		conn.On("GetW", ZOOKEEPER_CONFIG_NODE).Return([]byte(testEnsembleConfig), stat, events, nil).Once()
+
+		data, err := client.GetConfig().UsingWatcher(NewWatcher(func(event *zk.Event) {
+			defer wg.Done()
+
+			assert.Equal(s.T(), zk.EventNodeDataChanged, event.Type)
+			assert.Equal(s.T(), ZOOKEEPER_CONFIG_NODE, event.Path)
+		})).ForEnsemble()
+
+		assert.Equal(s.T(), []byte(testEnsembleConfig), data)
+		assert.NoError(s.T(), err)
+
+		events <- zk.Event{
+			Type: zk.EventNodeDataChanged,
+			Path: ZOOKEEPER_CONFIG_NODE,
+		}
+	})
+}
+
+func (s *GetConfigBuilderTestSuite) TestBackground() {
+	s.With(func(client CuratorFramework, conn *mockConn, wg *sync.WaitGroup, stat *zk.Stat) {
+		ctxt := "context"
+
+		conn.On("Get", ZOOKEEPER_CONFIG_NODE).Return([]byte(testEnsembleConfig), stat, nil).Once()
+
+		_, err := client.GetConfig().InBackgroundWithCallbackAndContext(
+			func(client CuratorFramework, event CuratorEvent) error {
+				defer wg.Done()
+
+				assert.Equal(s.T(), GET_CONFIG, event.Type())
+				assert.Equal(s.T(), ZOOKEEPER_CONFIG_NODE, event.Path())
+				assert.Equal(s.T(), "config", event.Name())
+				assert.Equal(s.T(), []byte(testEnsembleConfig), event.Data())
+				assert.Equal(s.T(), stat, event.Stat())
+				assert.NoError(s.T(), event.Err())
+				assert.Equal(s.T(), ctxt, event.Context())
+
+				return nil
+			}, ctxt).ForEnsemble()
+
+		assert.NoError(s.T(), err)
+	})
+}
+
+type ReconfigBuilderTestSuite struct {
+	mockContainerTestSuite
+}
+
+func TestReconfigBuilder(t *testing.T) {
+	suite.Run(t, new(ReconfigBuilderTestSuite))
+}
+
+func (s *ReconfigBuilderTestSuite) TestIncremental() {
+	s.With(func(client CuratorFramework, conn *mockConn, stat *zk.Stat) {
+		joining := []string{"server.4=10.0.0.4:2888:3888;2181"}
+		leaving := []string{"3"}
+
+		conn.On("IncrementalReconfig", joining, leaving, int64(0x100000002)).Return(stat, nil).Once()
+
+		var stat2 zk.Stat
+
+		stat3, err := client.Reconfig().Joining(joining...).Leaving(leaving...).FromConfig(0x100000002).StoringStatIn(&stat2).ForEnsemble()
+
+		assert.Equal(s.T(), stat, stat3)
+		assert.Equal(s.T(), stat, &stat2)
+		assert.NoError(s.T(), err)
+	})
+}
+
+func (s *ReconfigBuilderTestSuite) TestNewMembers() {
+	s.With(func(client CuratorFramework, conn *mockConn, stat *zk.Stat) {
+		members := []string{"server.1=10.0.0.1:2888:3888;2181", "server.2=10.0.0.2:2888:3888;2181"}
+
+		conn.On("Reconfig", members, int64(-1)).Return(stat, nil).Once()
+
+		stat2, err := client.Reconfig().WithNewMembers(members...).ForEnsemble()
+
+		assert.Equal(s.T(), stat, stat2)
+		assert.NoError(s.T(), err)
+
+		_, err = client.Reconfig().WithNewMembers(members...).Leaving("3").ForEnsemble()
+
+		assert.Error(s.T(), err)
+	})
+}
+
+func (s *ReconfigBuilderTestSuite) TestBackground() {
+	s.With(func(client CuratorFramework, conn *mockConn, wg *sync.WaitGroup) {
+		conn.On("IncrementalReconfig", []string(nil), []string{"3"}, int64(-1)).Return(nil, zk.ErrReconfigDisabled).Once()
+
+		_, err := client.Reconfig().Leaving("3").InBackgroundWithCallback(
+			func(client CuratorFramework, event CuratorEvent) error {
+				defer wg.Done()
+
+				assert.Equal(s.T(), RECONFIG, event.Type())
+				assert.Equal(s.T(), ZOOKEEPER_CONFIG_NODE, event.Path())
+				assert.Equal(s.T(), zk.ErrReconfigDisabled, event.Err())
+
+				return nil
+			}).ForEnsemble()
+
+		assert.NoError(s.T(), err)
+	})
+}
+
+func TestConfigEnsembleProvider(t *testing.T) {
+	newMockContainer().Test(t, func(client CuratorFramework, conn *mockConn, stat *zk.Stat) {
+		events := make(chan zk.Event)
+
+		defer close(events)
+
+		p := NewConfigEnsembleProvider("10.0.0.1:2181/chroot")
+
+		assert.Equal(t, "10.0.0.1:2181/chroot", p.ConnectionString())
+		assert.Nil(t, p.Config())
+
+		conn.On("GetW", ZOOKEEPER_CONFIG_NODE).Return([]byte(testEnsembleConfig), stat, events, nil).Once()
+
+		assert.NoError(t, p.Start())
+
+		p.track(client)
+
+		assert.Eventually(t, func() bool {
+			return p.ConnectionString() == "10.0.0.1:2181,10.0.1.2:2182,[fe80::3]:2181/chroot"
+		}, time.Second, 10*time.Millisecond)
+
+		// the config changed, the provider reloads and watches it again
+		conn.On("GetW", ZOOKEEPER_CONFIG_NODE).Return([]byte("server.5=10.0.0.5:2888:3888;2181\nversion=100000003"), stat, nil, nil).Once()
+
+		events <- zk.Event{Type: zk.EventNodeDataChanged, Path: ZOOKEEPER_CONFIG_NODE}
+
+		assert.Eventually(t, func() bool {
+			return p.ConnectionString() == "10.0.0.5:2181/chroot"
+		}, time.Second, 10*time.Millisecond)
+
+		assert.Equal(t, int64(0x100000003), p.Config().Version)
+
+		// the watch is kept after reconnecting, so the config is read without another watch
+		conn.On("Get", ZOOKEEPER_CONFIG_NODE).Return([]byte("server.6=10.0.0.6:2888:3888;2181\nversion=100000004"), stat, nil).Once()
+
+		p.listener.StateChanged(client, RECONNECTED)
+
+		assert.Eventually(t, func() bool {
+			return p.ConnectionString() == "10.0.0.6:2181/chroot"
+		}, time.Second, 10*time.Millisecond)
+
+		// a stale config is ignored
+		p.update(&EnsembleConfig{Servers: []*QuorumServer{{Host: "10.0.0.6", ClientPort: 2181}}, Version: 0x100000001})
+
+		assert.Equal(t, "10.0.0.6:2181/chroot", p.ConnectionString())
+
+		assert.NoError(t, p.Close())
+	})
+}
@@ -8,6 +8,9 @@ type createBuilder struct {
 	backgrounding         backgrounding
 	createParentsIfNeeded bool
 	compress              bool
+	skipCompress          bool
+	encoding              bool
+	encoded               interface{}
 	acling                acling
 }
 
@@ -16,18 +19,32 @@ func (b *createBuilder) ForPath(path string) (string, error) {
 }
 
 func (b *createBuilder) ForPathWithData(givenPath string, payload []byte) (string, error) {
-	if b.compress {
-		if data, err := b.client.compressionProvider.Compress(givenPath, payload); err != nil {
+	if b.encoding {
+		if data, err := EncodeData(b.client.codec, givenPath, b.encoded); err != nil {
 			return "", err
 		} else {
 			payload = data
 		}
 	}
 
-	adjustedPath := b.client.fixForNamespace(givenPath, b.createMode.IsSequential())
+	adjustedPath, err := b.client.fixForNamespace(givenPath, b.createMode.IsSequential())
+
+	if err != nil {
+		return "", err
+	}
+
+	if err := b.client.schemaSet.ValidateCreate(adjustedPath, b.createMode, payload); err != nil {
+		return "", err
+	}
+
+	if data, err := b.client.encodeData(givenPath, payload, b.client.shouldCompress(givenPath, b.compress, b.skipCompress)); err != nil {
+		return "", err
+	} else {
+		payload = data
+	}
 
 	if b.backgrounding.inBackground {
-		go b.pathInBackground(adjustedPath, payload, givenPath)
+		b.client.client.inBackground(func() { b.pathInBackground(adjustedPath, payload, givenPath) })
 
 		return b.client.unfixForNamespace(adjustedPath), nil
 	} else {
@@ -65,22 +82,57 @@ func (b *createBuilder) pathInBackground(path string, payload []byte, givenPath
 }
 
 func (b *createBuilder) pathInForeground(path string, payload []byte) (string, error) {
+	if err := b.client.ensureWritable(); err != nil {
+		return "", err
+	}
+
 	zkClient := b.client.ZookeeperClient()
 
 	result, err := zkClient.NewRetryLoop().CallWithRetry(func() (interface{}, error) {
 		if conn, err := zkClient.Conn(); err != nil {
 			return nil, err
 		} else {
-			createdPath, err := conn.Create(path, payload, int32(b.createMode), b.acling.getAclList(path))
+			// create the node, with the chunks in the same transaction if any
+			createNode := func(data []byte, chunks []interface{}) (string, error) {
+				acls := b.acling.getAclList(path)
+
+				if len(chunks) == 0 {
+					return conn.Create(path, data, int32(b.createMode), acls)
+				}
 
-			if err == zk.ErrNoNode && b.createParentsIfNeeded {
-				if err := MakeDirs(conn, path, false, b.acling.aclProvider); err != nil {
+				responses, err := conn.Multi(append([]interface{}{&zk.CreateRequest{Path: path, Data: data, Acl: acls, Flags: int32(b.createMode)}}, chunks...)...)
+
+				if err != nil {
 					return "", err
 				}
 
-				return conn.Create(path, payload, int32(b.createMode), b.acling.getAclList(path))
+				return responses[0].String, nil
+			}
+
+			create := func(data []byte, chunks ...interface{}) (string, error) {
+				createdPath, err := createNode(data, chunks)
+
+				if b.client.namespace.recreate(conn, err) {
+					createdPath, err = createNode(data, chunks)
+				}
+
+				if err == zk.ErrNoNode && b.createParentsIfNeeded {
+					if err := MakeDirs(conn, path, false, b.acling.aclProvider); err != nil {
+						return "", err
+					}
+
+					return createNode(data, chunks)
+				} else {
+					return createdPath, err
+				}
+			}
+
+			if !b.client.shouldChunk(payload) {
+				return create(payload)
+			} else if b.createMode.IsEphemeral() {
+				return "", ErrChunkedEphemeral
 			} else {
-				return createdPath, err
+				return b.client.createChunked(conn, path, payload, b.createMode, b.acling.getAclList(path), create)
 			}
 		}
 	})
@@ -110,6 +162,16 @@ func (b *createBuilder) Compressed() CreateBuilder {
 	return b
 }
 
+func (b *createBuilder) SkipCompression() CreateBuilder {
+	b.skipCompress = true
+	return b
+}
+
+func (b *createBuilder) Encoded(v interface{}) CreateBuilder {
+	b.encoding, b.encoded = true, v
+	return b
+}
+
 func (b *createBuilder) InBackground() CreateBuilder {
 	b.backgrounding = backgrounding{inBackground: true}
 	return b
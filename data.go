@@ -3,23 +3,31 @@ package curator
 import "github.com/yxdrlitao/go-zookeeper/zk"
 
 type getDataBuilder struct {
-	client        *curatorFramework
-	backgrounding backgrounding
-	decompress    bool
-	stat          *zk.Stat
-	watching      watching
+	client         *curatorFramework
+	backgrounding  backgrounding
+	decompress     bool
+	skipDecompress bool
+	skipDecrypt    bool // only used by ReEncrypt()
+	into           interface{}
+	stat           *zk.Stat
+	watching       watching
+	stale          *bool
 }
 
 func (b *getDataBuilder) ForPath(givenPath string) ([]byte, error) {
-	adjustedPath := b.client.fixForNamespace(givenPath, false)
+	adjustedPath, err := b.client.fixForNamespace(givenPath, false)
+
+	if err != nil {
+		return nil, err
+	}
 
 	if b.backgrounding.inBackground {
-		go b.pathInBackground(adjustedPath, givenPath)
+		b.client.client.inBackground(func() { b.pathInBackground(adjustedPath, givenPath) })
 
 		return nil, nil
 	}
 
-	if payload, err := b.pathInForeground(adjustedPath); err != nil {
+	if payload, err := b.pathInForeground(adjustedPath, givenPath); err != nil {
 		return nil, err
 	} else {
 		return payload, err
@@ -31,7 +39,7 @@ func (b *getDataBuilder) pathInBackground(adjustedPath, givenPath string) {
 
 	defer tracer.Commit()
 
-	data, err := b.pathInForeground(adjustedPath)
+	data, err := b.pathInForeground(adjustedPath, givenPath)
 
 	if b.backgrounding.callback != nil {
 		event := &curatorEvent{
@@ -41,6 +49,7 @@ func (b *getDataBuilder) pathInBackground(adjustedPath, givenPath string) {
 			data:      data,
 			stat:      b.stat,
 			context:   b.backgrounding.context,
+			stale:     b.client.ReadOnly(),
 		}
 
 		if err != nil {
@@ -53,8 +62,9 @@ func (b *getDataBuilder) pathInBackground(adjustedPath, givenPath string) {
 	}
 }
 
-func (b *getDataBuilder) pathInForeground(path string) ([]byte, error) {
+func (b *getDataBuilder) pathInForeground(path, givenPath string) ([]byte, error) {
 	zkClient := b.client.ZookeeperClient()
+	decompress := b.client.shouldCompress(givenPath, b.decompress, b.skipDecompress)
 
 	result, err := zkClient.NewRetryLoop().CallWithRetry(func() (interface{}, error) {
 		if conn, err := zkClient.Conn(); err != nil {
@@ -66,16 +76,28 @@ func (b *getDataBuilder) pathInForeground(path string) ([]byte, error) {
 			var err error
 
 			if b.watching.watched || b.watching.watcher != nil {
+				if err := b.client.schemaSet.ValidateWatch(path); err != nil {
+					return nil, err
+				}
+
 				data, stat, events, err = conn.GetW(path)
 
 				if events != nil && b.watching.watcher != nil {
-					go NewWatchers(b.watching.watcher).Watch(events)
+					b.client.client.watch(b.watching.watcher, events)
 				}
 			} else {
 				data, stat, err = conn.Get(path)
 			}
 
+			if manifest, ok := parseChunkManifest(data); ok && err == nil && b.client.chunkSize > 0 {
+				if data, err = b.client.readChunks(conn, path, manifest, stat); err != nil {
+					return nil, err
+				}
+			}
+
 			if stat != nil {
+				b.client.client.recordStat(stat)
+
 				if b.stat != nil {
 					*b.stat = *stat
 				} else {
@@ -83,11 +105,15 @@ func (b *getDataBuilder) pathInForeground(path string) ([]byte, error) {
 				}
 			}
 
-			if b.decompress {
-				if payload, err := b.client.compressionProvider.Decompress(path, data); err != nil {
+			if err == nil {
+				if data, err = b.client.decodeData(givenPath, data, !b.skipDecrypt, decompress); err != nil {
 					return nil, err
-				} else {
-					data = payload
+				}
+
+				if b.into != nil {
+					if err := DecodeData(b.client.codec, givenPath, data, b.into); err != nil {
+						return nil, err
+					}
 				}
 			}
 
@@ -97,6 +123,10 @@ func (b *getDataBuilder) pathInForeground(path string) ([]byte, error) {
 
 	data, _ := result.([]byte)
 
+	if b.stale != nil {
+		*b.stale = b.client.ReadOnly()
+	}
+
 	return data, err
 }
 
@@ -106,12 +136,30 @@ func (b *getDataBuilder) Decompressed() GetDataBuilder {
 	return b
 }
 
+func (b *getDataBuilder) SkipDecompression() GetDataBuilder {
+	b.skipDecompress = true
+
+	return b
+}
+
+func (b *getDataBuilder) Into(v interface{}) GetDataBuilder {
+	b.into = v
+
+	return b
+}
+
 func (b *getDataBuilder) StoringStatIn(stat *zk.Stat) GetDataBuilder {
 	b.stat = stat
 
 	return b
 }
 
+func (b *getDataBuilder) StoringStaleIn(stale *bool) GetDataBuilder {
+	b.stale = stale
+
+	return b
+}
+
 func (b *getDataBuilder) Watched() GetDataBuilder {
 	b.watching.watched = true
 
@@ -149,10 +197,14 @@ func (b *getDataBuilder) InBackgroundWithCallbackAndContext(callback BackgroundC
 }
 
 type setDataBuilder struct {
-	client        *curatorFramework
-	backgrounding backgrounding
-	version       int32
-	compress      bool
+	client         *curatorFramework
+	backgrounding  backgrounding
+	version        int32
+	compress       bool
+	skipCompress   bool
+	encoding       bool
+	encoded        interface{}
+	skipValidation bool // only used by ReEncrypt()
 }
 
 func (b *setDataBuilder) ForPath(path string) (*zk.Stat, error) {
@@ -160,18 +212,34 @@ func (b *setDataBuilder) ForPath(path string) (*zk.Stat, error) {
 }
 
 func (b *setDataBuilder) ForPathWithData(givenPath string, payload []byte) (*zk.Stat, error) {
-	if b.compress {
-		if data, err := b.client.compressionProvider.Compress(givenPath, payload); err != nil {
+	if b.encoding {
+		if data, err := EncodeData(b.client.codec, givenPath, b.encoded); err != nil {
 			return nil, err
 		} else {
 			payload = data
 		}
 	}
 
-	adjustedPath := b.client.fixForNamespace(givenPath, false)
+	adjustedPath, err := b.client.fixForNamespace(givenPath, false)
+
+	if err != nil {
+		return nil, err
+	}
+
+	if b.skipValidation {
+		// the payload is still compressed, which was validated when it was written
+	} else if err := b.client.schemaSet.ValidateData(adjustedPath, payload); err != nil {
+		return nil, err
+	}
+
+	if data, err := b.client.encodeData(givenPath, payload, b.client.shouldCompress(givenPath, b.compress, b.skipCompress)); err != nil {
+		return nil, err
+	} else {
+		payload = data
+	}
 
 	if b.backgrounding.inBackground {
-		go b.pathInBackground(adjustedPath, payload, givenPath)
+		b.client.client.inBackground(func() { b.pathInBackground(adjustedPath, payload, givenPath) })
 
 		return nil, nil
 	} else {
@@ -207,11 +275,17 @@ func (b *setDataBuilder) pathInBackground(path string, payload []byte, givenPath
 }
 
 func (b *setDataBuilder) pathInForeground(path string, payload []byte) (*zk.Stat, error) {
+	if err := b.client.ensureWritable(); err != nil {
+		return nil, err
+	}
+
 	zkClient := b.client.ZookeeperClient()
 
 	result, err := zkClient.NewRetryLoop().CallWithRetry(func() (interface{}, error) {
 		if conn, err := zkClient.Conn(); err != nil {
 			return nil, err
+		} else if b.client.chunkSize > 0 {
+			return b.client.setChunked(conn, path, payload, b.version)
 		} else {
 			return conn.Set(path, payload, b.version)
 		}
@@ -219,6 +293,8 @@ func (b *setDataBuilder) pathInForeground(path string, payload []byte) (*zk.Stat
 
 	stat, _ := result.(*zk.Stat)
 
+	b.client.client.recordStat(stat)
+
 	return stat, err
 }
 
@@ -232,6 +308,16 @@ func (b *setDataBuilder) Compressed() SetDataBuilder {
 	return b
 }
 
+func (b *setDataBuilder) SkipCompression() SetDataBuilder {
+	b.skipCompress = true
+	return b
+}
+
+func (b *setDataBuilder) Encoded(v interface{}) SetDataBuilder {
+	b.encoding, b.encoded = true, v
+	return b
+}
+
 func (b *setDataBuilder) InBackground() SetDataBuilder {
 	b.backgrounding = backgrounding{inBackground: true}
 	return b
@@ -95,6 +95,31 @@ func (s *GetDataBuilderTestSuite) TestWatcher() {
 	})
 }
 
+func (s *GetDataBuilderTestSuite) TestNamespaceWatcher() {
+	s.WithNamespace("parent", func(client CuratorFramework, conn *mockConn, wg *sync.WaitGroup, data []byte, stat *zk.Stat) {
+		events := make(chan zk.Event)
+
+		defer close(events)
+
+		conn.On("Exists", "/parent").Return(true, nil, nil).Once()
+		conn.On("GetW", "/parent/child").Return(data, stat, events, nil).Once()
+
+		_, err := client.GetData().UsingWatcher(NewWatcher(func(event *zk.Event) {
+			defer wg.Done()
+
+			assert.Equal(s.T(), zk.EventNodeDataChanged, event.Type)
+			assert.Equal(s.T(), "/child", event.Path)
+		})).ForPath("/child")
+
+		assert.NoError(s.T(), err)
+
+		events <- zk.Event{
+			Type: zk.EventNodeDataChanged,
+			Path: "/parent/child",
+		}
+	})
+}
+
 type SetDataBuilderTestSuite struct {
 	mockContainerTestSuite
 }
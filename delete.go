@@ -10,10 +10,14 @@ type deleteBuilder struct {
 }
 
 func (b *deleteBuilder) ForPath(givenPath string) error {
-	adjustedPath := b.client.fixForNamespace(givenPath, false)
+	adjustedPath, err := b.client.fixForNamespace(givenPath, false)
+
+	if err != nil {
+		return err
+	}
 
 	if b.backgrounding.inBackground {
-		go b.pathInBackground(adjustedPath, givenPath)
+		b.client.client.inBackground(func() { b.pathInBackground(adjustedPath, givenPath) })
 
 		return nil
 	} else {
@@ -47,6 +51,10 @@ func (b *deleteBuilder) pathInBackground(path string, givenPath string) {
 }
 
 func (b *deleteBuilder) pathInForeground(path string, givenPath string) error {
+	if err := b.client.ensureWritable(); err != nil {
+		return err
+	}
+
 	zkClient := b.client.ZookeeperClient()
 
 	_, err := zkClient.NewRetryLoop().CallWithRetry(func() (interface{}, error) {
@@ -55,6 +63,10 @@ func (b *deleteBuilder) pathInForeground(path string, givenPath string) error {
 		if err == nil {
 			err = conn.Delete(path, b.version)
 
+			if err == zk.ErrNotEmpty && b.client.chunkSize > 0 {
+				err = b.client.deleteChunked(conn, path, b.version)
+			}
+
 			if err == zk.ErrNotEmpty && b.deletingChildrenIfNeeded {
 				err = DeleteChildren(conn, path, true)
 			}
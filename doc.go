@@ -103,11 +103,27 @@ Curator provides generic API for builder
 	type Compressible[T] interface {
 	    // Cause the data to be compressed using the configured compression provider
 	    Compressed() T
+
+	    // Don't compress the data even if the compression is enabled for all the operations
+	    SkipCompression() T
 	}
 
 	type Decompressible[T] interface {
 	    // Cause the data to be de-compressed using the configured compression provider
 	    Decompressed() T
+
+	    // Don't de-compress the data even if the compression is enabled for all the operations
+	    SkipDecompression() T
+	}
+
+	type Encodable[T] interface {
+	    // Encode the value with the codec of the client as the data
+	    Encoded(v interface{}) T
+	}
+
+	type Decodable[T] interface {
+	    // Decode the data with the codec of the client into the value
+	    Into(v interface{}) T
 	}
 
 	type CreateModable[T] interface {
@@ -130,6 +146,11 @@ Curator provides generic API for builder
 	    StoringStatIn(*zk.Stat) T
 	}
 
+	type Staleable[T] interface {
+	    // Have the operation tell whether the result may be stale as the connection is read-only
+	    StoringStaleIn(*bool) T
+	}
+
 	type ParentsCreatable[T] interface {
 	    // Causes any parent nodes to get created if they haven't already been
 	    CreatingParentsIfNeeded() T
@@ -0,0 +1,341 @@
+package curator
+
+import (
+	"bytes"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"errors"
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/yxdrlitao/go-zookeeper/zk"
+)
+
+const ENCRYPTION_VERSION byte = 1
+
+var (
+	// The magic header of AESGCMEncryptionProvider, followed by the version, the key id and the nonce
+	ENCRYPTION_MAGIC = []byte{0xC7, 'E', 'K'}
+
+	ErrNotEncrypted = errors.New("data is not encrypted")
+)
+
+// Encrypt the data of znodes on the client side, so the data is not readable by the clients without the keys.
+//
+// The data is compressed before encrypted, and decrypted before decompressed.
+type EncryptionProvider interface {
+	// Encrypt the data of path
+	Encrypt(path string, data []byte) ([]byte, error)
+
+	// Decrypt the data of path
+	Decrypt(path string, encryptedData []byte) ([]byte, error)
+}
+
+// Optional interface of EncryptionProvider, which tells whether the data should be re-encrypted by ReEncrypt()
+type ReEncryptable interface {
+	// Return true if the data is not encrypted, or encrypted with a key other than the current one
+	NeedsReEncryption(path string, encryptedData []byte) bool
+}
+
+// A set of keys identified by id, the current key is used to encrypt and all the keys could be used to decrypt.
+//
+// Rotate the key to encrypt the new data with the new key while the old data is still readable,
+// then re-encrypt the existing data with ReEncrypt() and remove the old key.
+type Keyring struct {
+	lock    sync.RWMutex
+	current string
+	keys    map[string]cipher.AEAD
+}
+
+func NewKeyring() *Keyring {
+	return &Keyring{keys: make(map[string]cipher.AEAD)}
+}
+
+// Add a AES-128, AES-192 or AES-256 key for decryption, the first key added is used as the current key
+func (k *Keyring) Add(id string, key []byte) error {
+	if len(id) == 0 || len(id) > 255 {
+		return fmt.Errorf("invalid key id `%s`", id)
+	}
+
+	block, err := aes.NewCipher(key)
+
+	if err != nil {
+		return err
+	}
+
+	aead, err := cipher.NewGCM(block)
+
+	if err != nil {
+		return err
+	}
+
+	k.lock.Lock()
+	defer k.lock.Unlock()
+
+	k.keys[id] = aead
+
+	if len(k.current) == 0 {
+		k.current = id
+	}
+
+	return nil
+}
+
+// Add the key and use it as the current key
+func (k *Keyring) Rotate(id string, key []byte) error {
+	if err := k.Add(id, key); err != nil {
+		return err
+	}
+
+	k.lock.Lock()
+	defer k.lock.Unlock()
+
+	k.current = id
+
+	return nil
+}
+
+// Remove a key which is no longer used, the current key can't be removed
+func (k *Keyring) Remove(id string) error {
+	k.lock.Lock()
+	defer k.lock.Unlock()
+
+	if id == k.current {
+		return fmt.Errorf("can't remove the current key `%s`", id)
+	}
+
+	delete(k.keys, id)
+
+	return nil
+}
+
+// Return the id of the current key
+func (k *Keyring) Current() string {
+	k.lock.RLock()
+	defer k.lock.RUnlock()
+
+	return k.current
+}
+
+// Return the ids of all the keys
+func (k *Keyring) Keys() []string {
+	k.lock.RLock()
+	defer k.lock.RUnlock()
+
+	var ids []string
+
+	for id := range k.keys {
+		ids = append(ids, id)
+	}
+
+	sort.Strings(ids)
+
+	return ids
+}
+
+func (k *Keyring) key(id string) (cipher.AEAD, error) {
+	k.lock.RLock()
+	defer k.lock.RUnlock()
+
+	if aead, exists := k.keys[id]; exists {
+		return aead, nil
+	}
+
+	return nil, fmt.Errorf("unknown encryption key `%s`", id)
+}
+
+type pathKeyring struct {
+	prefix  string
+	keyring *Keyring
+}
+
+// Encryption provider with AES-GCM, which writes a header with the key id before the nonce and the sealed data,
+// the header is authenticated as well.
+type AESGCMEncryptionProvider struct {
+	keyring        *Keyring
+	pathKeyrings   []pathKeyring
+	allowPlaintext bool
+}
+
+func NewAESGCMEncryptionProvider(keyring *Keyring) *AESGCMEncryptionProvider {
+	return &AESGCMEncryptionProvider{keyring: keyring}
+}
+
+// Use the keyring for the path and its descendants, the longest matched prefix wins
+func (p *AESGCMEncryptionProvider) WithPathKeyring(prefix string, keyring *Keyring) *AESGCMEncryptionProvider {
+	p.pathKeyrings = append(p.pathKeyrings, pathKeyring{strings.TrimSuffix(prefix, PATH_SEPARATOR), keyring})
+
+	sort.SliceStable(p.pathKeyrings, func(i, j int) bool {
+		return len(p.pathKeyrings[i].prefix) > len(p.pathKeyrings[j].prefix)
+	})
+
+	return p
+}
+
+// Return the data without the header as it is, instead of ErrNotEncrypted, e.g. during the migration
+func (p *AESGCMEncryptionProvider) AllowPlaintext() *AESGCMEncryptionProvider {
+	p.allowPlaintext = true
+
+	return p
+}
+
+func (p *AESGCMEncryptionProvider) keyringFor(path string) *Keyring {
+	for _, k := range p.pathKeyrings {
+		if len(k.prefix) == 0 || path == k.prefix || strings.HasPrefix(path, k.prefix+PATH_SEPARATOR) {
+			return k.keyring
+		}
+	}
+
+	return p.keyring
+}
+
+func (p *AESGCMEncryptionProvider) Encrypt(path string, data []byte) ([]byte, error) {
+	keyring := p.keyringFor(path)
+	id := keyring.Current()
+
+	aead, err := keyring.key(id)
+
+	if err != nil {
+		return nil, err
+	}
+
+	header := make([]byte, 0, len(ENCRYPTION_MAGIC)+2+len(id)+aead.NonceSize())
+	header = append(header, ENCRYPTION_MAGIC...)
+	header = append(header, ENCRYPTION_VERSION, byte(len(id)))
+	header = append(header, id...)
+
+	nonce := make([]byte, aead.NonceSize())
+
+	if _, err := io.ReadFull(rand.Reader, nonce); err != nil {
+		return nil, err
+	}
+
+	return aead.Seal(append(header, nonce...), nonce, data, header), nil
+}
+
+func (p *AESGCMEncryptionProvider) Decrypt(path string, encryptedData []byte) ([]byte, error) {
+	// the parents created by CreatingParentsIfNeeded() have no data
+	if len(encryptedData) == 0 {
+		return encryptedData, nil
+	}
+
+	id, header, sealed, err := parseEncryptionHeader(encryptedData)
+
+	if err == ErrNotEncrypted && p.allowPlaintext {
+		return encryptedData, nil
+	} else if err != nil {
+		return nil, err
+	}
+
+	aead, err := p.keyringFor(path).key(id)
+
+	if err != nil {
+		return nil, err
+	}
+
+	if len(sealed) < aead.NonceSize() {
+		return nil, fmt.Errorf("truncated encrypted data of %s", path)
+	}
+
+	data, err := aead.Open(nil, sealed[:aead.NonceSize()], sealed[aead.NonceSize():], header)
+
+	if err != nil {
+		return nil, fmt.Errorf("fail to decrypt data of %s with key `%s`, %s", path, id, err)
+	}
+
+	return data, nil
+}
+
+func (p *AESGCMEncryptionProvider) NeedsReEncryption(path string, encryptedData []byte) bool {
+	id, _, _, err := parseEncryptionHeader(encryptedData)
+
+	return err != nil || id != p.keyringFor(path).Current()
+}
+
+// Return the key id, the authenticated header and the nonce with the sealed data
+func parseEncryptionHeader(data []byte) (string, []byte, []byte, error) {
+	if !bytes.HasPrefix(data, ENCRYPTION_MAGIC) || len(data) < len(ENCRYPTION_MAGIC)+2 {
+		return "", nil, nil, ErrNotEncrypted
+	}
+
+	if version := data[len(ENCRYPTION_MAGIC)]; version != ENCRYPTION_VERSION {
+		return "", nil, nil, fmt.Errorf("unsupported encryption version %d", version)
+	}
+
+	size := len(ENCRYPTION_MAGIC) + 2 + int(data[len(ENCRYPTION_MAGIC)+1])
+
+	if len(data) < size {
+		return "", nil, nil, fmt.Errorf("truncated encryption header")
+	}
+
+	return string(data[len(ENCRYPTION_MAGIC)+2 : size]), data[:size], data[size:], nil
+}
+
+// Re-encrypt the data of the subtree with the current keys of the encryption provider of the client,
+// e.g. after the key is rotated, or to encrypt the existing plaintext data. Return the paths re-encrypted.
+//
+// The data is re-encrypted as it is stored, without decompressing and compressing again.
+// A node modified concurrently is skipped, since it has been written with the current key.
+func ReEncrypt(client CuratorFramework, root string) ([]string, error) {
+	builder, ok := client.GetData().(*getDataBuilder)
+
+	if !ok {
+		return nil, errors.New("the client doesn't support re-encryption")
+	} else if builder.client.encryptionProvider == nil {
+		return nil, errors.New("the client has no encryption provider")
+	}
+
+	provider := builder.client.encryptionProvider
+
+	var paths []string
+
+	err := Walk(client, root, func(node *WalkNode) error {
+		nodePath := node.Path
+
+		var stat zk.Stat
+
+		builder := client.GetData().(*getDataBuilder)
+		builder.skipDecrypt = true
+
+		data, err := builder.SkipDecompression().StoringStatIn(&stat).ForPath(nodePath)
+
+		if err == zk.ErrNoNode {
+			return ErrSkipChildren
+		} else if err != nil {
+			return err
+		}
+
+		needed := len(data) > 0
+
+		if p, ok := provider.(ReEncryptable); ok && needed {
+			needed = p.NeedsReEncryption(nodePath, data)
+		}
+
+		if needed {
+			payload, err := provider.Decrypt(nodePath, data)
+
+			if err == ErrNotEncrypted {
+				payload = data
+			} else if err != nil {
+				return err
+			}
+
+			setter := client.SetData().(*setDataBuilder)
+			setter.skipValidation = true
+
+			if _, err := setter.SkipCompression().WithVersion(stat.Version).ForPathWithData(nodePath, payload); err == nil {
+				paths = append(paths, nodePath)
+			} else if err != zk.ErrBadVersion && err != zk.ErrNoNode {
+				return err
+			}
+		}
+
+		return nil
+	}, nil)
+
+	return paths, err
+}
@@ -0,0 +1,324 @@
+package curator
+
+import (
+	"bytes"
+	"errors"
+	"regexp"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/suite"
+	"github.com/yxdrlitao/go-zookeeper/zk"
+)
+
+var (
+	key1 = bytes.Repeat([]byte{1}, 16)
+	key2 = bytes.Repeat([]byte{2}, 32)
+)
+
+func newTestKeyring(t *testing.T, id string, key []byte) *Keyring {
+	keyring := NewKeyring()
+
+	assert.NoError(t, keyring.Add(id, key))
+
+	return keyring
+}
+
+func TestKeyring(t *testing.T) {
+	keyring := NewKeyring()
+
+	assert.Error(t, keyring.Add("", key1))
+	assert.Error(t, keyring.Add("short", []byte("short")))
+	assert.NoError(t, keyring.Add("k1", key1))
+	assert.Equal(t, "k1", keyring.Current())
+
+	assert.NoError(t, keyring.Add("k2", key2))
+	assert.Equal(t, "k1", keyring.Current(), "the first key is the current key")
+
+	assert.NoError(t, keyring.Rotate("k3", key2))
+	assert.Equal(t, "k3", keyring.Current())
+	assert.Equal(t, []string{"k1", "k2", "k3"}, keyring.Keys())
+
+	assert.EqualError(t, keyring.Remove("k3"), "can't remove the current key `k3`")
+	assert.NoError(t, keyring.Remove("k1"))
+	assert.Equal(t, []string{"k2", "k3"}, keyring.Keys())
+}
+
+func TestAESGCMEncryptionProvider(t *testing.T) {
+	keyring := newTestKeyring(t, "k1", key1)
+	p := NewAESGCMEncryptionProvider(keyring)
+
+	data, err := p.Encrypt("/node", []byte("secret"))
+
+	assert.NoError(t, err)
+	assert.Equal(t, ENCRYPTION_MAGIC, data[:len(ENCRYPTION_MAGIC)])
+	assert.Equal(t, []byte{ENCRYPTION_VERSION, 2, 'k', '1'}, data[len(ENCRYPTION_MAGIC):len(ENCRYPTION_MAGIC)+4])
+	assert.NotContains(t, string(data), "secret")
+	assert.False(t, p.NeedsReEncryption("/node", data))
+
+	data2, err := p.Encrypt("/node", []byte("secret"))
+
+	assert.NoError(t, err)
+	assert.NotEqual(t, data, data2, "random nonce")
+
+	plain, err := p.Decrypt("/node", data)
+
+	assert.NoError(t, err)
+	assert.Equal(t, "secret", string(plain))
+
+	// the old data is readable after the key is rotated
+	assert.NoError(t, keyring.Rotate("k2", key2))
+	assert.True(t, p.NeedsReEncryption("/node", data))
+
+	plain, err = p.Decrypt("/node", data)
+
+	assert.NoError(t, err)
+	assert.Equal(t, "secret", string(plain))
+
+	assert.NoError(t, keyring.Remove("k1"))
+
+	_, err = p.Decrypt("/node", data)
+
+	assert.EqualError(t, err, "unknown encryption key `k1`")
+
+	// tampered data
+	data, err = p.Encrypt("/node", []byte("secret"))
+
+	assert.NoError(t, err)
+
+	data[len(data)-1] ^= 0xff
+
+	_, err = p.Decrypt("/node", data)
+
+	assert.Error(t, err)
+
+	// tampered key id
+	data, err = p.Encrypt("/node", []byte("secret"))
+
+	assert.NoError(t, err)
+
+	data[len(ENCRYPTION_MAGIC)+3] = '3'
+
+	_, err = p.Decrypt("/node", data)
+
+	assert.EqualError(t, err, "unknown encryption key `k3`")
+
+	// plaintext
+	_, err = p.Decrypt("/node", []byte("plain"))
+
+	assert.Equal(t, ErrNotEncrypted, err)
+	assert.True(t, p.NeedsReEncryption("/node", []byte("plain")))
+
+	plain, err = p.AllowPlaintext().Decrypt("/node", []byte("plain"))
+
+	assert.NoError(t, err)
+	assert.Equal(t, "plain", string(plain))
+
+	plain, err = p.Decrypt("/node", []byte{})
+
+	assert.NoError(t, err)
+	assert.Empty(t, plain)
+}
+
+func TestPathKeyring(t *testing.T) {
+	p := NewAESGCMEncryptionProvider(newTestKeyring(t, "default", key1)).
+		WithPathKeyring("/secrets", newTestKeyring(t, "secrets", key2)).
+		WithPathKeyring("/secrets/db/", newTestKeyring(t, "db", key1))
+
+	for path, id := range map[string]string{
+		"/config":          "default",
+		"/secretsxxx":      "default",
+		"/secrets":         "secrets",
+		"/secrets/app":     "secrets",
+		"/secrets/db":      "db",
+		"/secrets/db/user": "db",
+	} {
+		data, err := p.Encrypt(path, []byte("data"))
+
+		assert.NoError(t, err)
+
+		keyID, _, _, err := parseEncryptionHeader(data)
+
+		assert.NoError(t, err)
+		assert.Equal(t, id, keyID, path)
+
+		plain, err := p.Decrypt(path, data)
+
+		assert.NoError(t, err)
+		assert.Equal(t, "data", string(plain))
+	}
+
+	data, err := p.Encrypt("/secrets/app", []byte("data"))
+
+	assert.NoError(t, err)
+
+	_, err = p.Decrypt("/config", data)
+
+	assert.EqualError(t, err, "unknown encryption key `secrets`")
+}
+
+type EncryptionTestSuite struct {
+	mockContainerTestSuite
+
+	keyring  *Keyring
+	provider *AESGCMEncryptionProvider
+}
+
+func TestEncryption(t *testing.T) {
+	suite.Run(t, new(EncryptionTestSuite))
+}
+
+func (s *EncryptionTestSuite) SetupTest() {
+	s.keyring = newTestKeyring(s.T(), "k1", key1)
+	s.provider = NewAESGCMEncryptionProvider(s.keyring)
+}
+
+func (s *EncryptionTestSuite) encrypted(path string, expected []byte) interface{} {
+	return mock.MatchedBy(func(data []byte) bool {
+		plain, err := s.provider.Decrypt(path, data)
+
+		return err == nil && bytes.Equal(expected, plain)
+	})
+}
+
+func (s *EncryptionTestSuite) TestCreateAndGetData() {
+	s.WithPrepare(func(builder *CuratorFrameworkBuilder) {
+		builder.Encryption(s.provider)
+	}, func(client CuratorFramework, conn *mockConn, acls []zk.ACL, stat *zk.Stat) {
+		var stored []byte
+
+		conn.On("Create", "/node", s.encrypted("/node", []byte("secret")), int32(PERSISTENT), acls).Return("/node", nil).Run(func(args mock.Arguments) {
+			stored = args.Get(1).([]byte)
+		}).Once()
+		conn.On("Set", "/node", s.encrypted("/node", []byte("secret2")), int32(AnyVersion)).Return(stat, nil).Once()
+
+		_, err := client.Create().WithACL(acls...).ForPathWithData("/node", []byte("secret"))
+
+		s.NoError(err)
+
+		_, err = client.SetData().ForPathWithData("/node", []byte("secret2"))
+
+		s.NoError(err)
+
+		conn.On("Get", "/node").Return(stored, stat, nil).Once()
+
+		data, err := client.GetData().ForPath("/node")
+
+		s.NoError(err)
+		s.Equal("secret", string(data))
+
+		conn.On("Get", "/plain").Return([]byte("plain"), stat, nil).Once()
+
+		_, err = client.GetData().ForPath("/plain")
+
+		s.Equal(ErrNotEncrypted, err)
+	})
+}
+
+func (s *EncryptionTestSuite) TestCompressThenEncrypt() {
+	s.WithPrepare(func(builder *CuratorFrameworkBuilder) {
+		builder.Encryption(s.provider).EnableCompression()
+	}, func(client CuratorFramework, conn *mockConn, compress *mockCompressionProvider, acls []zk.ACL, stat *zk.Stat) {
+		var stored []byte
+
+		compress.On("Compress", "/node", []byte("secret")).Return([]byte("compressed(secret)"), nil).Once()
+		compress.On("Decompress", "/node", []byte("compressed(secret)")).Return([]byte("secret"), nil).Once()
+		conn.On("Create", "/node", s.encrypted("/node", []byte("compressed(secret)")), int32(PERSISTENT), acls).Return("/node", nil).Run(func(args mock.Arguments) {
+			stored = args.Get(1).([]byte)
+		}).Once()
+
+		_, err := client.Create().WithACL(acls...).ForPathWithData("/node", []byte("secret"))
+
+		s.NoError(err)
+
+		conn.On("Get", "/node").Return(stored, stat, nil).Once()
+
+		data, err := client.GetData().ForPath("/node")
+
+		s.NoError(err)
+		s.Equal("secret", string(data))
+	})
+}
+
+func (s *EncryptionTestSuite) TestTransaction() {
+	s.WithPrepare(func(builder *CuratorFrameworkBuilder) {
+		builder.Encryption(s.provider)
+	}, func(client CuratorFramework, conn *mockConn, acls []zk.ACL) {
+		conn.On("Multi", mock.Anything).Return([]zk.MultiResponse{{Stat: &zk.Stat{}}}, nil).Once()
+
+		_, err := client.InTransaction().SetData().ForPathWithData("/node", []byte("secret")).Commit()
+
+		s.NoError(err)
+		s.Len(conn.operations, 1)
+
+		data, err := s.provider.Decrypt("/node", conn.operations[0].(*zk.SetDataRequest).Data)
+
+		s.NoError(err)
+		s.Equal("secret", string(data))
+
+		// the error of encryption fails the transaction
+		s.keyring.current = "missing"
+
+		_, err = client.InTransaction().SetData().ForPathWithData("/node", []byte("secret")).Commit()
+
+		s.EqualError(err, "unknown encryption key `missing`")
+	})
+}
+
+func (s *EncryptionTestSuite) TestReEncrypt() {
+	s.WithPrepare(func(builder *CuratorFrameworkBuilder) {
+		builder.Encryption(s.provider)
+		// the data may be compressed, so it's not validated again
+		builder.Schemas(NewSchemaSet(&Schema{PathRegex: regexp.MustCompile(`^/root/.+$`), Validator: DataValidatorFunc(func(path string, data []byte) error {
+			return errors.New("unexpected validation")
+		})}))
+	}, func(client CuratorFramework, conn *mockConn, stat *zk.Stat) {
+		old, err := s.provider.Encrypt("/root/old", []byte("old"))
+
+		s.NoError(err)
+
+		s.NoError(s.keyring.Rotate("k2", key2))
+
+		current, err := s.provider.Encrypt("/root/current", []byte("current"))
+
+		s.NoError(err)
+
+		conn.On("Get", "/root").Return([]byte{}, &zk.Stat{Version: 1}, nil).Once()
+		conn.On("Children", "/root").Return([]string{"plain", "old", "current", "changed"}, stat, nil).Once()
+		conn.On("Get", "/root/changed").Return(old, &zk.Stat{Version: 4}, nil).Once()
+		conn.On("Get", "/root/current").Return(current, &zk.Stat{Version: 2}, nil).Once()
+		conn.On("Get", "/root/old").Return(old, &zk.Stat{Version: 3}, nil).Once()
+		conn.On("Get", "/root/plain").Return([]byte("plain"), &zk.Stat{Version: 5}, nil).Once()
+
+		conn.On("Set", "/root/changed", mock.Anything, int32(4)).Return(nil, zk.ErrBadVersion).Once()
+		conn.On("Set", "/root/old", s.encrypted("/root/old", []byte("old")), int32(3)).Return(stat, nil).Once()
+		conn.On("Set", "/root/plain", s.encrypted("/root/plain", []byte("plain")), int32(5)).Return(stat, nil).Once()
+
+		for _, child := range []string{"changed", "current", "old", "plain"} {
+			conn.On("Children", "/root/"+child).Return([]string{}, stat, nil).Once()
+		}
+
+		paths, err := ReEncrypt(client, "/root")
+
+		s.NoError(err)
+		s.Equal([]string{"/root/old", "/root/plain"}, paths)
+
+		for _, call := range conn.Calls {
+			if call.Method == "Set" && call.Arguments.Get(0) == "/root/old" {
+				keyID, _, _, err := parseEncryptionHeader(call.Arguments.Get(1).([]byte))
+
+				s.NoError(err)
+				s.Equal("k2", keyID)
+			}
+		}
+	})
+}
+
+func TestReEncryptWithoutProvider(t *testing.T) {
+	newMockContainer().Test(t, func(client CuratorFramework) {
+		_, err := ReEncrypt(client, "/")
+
+		assert.EqualError(t, err, "the client has no encryption provider")
+	})
+}
@@ -1,5 +1,22 @@
 package curator
 
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"log"
+	"net"
+	"net/http"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+	"sync/atomic"
+	"time"
+	"unicode"
+)
+
 // Abstraction that provides the ZooKeeper connection string
 type EnsembleProvider interface {
 	// Curator will call this method when CuratorZookeeperClient.Start() is called
@@ -26,3 +43,423 @@ func (p *FixedEnsembleProvider) Start() error { return nil }
 func (p *FixedEnsembleProvider) Close() error { return nil }
 
 func (p *FixedEnsembleProvider) ConnectionString() string { return p.connectString }
+
+const DEFAULT_ENSEMBLE_POLL_INTERVAL = 30 * time.Second
+
+var ErrEmptyConnectionString = errors.New("empty connection string")
+
+// Periodically polls a source of connection strings and keeps the last good value
+type ensemblePoller struct {
+	name          string
+	interval      time.Duration
+	poll          func() (string, error)
+	onError       func(err error)
+	state         State
+	connectString atomic.Value
+	stop          chan struct{}
+	done          chan struct{}
+}
+
+func newEnsemblePoller(name string, interval time.Duration, poll func() (string, error)) *ensemblePoller {
+	if interval <= 0 {
+		interval = DEFAULT_ENSEMBLE_POLL_INTERVAL
+	}
+
+	p := &ensemblePoller{
+		name:     name,
+		interval: interval,
+		poll:     poll,
+		stop:     make(chan struct{}),
+		done:     make(chan struct{}),
+	}
+
+	p.connectString.Store("")
+
+	return p
+}
+
+func (p *ensemblePoller) start() error {
+	if !p.state.Change(LATENT, STARTED) {
+		return fmt.Errorf("Cannot be started more than once")
+	}
+
+	if err := p.update(); err != nil {
+		p.state.Change(STARTED, STOPPED)
+
+		close(p.done)
+
+		return err
+	}
+
+	go p.run()
+
+	return nil
+}
+
+func (p *ensemblePoller) close() error {
+	if p.state.Change(STARTED, STOPPED) {
+		close(p.stop)
+
+		<-p.done
+	}
+
+	return nil
+}
+
+func (p *ensemblePoller) value() string {
+	return p.connectString.Load().(string)
+}
+
+func (p *ensemblePoller) run() {
+	defer close(p.done)
+
+	ticker := time.NewTicker(p.interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-p.stop:
+			return
+
+		case <-ticker.C:
+			if err := p.update(); err != nil {
+				log.Printf("fail to poll %s, keep using `%s`, %s", p.name, p.value(), err)
+
+				if p.onError != nil {
+					p.onError(err)
+				}
+			}
+		}
+	}
+}
+
+func (p *ensemblePoller) update() error {
+	connectString, err := p.poll()
+
+	if err != nil {
+		return err
+	} else if len(connectString) == 0 {
+		return ErrEmptyConnectionString
+	}
+
+	if old := p.value(); old != connectString {
+		if len(old) > 0 {
+			log.Printf("connection string of %s changed from `%s` to `%s`", p.name, old, connectString)
+		}
+
+		p.connectString.Store(connectString)
+	}
+
+	return nil
+}
+
+// Ensemble provider that reads the connection string from a file and reloads it when the file changes.
+//
+// Servers may be separated by commas or new lines, and lines starting with '#' are ignored.
+type FileEnsembleProvider struct {
+	filename string
+	modTime  time.Time
+	size     int64
+	poller   *ensemblePoller
+}
+
+func NewFileEnsembleProvider(filename string, interval time.Duration) *FileEnsembleProvider {
+	p := &FileEnsembleProvider{filename: filename}
+
+	p.poller = newEnsemblePoller(filename, interval, p.load)
+
+	return p
+}
+
+func (p *FileEnsembleProvider) Start() error { return p.poller.start() }
+
+func (p *FileEnsembleProvider) Close() error { return p.poller.close() }
+
+func (p *FileEnsembleProvider) ConnectionString() string { return p.poller.value() }
+
+func (p *FileEnsembleProvider) load() (string, error) {
+	fi, err := os.Stat(p.filename)
+
+	if err != nil {
+		return "", err
+	}
+
+	if connectString := p.poller.value(); len(connectString) > 0 && fi.ModTime().Equal(p.modTime) && fi.Size() == p.size {
+		return connectString, nil
+	}
+
+	content, err := ioutil.ReadFile(p.filename)
+
+	if err != nil {
+		return "", err
+	}
+
+	p.modTime = fi.ModTime()
+	p.size = fi.Size()
+
+	return parseConnectionString(string(content)), nil
+}
+
+func parseConnectionString(content string) string {
+	var servers []string
+
+	for _, line := range strings.Split(content, "\n") {
+		if line = strings.TrimSpace(line); len(line) == 0 || strings.HasPrefix(line, "#") {
+			continue
+		}
+
+		for _, server := range strings.FieldsFunc(line, func(r rune) bool { return r == ',' || unicode.IsSpace(r) }) {
+			servers = append(servers, server)
+		}
+	}
+
+	return strings.Join(servers, ",")
+}
+
+// The DNS lookups used by DNSEnsembleProvider, which could be replaced in tests
+type Resolver interface {
+	LookupSRV(service, proto, name string) (cname string, addrs []*net.SRV, err error)
+
+	LookupHost(host string) (addrs []string, err error)
+}
+
+type defaultResolver struct{}
+
+func (r defaultResolver) LookupSRV(service, proto, name string) (string, []*net.SRV, error) {
+	return net.LookupSRV(service, proto, name)
+}
+
+func (r defaultResolver) LookupHost(host string) ([]string, error) {
+	return net.LookupHost(host)
+}
+
+// Ensemble provider that periodically re-resolves the ensemble from DNS,
+// either from the SRV records of a service or from the addresses of a hostname.
+type DNSEnsembleProvider struct {
+	resolver Resolver
+	resolve  func(resolver Resolver) ([]string, error)
+	poller   *ensemblePoller
+}
+
+// Resolve the ensemble from the SRV records of _service._proto.name
+func NewSRVEnsembleProvider(service, proto, name string, interval time.Duration) *DNSEnsembleProvider {
+	return newDNSEnsembleProvider(fmt.Sprintf("_%s._%s.%s", service, proto, name), interval, func(resolver Resolver) ([]string, error) {
+		_, addrs, err := resolver.LookupSRV(service, proto, name)
+
+		if err != nil {
+			return nil, err
+		}
+
+		var servers []string
+
+		for _, addr := range addrs {
+			servers = append(servers, net.JoinHostPort(strings.TrimSuffix(addr.Target, "."), strconv.Itoa(int(addr.Port))))
+		}
+
+		return servers, nil
+	})
+}
+
+// Resolve the ensemble from all the addresses of a hostname
+func NewHostnameEnsembleProvider(host string, port int, interval time.Duration) *DNSEnsembleProvider {
+	return newDNSEnsembleProvider(host, interval, func(resolver Resolver) ([]string, error) {
+		addrs, err := resolver.LookupHost(host)
+
+		if err != nil {
+			return nil, err
+		}
+
+		var servers []string
+
+		for _, addr := range addrs {
+			servers = append(servers, net.JoinHostPort(addr, strconv.Itoa(port)))
+		}
+
+		return servers, nil
+	})
+}
+
+func newDNSEnsembleProvider(name string, interval time.Duration, resolve func(resolver Resolver) ([]string, error)) *DNSEnsembleProvider {
+	p := &DNSEnsembleProvider{resolver: defaultResolver{}, resolve: resolve}
+
+	p.poller = newEnsemblePoller(name, interval, p.load)
+
+	return p
+}
+
+// Use the resolver to lookup DNS, must be called before Start()
+func (p *DNSEnsembleProvider) WithResolver(resolver Resolver) *DNSEnsembleProvider {
+	p.resolver = resolver
+
+	return p
+}
+
+func (p *DNSEnsembleProvider) Start() error { return p.poller.start() }
+
+func (p *DNSEnsembleProvider) Close() error { return p.poller.close() }
+
+func (p *DNSEnsembleProvider) ConnectionString() string { return p.poller.value() }
+
+func (p *DNSEnsembleProvider) load() (string, error) {
+	servers, err := p.resolve(p.resolver)
+
+	if err != nil {
+		return "", err
+	}
+
+	// the order of DNS answers is not stable, sort them to avoid resetting the connection needlessly
+	sort.Strings(servers)
+
+	return strings.Join(servers, ","), nil
+}
+
+// The response of the HTTP endpoint used by HTTPEnsembleProvider, e.g. {"servers": ["host1", "host2"], "port": 2181}
+type ensembleServers struct {
+	Servers []string `json:"servers"`
+	Port    int      `json:"port"`
+}
+
+// Ensemble provider that polls an HTTP endpoint (in the style of Exhibitor) for the servers of the ensemble.
+//
+// The last good value is kept when polling fails, and the backup provider (if any)
+// is used until the endpoint could be polled successfully.
+type HTTPEnsembleProvider struct {
+	url            string
+	client         *http.Client
+	backup         EnsembleProvider
+	usingBackup    AtomicBool
+	poller         *ensemblePoller
+	errorListeners *UnhandledErrorListenerContainer
+}
+
+func NewHTTPEnsembleProvider(url string, interval time.Duration, backup EnsembleProvider) *HTTPEnsembleProvider {
+	p := &HTTPEnsembleProvider{
+		url:            url,
+		client:         &http.Client{Timeout: 10 * time.Second},
+		backup:         backup,
+		errorListeners: &UnhandledErrorListenerContainer{},
+	}
+
+	p.poller = newEnsemblePoller(url, interval, p.load)
+	p.poller.onError = p.notifyError
+
+	return p
+}
+
+// Use the HTTP client to poll the endpoint, must be called before Start()
+func (p *HTTPEnsembleProvider) WithHTTPClient(client *http.Client) *HTTPEnsembleProvider {
+	p.client = client
+
+	return p
+}
+
+// Returns the listenable interface for polling errors
+func (p *HTTPEnsembleProvider) UnhandledErrorListenable() UnhandledErrorListenable {
+	return p.errorListeners
+}
+
+// Return true if the connection string comes from the backup provider
+func (p *HTTPEnsembleProvider) UsingBackup() bool { return p.usingBackup.Load() }
+
+func (p *HTTPEnsembleProvider) Start() error {
+	if p.backup != nil {
+		if err := p.backup.Start(); err != nil {
+			return err
+		}
+	}
+
+	if err := p.poller.start(); err != nil {
+		if p.backup != nil {
+			p.backup.Close()
+		}
+
+		return err
+	}
+
+	return nil
+}
+
+func (p *HTTPEnsembleProvider) Close() error {
+	err := p.poller.close()
+
+	if p.backup != nil {
+		if backupErr := p.backup.Close(); err == nil {
+			err = backupErr
+		}
+	}
+
+	return err
+}
+
+func (p *HTTPEnsembleProvider) ConnectionString() string { return p.poller.value() }
+
+func (p *HTTPEnsembleProvider) load() (string, error) {
+	connectString, err := p.fetch()
+
+	if err == nil {
+		p.usingBackup.Set(false)
+
+		return connectString, nil
+	}
+
+	if p.backup != nil && (len(p.poller.value()) == 0 || p.usingBackup.Load()) {
+		if connectString := p.backup.ConnectionString(); len(connectString) > 0 {
+			log.Printf("fail to poll %s, use backup `%s`, %s", p.url, connectString, err)
+
+			p.notifyError(err)
+			p.usingBackup.Set(true)
+
+			return connectString, nil
+		}
+	}
+
+	return "", err
+}
+
+func (p *HTTPEnsembleProvider) fetch() (string, error) {
+	res, err := p.client.Get(p.url)
+
+	if err != nil {
+		return "", err
+	}
+
+	defer res.Body.Close()
+
+	if res.StatusCode != http.StatusOK {
+		return "", fmt.Errorf("unexpected status `%s` from %s", res.Status, p.url)
+	}
+
+	var ensemble ensembleServers
+
+	if err := json.NewDecoder(res.Body).Decode(&ensemble); err != nil {
+		return "", fmt.Errorf("fail to decode response from %s, %s", p.url, err)
+	}
+
+	var servers []string
+
+	for _, server := range ensemble.Servers {
+		if server = strings.TrimSpace(server); len(server) == 0 {
+			continue
+		}
+
+		if _, _, err := net.SplitHostPort(server); err != nil {
+			if ensemble.Port <= 0 {
+				return "", fmt.Errorf("missing port of server `%s` from %s", server, p.url)
+			}
+
+			server = net.JoinHostPort(server, strconv.Itoa(ensemble.Port))
+		}
+
+		servers = append(servers, server)
+	}
+
+	sort.Strings(servers)
+
+	return strings.Join(servers, ","), nil
+}
+
+func (p *HTTPEnsembleProvider) notifyError(err error) {
+	p.errorListeners.ForEach(func(listener interface{}) {
+		listener.(UnhandledErrorListener).UnhandledError(err)
+	})
+}
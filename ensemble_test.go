@@ -1,9 +1,19 @@
 package curator
 
 import (
+	"errors"
+	"io/ioutil"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"sync"
 	"testing"
+	"time"
 
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
 )
 
 func TestFixedEnsembleProvider(t *testing.T) {
@@ -17,3 +27,222 @@ func TestFixedEnsembleProvider(t *testing.T) {
 
 	assert.NoError(t, p.Close())
 }
+
+func TestFileEnsembleProvider(t *testing.T) {
+	dir, err := ioutil.TempDir("", "ensemble")
+
+	assert.NoError(t, err)
+
+	defer os.RemoveAll(dir)
+
+	filename := filepath.Join(dir, "zookeeper")
+
+	p := NewFileEnsembleProvider(filename, 10*time.Millisecond)
+
+	assert.Error(t, p.Start(), "missing file should fail to start")
+
+	assert.NoError(t, ioutil.WriteFile(filename, []byte("# ensemble\nhost1:2181, host2:2181\nhost3:2181\n"), 0644))
+
+	p = NewFileEnsembleProvider(filename, 10*time.Millisecond)
+
+	assert.NoError(t, p.Start())
+	assert.Equal(t, "host1:2181,host2:2181,host3:2181", p.ConnectionString())
+
+	// the file is reloaded when changed
+	assert.NoError(t, ioutil.WriteFile(filename, []byte("host4:2181,host5:2181/chroot"), 0644))
+	assert.NoError(t, os.Chtimes(filename, time.Now(), time.Now().Add(time.Minute)))
+
+	assert.Eventually(t, func() bool { return p.ConnectionString() == "host4:2181,host5:2181/chroot" }, time.Second, 10*time.Millisecond)
+
+	// the last good value is kept when the file is broken
+	assert.NoError(t, ioutil.WriteFile(filename, []byte("\n# empty\n"), 0644))
+	assert.NoError(t, os.Chtimes(filename, time.Now(), time.Now().Add(2*time.Minute)))
+
+	time.Sleep(50 * time.Millisecond)
+
+	assert.Equal(t, "host4:2181,host5:2181/chroot", p.ConnectionString())
+
+	assert.NoError(t, os.Remove(filename))
+
+	time.Sleep(50 * time.Millisecond)
+
+	assert.Equal(t, "host4:2181,host5:2181/chroot", p.ConnectionString())
+
+	assert.NoError(t, p.Close())
+	assert.NoError(t, p.Close())
+}
+
+type mockResolver struct {
+	mock.Mock
+}
+
+func (r *mockResolver) LookupSRV(service, proto, name string) (string, []*net.SRV, error) {
+	args := r.Called(service, proto, name)
+
+	addrs, _ := args.Get(1).([]*net.SRV)
+
+	return args.String(0), addrs, args.Error(2)
+}
+
+func (r *mockResolver) LookupHost(host string) ([]string, error) {
+	args := r.Called(host)
+
+	addrs, _ := args.Get(0).([]string)
+
+	return addrs, args.Error(1)
+}
+
+func TestSRVEnsembleProvider(t *testing.T) {
+	resolver := &mockResolver{}
+
+	p := NewSRVEnsembleProvider("zookeeper", "tcp", "example.com", 10*time.Millisecond).WithResolver(resolver)
+
+	resolver.On("LookupSRV", "zookeeper", "tcp", "example.com").Return("", []*net.SRV{
+		{Target: "zk2.example.com.", Port: 2181},
+		{Target: "zk1.example.com.", Port: 2181},
+	}, nil).Once()
+	resolver.On("LookupSRV", "zookeeper", "tcp", "example.com").Return("", nil, errors.New("timeout")).Once()
+	resolver.On("LookupSRV", "zookeeper", "tcp", "example.com").Return("", []*net.SRV{
+		{Target: "zk3.example.com.", Port: 2182},
+	}, nil)
+
+	assert.NoError(t, p.Start())
+	assert.Equal(t, "zk1.example.com:2181,zk2.example.com:2181", p.ConnectionString())
+
+	assert.Eventually(t, func() bool { return p.ConnectionString() == "zk3.example.com:2182" }, time.Second, 10*time.Millisecond)
+
+	assert.NoError(t, p.Close())
+
+	resolver.AssertExpectations(t)
+}
+
+func TestHostnameEnsembleProvider(t *testing.T) {
+	resolver := &mockResolver{}
+
+	p := NewHostnameEnsembleProvider("zk.example.com", 2181, time.Hour).WithResolver(resolver)
+
+	resolver.On("LookupHost", "zk.example.com").Return(nil, errors.New("no such host")).Once()
+
+	assert.EqualError(t, p.Start(), "no such host")
+
+	p = NewHostnameEnsembleProvider("zk.example.com", 2181, time.Hour).WithResolver(resolver)
+
+	resolver.On("LookupHost", "zk.example.com").Return([]string{"10.0.0.2", "10.0.0.1", "::1"}, nil).Once()
+
+	assert.NoError(t, p.Start())
+	assert.Equal(t, "10.0.0.1:2181,10.0.0.2:2181,[::1]:2181", p.ConnectionString())
+	assert.EqualError(t, p.Start(), "Cannot be started more than once")
+	assert.NoError(t, p.Close())
+
+	resolver.AssertExpectations(t)
+}
+
+func TestHTTPEnsembleProvider(t *testing.T) {
+	var lock sync.Mutex
+
+	status, body := http.StatusOK, `{"servers": ["zk2.example.com", "zk1.example.com"], "port": 2181}`
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		lock.Lock()
+		defer lock.Unlock()
+
+		w.WriteHeader(status)
+		w.Write([]byte(body))
+	}))
+
+	defer server.Close()
+
+	respond := func(code int, content string) {
+		lock.Lock()
+		defer lock.Unlock()
+
+		status, body = code, content
+	}
+
+	errs := make(chan error, 10)
+
+	p := NewHTTPEnsembleProvider(server.URL, 10*time.Millisecond, nil)
+
+	p.UnhandledErrorListenable().AddListener(NewUnhandledErrorListener(func(err error) {
+		select {
+		case errs <- err:
+		default:
+		}
+	}))
+
+	assert.NoError(t, p.Start())
+	assert.Equal(t, "zk1.example.com:2181,zk2.example.com:2181", p.ConnectionString())
+	assert.False(t, p.UsingBackup())
+
+	// the last good value is kept when polling fails
+	respond(http.StatusServiceUnavailable, "")
+
+	select {
+	case err := <-errs:
+		assert.Contains(t, err.Error(), "503")
+	case <-time.After(time.Second):
+		assert.Fail(t, "polling error not reported")
+	}
+
+	assert.Equal(t, "zk1.example.com:2181,zk2.example.com:2181", p.ConnectionString())
+
+	respond(http.StatusOK, `{"servers": ["zk3.example.com:2182"]}`)
+
+	assert.Eventually(t, func() bool { return p.ConnectionString() == "zk3.example.com:2182" }, time.Second, 10*time.Millisecond)
+
+	assert.NoError(t, p.Close())
+}
+
+func TestHTTPEnsembleProviderBackup(t *testing.T) {
+	var broken AtomicBool
+
+	broken.Set(true)
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if broken.Load() {
+			w.Write([]byte("not json"))
+		} else {
+			w.Write([]byte(`{"servers": ["zk1.example.com"], "port": 2181}`))
+		}
+	}))
+
+	defer server.Close()
+
+	p := NewHTTPEnsembleProvider(server.URL, time.Hour, nil)
+
+	assert.Error(t, p.Start(), "no backup provider")
+
+	// the backup is closed if the provider fails to start
+	backup := &mockEnsembleProvider{log: t.Logf}
+
+	backup.On("Start").Return(nil).Once()
+	backup.On("ConnectionString").Return("").Once()
+	backup.On("Close").Return(nil).Once()
+
+	p = NewHTTPEnsembleProvider(server.URL, time.Hour, backup)
+
+	assert.Error(t, p.Start(), "empty backup provider")
+
+	backup.AssertExpectations(t)
+
+	p = NewHTTPEnsembleProvider(server.URL, 10*time.Millisecond, NewFixedEnsembleProvider("backup:2181"))
+
+	assert.NoError(t, p.Start())
+	assert.Equal(t, "backup:2181", p.ConnectionString())
+	assert.True(t, p.UsingBackup())
+
+	broken.Set(false)
+
+	assert.Eventually(t, func() bool { return p.ConnectionString() == "zk1.example.com:2181" }, time.Second, 10*time.Millisecond)
+	assert.False(t, p.UsingBackup())
+
+	// once polled successfully, the last good value is preferred to the backup
+	broken.Set(true)
+
+	time.Sleep(50 * time.Millisecond)
+
+	assert.Equal(t, "zk1.example.com:2181", p.ConnectionString())
+	assert.False(t, p.UsingBackup())
+
+	assert.NoError(t, p.Close())
+}
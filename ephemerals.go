@@ -0,0 +1,263 @@
+package curator
+
+import (
+	"sort"
+	"strings"
+
+	"github.com/yxdrlitao/go-zookeeper/zk"
+)
+
+// Emulate GetEphemerals by walking the nodes under the prefix, the system nodes are skipped
+func emulateGetEphemerals(conn ZookeeperConnection, sessionID int64, prefix string) ([]string, error) {
+	root := PATH_SEPARATOR
+
+	if i := strings.LastIndex(prefix, PATH_SEPARATOR); i > 0 {
+		root = prefix[:i]
+	}
+
+	var ephemerals []string
+
+	var walk func(path string) error
+
+	walk = func(path string) error {
+		children, _, err := conn.Children(path)
+
+		if err == zk.ErrNoNode {
+			return nil // deleted during the walk
+		} else if err != nil {
+			return err
+		}
+
+		for _, child := range children {
+			childPath := JoinPath(path, child)
+
+			if childPath == ZOOKEEPER_SYSTEM_NODE || !strings.HasPrefix(childPath, prefix) {
+				continue
+			}
+
+			_, stat, err := conn.Exists(childPath)
+
+			if err != nil && err != zk.ErrNoNode {
+				return err
+			} else if stat == nil {
+				continue
+			}
+
+			if stat.EphemeralOwner == sessionID {
+				ephemerals = append(ephemerals, childPath)
+			} else if stat.NumChildren > 0 {
+				if err := walk(childPath); err != nil {
+					return err
+				}
+			}
+		}
+
+		return nil
+	}
+
+	if err := walk(root); err != nil {
+		return nil, err
+	}
+
+	sort.Strings(ephemerals)
+
+	return ephemerals, nil
+}
+
+// Emulate GetAllChildrenNumber by counting the descendants one level after another
+func emulateGetAllChildrenNumber(conn ZookeeperConnection, path string) (int32, error) {
+	children, _, err := conn.Children(path)
+
+	if err != nil {
+		return 0, err
+	}
+
+	number := int32(len(children))
+
+	for _, child := range children {
+		if n, err := emulateGetAllChildrenNumber(conn, JoinPath(path, child)); err == nil {
+			number += n
+		} else if err != zk.ErrNoNode {
+			return 0, err
+		}
+	}
+
+	return number, nil
+}
+
+type getEphemeralsBuilder struct {
+	client        *curatorFramework
+	backgrounding backgrounding
+}
+
+func (b *getEphemeralsBuilder) ForPrefix(givenPrefix string) ([]string, error) {
+	if len(givenPrefix) == 0 {
+		givenPrefix = PATH_SEPARATOR
+	}
+
+	adjustedPrefix, err := b.client.fixForNamespace(givenPrefix, false)
+
+	if err != nil {
+		return nil, err
+	}
+
+	if b.backgrounding.inBackground {
+		b.client.client.inBackground(func() { b.pathInBackground(adjustedPrefix, givenPrefix) })
+
+		return nil, nil
+	}
+
+	return b.pathInForeground(adjustedPrefix)
+}
+
+func (b *getEphemeralsBuilder) pathInBackground(adjustedPrefix, givenPrefix string) {
+	tracer := b.client.ZookeeperClient().StartTracer("getEphemeralsBuilder.pathInBackground")
+
+	defer tracer.Commit()
+
+	paths, err := b.pathInForeground(adjustedPrefix)
+
+	if b.backgrounding.callback != nil {
+		b.backgrounding.callback(b.client, &curatorEvent{
+			eventType: GET_EPHEMERALS,
+			err:       err,
+			path:      givenPrefix,
+			children:  paths,
+			context:   b.backgrounding.context,
+			stale:     b.client.ReadOnly(),
+		})
+	}
+}
+
+func (b *getEphemeralsBuilder) pathInForeground(prefix string) ([]string, error) {
+	zkClient := b.client.ZookeeperClient()
+
+	result, err := zkClient.NewRetryLoop().CallWithRetry(func() (interface{}, error) {
+		if conn, err := zkClient.Conn(); err != nil {
+			return nil, err
+		} else {
+			return conn.GetEphemerals(prefix)
+		}
+	})
+
+	if err != nil {
+		return nil, err
+	}
+
+	ephemerals, _ := result.([]string)
+
+	paths := make([]string, 0, len(ephemerals))
+
+	for _, path := range ephemerals {
+		if b.client.namespace.contains(path) {
+			paths = append(paths, b.client.unfixForNamespace(path))
+		}
+	}
+
+	return paths, nil
+}
+
+func (b *getEphemeralsBuilder) InBackground() GetEphemeralsBuilder {
+	b.backgrounding = backgrounding{inBackground: true}
+
+	return b
+}
+
+func (b *getEphemeralsBuilder) InBackgroundWithContext(context interface{}) GetEphemeralsBuilder {
+	b.backgrounding = backgrounding{inBackground: true, context: context}
+
+	return b
+}
+
+func (b *getEphemeralsBuilder) InBackgroundWithCallback(callback BackgroundCallback) GetEphemeralsBuilder {
+	b.backgrounding = backgrounding{inBackground: true, callback: callback}
+
+	return b
+}
+
+func (b *getEphemeralsBuilder) InBackgroundWithCallbackAndContext(callback BackgroundCallback, context interface{}) GetEphemeralsBuilder {
+	b.backgrounding = backgrounding{inBackground: true, context: context, callback: callback}
+
+	return b
+}
+
+type getAllChildrenNumberBuilder struct {
+	client        *curatorFramework
+	backgrounding backgrounding
+}
+
+func (b *getAllChildrenNumberBuilder) ForPath(givenPath string) (int32, error) {
+	adjustedPath, err := b.client.fixForNamespace(givenPath, false)
+
+	if err != nil {
+		return 0, err
+	}
+
+	if b.backgrounding.inBackground {
+		b.client.client.inBackground(func() { b.pathInBackground(adjustedPath, givenPath) })
+
+		return 0, nil
+	}
+
+	return b.pathInForeground(adjustedPath)
+}
+
+func (b *getAllChildrenNumberBuilder) pathInBackground(adjustedPath, givenPath string) {
+	tracer := b.client.ZookeeperClient().StartTracer("getAllChildrenNumberBuilder.pathInBackground")
+
+	defer tracer.Commit()
+
+	number, err := b.pathInForeground(adjustedPath)
+
+	if b.backgrounding.callback != nil {
+		b.backgrounding.callback(b.client, &curatorEvent{
+			eventType:      GET_ALL_CHILDREN_NUMBER,
+			err:            err,
+			path:           givenPath,
+			name:           GetNodeFromPath(givenPath),
+			childrenNumber: number,
+			context:        b.backgrounding.context,
+			stale:          b.client.ReadOnly(),
+		})
+	}
+}
+
+func (b *getAllChildrenNumberBuilder) pathInForeground(path string) (int32, error) {
+	zkClient := b.client.ZookeeperClient()
+
+	result, err := zkClient.NewRetryLoop().CallWithRetry(func() (interface{}, error) {
+		if conn, err := zkClient.Conn(); err != nil {
+			return nil, err
+		} else {
+			return conn.GetAllChildrenNumber(path)
+		}
+	})
+
+	number, _ := result.(int32)
+
+	return number, err
+}
+
+func (b *getAllChildrenNumberBuilder) InBackground() GetAllChildrenNumberBuilder {
+	b.backgrounding = backgrounding{inBackground: true}
+
+	return b
+}
+
+func (b *getAllChildrenNumberBuilder) InBackgroundWithContext(context interface{}) GetAllChildrenNumberBuilder {
+	b.backgrounding = backgrounding{inBackground: true, context: context}
+
+	return b
+}
+
+func (b *getAllChildrenNumberBuilder) InBackgroundWithCallback(callback BackgroundCallback) GetAllChildrenNumberBuilder {
+	b.backgrounding = backgrounding{inBackground: true, callback: callback}
+
+	return b
+}
+
+func (b *getAllChildrenNumberBuilder) InBackgroundWithCallbackAndContext(callback BackgroundCallback, context interface{}) GetAllChildrenNumberBuilder {
+	b.backgrounding = backgrounding{inBackground: true, context: context, callback: callback}
+
+	return b
+}
@@ -0,0 +1,111 @@
+package curator
+
+import (
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/suite"
+	"github.com/yxdrlitao/go-zookeeper/zk"
+)
+
+func TestEmulateGetEphemerals(t *testing.T) {
+	conn := &mockConn{log: t.Logf}
+
+	conn.On("Children", "/").Return([]string{"zookeeper", "app", "apple", "other"}, nil, nil).Once()
+	conn.On("Exists", "/app").Return(true, &zk.Stat{NumChildren: 3}, nil).Once()
+	conn.On("Exists", "/apple").Return(true, &zk.Stat{EphemeralOwner: 42}, nil).Once()
+	conn.On("Children", "/app").Return([]string{"lock", "node", "gone"}, nil, nil).Once()
+	conn.On("Exists", "/app/lock").Return(true, &zk.Stat{EphemeralOwner: 42}, nil).Once()
+	conn.On("Exists", "/app/node").Return(true, &zk.Stat{EphemeralOwner: 7}, nil).Once()
+	conn.On("Exists", "/app/gone").Return(false, nil, nil).Once()
+
+	ephemerals, err := emulateGetEphemerals(conn, 42, "/app")
+
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"/app/lock", "/apple"}, ephemerals)
+
+	conn.On("Children", "/app").Return([]string{"lock", "node"}, nil, nil).Once()
+	conn.On("Exists", "/app/lock").Return(true, &zk.Stat{EphemeralOwner: 42}, nil).Once()
+
+	ephemerals, err = emulateGetEphemerals(conn, 42, "/app/lo")
+
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"/app/lock"}, ephemerals)
+
+	conn.AssertExpectations(t)
+}
+
+func TestEmulateGetAllChildrenNumber(t *testing.T) {
+	conn := &mockConn{log: t.Logf}
+
+	conn.On("Children", "/node").Return([]string{"a", "b"}, nil, nil).Once()
+	conn.On("Children", "/node/a").Return([]string{"c"}, nil, nil).Once()
+	conn.On("Children", "/node/a/c").Return([]string{}, nil, nil).Once()
+	conn.On("Children", "/node/b").Return(nil, nil, zk.ErrNoNode).Once()
+
+	number, err := emulateGetAllChildrenNumber(conn, "/node")
+
+	assert.NoError(t, err)
+	assert.Equal(t, int32(3), number)
+
+	conn.On("Children", "/missing").Return(nil, nil, zk.ErrNoNode).Once()
+
+	_, err = emulateGetAllChildrenNumber(conn, "/missing")
+
+	assert.Equal(t, zk.ErrNoNode, err)
+
+	conn.AssertExpectations(t)
+}
+
+type GetEphemeralsBuilderTestSuite struct {
+	mockContainerTestSuite
+}
+
+func TestGetEphemeralsBuilder(t *testing.T) {
+	suite.Run(t, new(GetEphemeralsBuilderTestSuite))
+}
+
+func (s *GetEphemeralsBuilderTestSuite) TestNamespace() {
+	s.WithNamespace("parent", func(client CuratorFramework, conn *mockConn) {
+		conn.On("Exists", "/parent").Return(true, nil, nil).Once()
+		conn.On("GetEphemerals", "/parent").Return([]string{"/parent/lock", "/parentx/lock"}, nil).Once()
+
+		ephemerals, err := client.GetEphemerals().ForPrefix("")
+
+		s.NoError(err)
+		s.Equal([]string{"/lock"}, ephemerals)
+	})
+}
+
+func (s *GetEphemeralsBuilderTestSuite) TestGetAllChildrenNumber() {
+	s.WithNamespace("parent", func(client CuratorFramework, conn *mockConn) {
+		conn.On("Exists", "/parent").Return(true, nil, nil).Once()
+		conn.On("GetAllChildrenNumber", "/parent/node").Return(int32(5), nil).Once()
+
+		number, err := client.GetAllChildrenNumber().ForPath("/node")
+
+		s.NoError(err)
+		s.Equal(int32(5), number)
+	})
+}
+
+func (s *GetEphemeralsBuilderTestSuite) TestBackground() {
+	s.With(func(client CuratorFramework, conn *mockConn, wg *sync.WaitGroup) {
+		conn.On("GetAllChildrenNumber", "/node").Return(int32(5), nil).Once()
+
+		_, err := client.GetAllChildrenNumber().InBackgroundWithCallback(
+			func(client CuratorFramework, event CuratorEvent) error {
+				defer wg.Done()
+
+				s.Equal(GET_ALL_CHILDREN_NUMBER, event.Type())
+				s.Equal("/node", event.Path())
+				s.Equal(int32(5), event.ChildrenNumber())
+				s.NoError(event.Err())
+
+				return nil
+			}).ForPath("/node")
+
+		s.NoError(err)
+	})
+}
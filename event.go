@@ -9,20 +9,25 @@ import (
 type CuratorEventType int
 
 const (
-	CREATE   CuratorEventType = iota // CuratorFramework.Create() -> Err(), Path(), Data()
-	DELETE                           // CuratorFramework.Delete() -> Err(), Path()
-	EXISTS                           // CuratorFramework.CheckExists() -> Err(), Path(), Stat()
-	GET_DATA                         // CuratorFramework.GetData() -> Err(), Path(), Stat(), Data()
-	SET_DATA                         // CuratorFramework.SetData() -> Err(), Path(), Stat()
-	CHILDREN                         // CuratorFramework.GetChildren() -> Err(), Path(), Stat(), Children()
-	SYNC                             // CuratorFramework.Sync() -> Err(), Path()
-	GET_ACL                          // CuratorFramework.GetACL() -> Err(), Path()
-	SET_ACL                          // CuratorFramework.SetACL() -> Err(), Path()
-	WATCHED                          // Watchable.UsingWatcher() -> WatchedEvent()
-	CLOSING                          // Event sent when client is being closed
+	CREATE                  CuratorEventType = iota // CuratorFramework.Create() -> Err(), Path(), Data()
+	DELETE                                          // CuratorFramework.Delete() -> Err(), Path()
+	EXISTS                                          // CuratorFramework.CheckExists() -> Err(), Path(), Stat()
+	GET_DATA                                        // CuratorFramework.GetData() -> Err(), Path(), Stat(), Data()
+	SET_DATA                                        // CuratorFramework.SetData() -> Err(), Path(), Stat()
+	CHILDREN                                        // CuratorFramework.GetChildren() -> Err(), Path(), Stat(), Children()
+	SYNC                                            // CuratorFramework.Sync() -> Err(), Path()
+	GET_ACL                                         // CuratorFramework.GetACL() -> Err(), Path()
+	SET_ACL                                         // CuratorFramework.SetACL() -> Err(), Path()
+	WATCHED                                         // Watchable.UsingWatcher() -> WatchedEvent()
+	CLOSING                                         // Event sent when client is being closed
+	GET_CONFIG                                      // CuratorFramework.GetConfig() -> Err(), Path(), Stat(), Data()
+	RECONFIG                                        // CuratorFramework.Reconfig() -> Err(), Path(), Stat()
+	MULTI_READ                                      // CuratorFramework.MultiRead() -> Err(), MultiReadResults()
+	GET_EPHEMERALS                                  // CuratorFramework.GetEphemerals() -> Err(), Path(), Children()
+	GET_ALL_CHILDREN_NUMBER                         // CuratorFramework.GetAllChildrenNumber() -> Err(), Path(), ChildrenNumber()
 )
 
-var CuratorEventTypeNames = []string{"CREATE", "DELETE", "EXISTS", "GET_DATA", "SET_DATA", "CHILDREN", "SYNC", "GET_ACL", "SET_ACL", "WATCHED", "CLOSING"}
+var CuratorEventTypeNames = []string{"CREATE", "DELETE", "EXISTS", "GET_DATA", "SET_DATA", "CHILDREN", "SYNC", "GET_ACL", "SET_ACL", "WATCHED", "CLOSING", "GET_CONFIG", "RECONFIG", "MULTI_READ", "GET_EPHEMERALS", "GET_ALL_CHILDREN_NUMBER"}
 
 func (t CuratorEventType) String() string {
 	if int(t) < len(CuratorEventTypeNames) {
@@ -62,6 +67,15 @@ type CuratorEvent interface {
 	ACLs() []zk.ACL
 
 	WatchedEvent() *zk.Event
+
+	// the result of the read may be stale as the connection was read-only
+	Stale() bool
+
+	// the results of the operations of a multi read
+	MultiReadResults() []*MultiReadResult
+
+	// the number of all the descendants
+	ChildrenNumber() int32
 }
 
 type curatorEvent struct {
@@ -75,6 +89,10 @@ type curatorEvent struct {
 	data         []byte
 	watchedEvent *zk.Event
 	acls         []zk.ACL
+	stale        bool
+
+	multiReadResults []*MultiReadResult
+	childrenNumber   int32
 }
 
 func (e *curatorEvent) Type() CuratorEventType { return e.eventType }
@@ -96,3 +114,9 @@ func (e *curatorEvent) Children() []string { return e.children }
 func (e *curatorEvent) ACLs() []zk.ACL { return e.acls }
 
 func (e *curatorEvent) WatchedEvent() *zk.Event { return e.watchedEvent }
+
+func (e *curatorEvent) Stale() bool { return e.stale }
+
+func (e *curatorEvent) MultiReadResults() []*MultiReadResult { return e.multiReadResults }
+
+func (e *curatorEvent) ChildrenNumber() int32 { return e.childrenNumber }
@@ -6,13 +6,18 @@ type checkExistsBuilder struct {
 	client        *curatorFramework
 	backgrounding backgrounding
 	watching      watching
+	stale         *bool
 }
 
 func (b *checkExistsBuilder) ForPath(givenPath string) (*zk.Stat, error) {
-	adjustedPath := b.client.fixForNamespace(givenPath, false)
+	adjustedPath, err := b.client.fixForNamespace(givenPath, false)
+
+	if err != nil {
+		return nil, err
+	}
 
 	if b.backgrounding.inBackground {
-		go b.pathInBackground(adjustedPath)
+		b.client.client.inBackground(func() { b.pathInBackground(adjustedPath) })
 
 		return nil, nil
 	} else {
@@ -35,6 +40,7 @@ func (b *checkExistsBuilder) pathInBackground(path string) {
 			stat:      stat,
 			name:      GetNodeFromPath(path),
 			context:   b.backgrounding.context,
+			stale:     b.client.ReadOnly(),
 		}
 
 		b.backgrounding.callback(b.client, event)
@@ -54,10 +60,14 @@ func (b *checkExistsBuilder) pathInForeground(path string) (*zk.Stat, error) {
 			var err error
 
 			if b.watching.watched || b.watching.watcher != nil {
+				if err := b.client.schemaSet.ValidateWatch(path); err != nil {
+					return nil, err
+				}
+
 				exists, stat, events, err = conn.ExistsW(path)
 
 				if events != nil && b.watching.watcher != nil {
-					go NewWatchers(b.watching.watcher).Watch(events)
+					b.client.client.watch(b.watching.watcher, events)
 				}
 			} else {
 				exists, stat, err = conn.Exists(path)
@@ -75,9 +85,20 @@ func (b *checkExistsBuilder) pathInForeground(path string) (*zk.Stat, error) {
 
 	stat, _ := result.(*zk.Stat)
 
+	b.client.client.recordStat(stat)
+
+	if b.stale != nil {
+		*b.stale = b.client.ReadOnly()
+	}
+
 	return stat, err
 }
 
+func (b *checkExistsBuilder) StoringStaleIn(stale *bool) CheckExistsBuilder {
+	b.stale = stale
+	return b
+}
+
 func (b *checkExistsBuilder) Watched() CheckExistsBuilder {
 	b.watching.watched = true
 	return b
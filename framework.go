@@ -1,6 +1,7 @@
 package curator
 
 import (
+	"context"
 	"fmt"
 	"log"
 	"time"
@@ -20,7 +21,7 @@ type CuratorFramework interface {
 	// Most mutator methods will not work until the client is started
 	Start() error
 
-	// Stop the client
+	// Stop the client, or detach the listeners of a namespace facade
 	Close() error
 
 	// Returns the state of this instance
@@ -47,6 +48,18 @@ type CuratorFramework interface {
 	// Start a get children builder
 	GetChildren() GetChildrenBuilder
 
+	// Start a builder reading the data of several nodes concurrently
+	BatchGet() BatchGetBuilder
+
+	// Start a multi read builder, which reads several nodes as a consistent snapshot
+	MultiRead() MultiReadBuilder
+
+	// Start a builder finding the ephemeral nodes created by this session
+	GetEphemerals() GetEphemeralsBuilder
+
+	// Start a builder counting all the descendants of a node
+	GetAllChildrenNumber() GetAllChildrenNumberBuilder
+
 	// Start a get ACL builder
 	GetACL() GetACLBuilder
 
@@ -62,9 +75,19 @@ type CuratorFramework interface {
 	//  Start a sync builder. Note: sync is ALWAYS in the background even if you don't use one of the background() methods
 	Sync() SyncBuilder
 
+	// Start a get config builder, which reads the ensemble configuration from /zookeeper/config
+	GetConfig() GetConfigBuilder
+
+	// Start a reconfig builder, which changes the members of the ensemble
+	Reconfig() ReconfigBuilder
+
 	// Returns the listenable interface for the Connect State
 	ConnectionStateListenable() ConnectionStateListenable
 
+	// Subscribe the connection states, the channel receives the current state first and then every change,
+	// until the context is done or the client is closed
+	SubscribeConnectionState(ctx context.Context) <-chan ConnectionState
+
 	// Returns the listenable interface for events
 	CuratorListenable() CuratorListenable
 
@@ -76,11 +99,16 @@ type CuratorFramework interface {
 
 	// Returns a facade of the current instance that uses the specified namespace
 	// or no namespace if newNamespace is empty.
+	// The namespace is nested in the current one if it's called on a namespace facade,
+	// and the listeners of the facade only receive the events within its namespace.
 	UsingNamespace(newNamespace string) CuratorFramework
 
 	// Return the current namespace or "" if none
 	Namespace() string
 
+	// Return the codec of Encoded() and Into()
+	Codec() Codec
+
 	// Return the managed zookeeper client
 	ZookeeperClient() CuratorZookeeperClient
 
@@ -92,6 +120,9 @@ type CuratorFramework interface {
 
 	// Block until a connection to ZooKeeper is available or the maxWaitTime has been exceeded
 	BlockUntilConnectedTimeout(maxWaitTime time.Duration) error
+
+	// Return true if the connection is read-only, the reads may be stale and the writes fail with ErrReadOnly
+	ReadOnly() bool
 }
 
 // Create a new client with default session timeout and default connection timeout
@@ -121,8 +152,17 @@ type CuratorFrameworkBuilder struct {
 	MaxCloseWait        time.Duration       // the time to wait during close to wait background tasks
 	RetryPolicy         RetryPolicy         // the retry policy to use
 	CompressionProvider CompressionProvider // the compression provider
+	CompressionEnabled  bool                // compress the data of all the operations and caches unless skipped
+	EncryptionProvider  EncryptionProvider  // encrypt the data of all the operations and caches if set
+	ChunkSize           int                 // split the data larger than it into chunk nodes, 0 to disable
+	SchemaSet           *SchemaSet          // the schemas enforced by the operations if set
+	DataCodec           Codec               // the codec of Encoded() and Into(), JSON by default
 	AclProvider         ACLProvider         // the provider for ACLs
 	CanBeReadOnly       bool                // allow ZooKeeper client to enter read only mode in case of a network partition.
+	ReadWriteWaitTime   time.Duration       // the time the writes wait for a read-write connection, 0 to fail fast with ErrReadOnly
+	ReadWriteProbe      time.Duration       // the interval to probe a read-write server while read-only, 0 to disable
+	CredentialsProvider CredentialsProvider // the credentials added to every new connection, besides AuthInfos
+	Admission           *AdmissionPolicy    // the rate limits and circuit breaker of the requests if set
 }
 
 // Apply the current values and build a new CuratorFramework
@@ -148,6 +188,9 @@ func (b *CuratorFrameworkBuilder) Build() CuratorFramework {
 	if builder.AclProvider == nil {
 		builder.AclProvider = NewDefaultACLProvider()
 	}
+	if builder.DataCodec == nil {
+		builder.DataCodec = NewJSONCodec()
+	}
 
 	return newCuratorFramework(&builder)
 }
@@ -164,6 +207,24 @@ func (b *CuratorFrameworkBuilder) Authorization(scheme string, auth []byte) *Cur
 	return b
 }
 
+// Add digest authorization with the user and password
+func (b *CuratorFrameworkBuilder) DigestAuthorization(user, password string) *CuratorFrameworkBuilder {
+	b.AuthInfos = append(b.AuthInfos, NewDigestAuthInfo(user, password))
+	return b
+}
+
+// Set the provider of the credentials added to every new connection
+func (b *CuratorFrameworkBuilder) Credentials(provider CredentialsProvider) *CuratorFrameworkBuilder {
+	b.CredentialsProvider = provider
+	return b
+}
+
+// Connect to the servers with TLS
+func (b *CuratorFrameworkBuilder) TLS(dialer *TLSDialer) *CuratorFrameworkBuilder {
+	b.ZookeeperDialer = &DefaultZookeeperDialer{Dialer: dialer.Dial}
+	return b
+}
+
 // Add compression provider
 func (b *CuratorFrameworkBuilder) Compression(name string) *CuratorFrameworkBuilder {
 	if provider, exists := CompressionProviders[name]; exists {
@@ -172,7 +233,89 @@ func (b *CuratorFrameworkBuilder) Compression(name string) *CuratorFrameworkBuil
 	return b
 }
 
+// Compress the data of create, setData and transactions, and decompress the data of getData and caches,
+// as if Compressed() or Decompressed() is called on every builder, unless SkipCompression() or SkipDecompression().
+//
+// If the compression provider implements CompressionPolicy, only the paths accepted by the policy are compressed.
+func (b *CuratorFrameworkBuilder) EnableCompression() *CuratorFrameworkBuilder {
+	b.CompressionEnabled = true
+	return b
+}
+
+// Encrypt the data of create, setData and transactions, and decrypt the data of getData and caches
+func (b *CuratorFrameworkBuilder) Encryption(provider EncryptionProvider) *CuratorFrameworkBuilder {
+	b.EncryptionProvider = provider
+	return b
+}
+
+// Split the data larger than the chunk size (DEFAULT_CHUNK_SIZE if not positive) into chunk nodes on create and setData,
+// and reassemble them on getData, so the data larger than jute.maxbuffer could be stored.
+//
+// The chunk nodes are the hidden children of the node, the readers must enable chunking as well.
+// Only the manifest of the chunks is stored in the node, so the watches on the node signal the updates as usual.
+// The large data can't be written in a transaction or an ephemeral node.
+func (b *CuratorFrameworkBuilder) EnableChunking(chunkSize int) *CuratorFrameworkBuilder {
+	if chunkSize <= 0 {
+		chunkSize = DEFAULT_CHUNK_SIZE
+	}
+	b.ChunkSize = chunkSize
+	return b
+}
+
+// Allow the client to enter read-only mode, in which the writes fail fast with ErrReadOnly
+// unless WaitForReadWrite() is set, and probe the servers for a read-write one if probeInterval > 0.
+//
+// Reconnecting to the read-write server starts a new session, as the session of a read-only server can't be moved.
+func (b *CuratorFrameworkBuilder) EnableReadOnly(probeInterval time.Duration) *CuratorFrameworkBuilder {
+	b.CanBeReadOnly = true
+	b.ReadWriteProbe = probeInterval
+	return b
+}
+
+// Limit the rate of the requests of the method of ZookeeperConnection, e.g. "Get", or ALL_REQUESTS,
+// the requests exceeding the rate fail fast with ErrRateLimited.
+func (b *CuratorFrameworkBuilder) LimitRate(method string, rate float64, burst int) *CuratorFrameworkBuilder {
+	if b.Admission == nil {
+		b.Admission = &AdmissionPolicy{}
+	}
+	if b.Admission.RateLimits == nil {
+		b.Admission.RateLimits = make(map[string]RateLimit)
+	}
+	b.Admission.RateLimits[method] = RateLimit{Rate: rate, Burst: burst}
+	return b
+}
+
+// Fail the requests fast with ErrCircuitOpen after failureThreshold consecutive connection failures
+// or while the connection is SUSPENDED or LOST, a trial request is let through every resetTimeout.
+func (b *CuratorFrameworkBuilder) EnableCircuitBreaker(failureThreshold int, resetTimeout time.Duration) *CuratorFrameworkBuilder {
+	if b.Admission == nil {
+		b.Admission = &AdmissionPolicy{}
+	}
+	b.Admission.FailureThreshold = failureThreshold
+	b.Admission.ResetTimeout = resetTimeout
+	return b
+}
+
+// Have the writes wait for a read-write connection up to maxWaitTime before failing with ErrReadOnly
+func (b *CuratorFrameworkBuilder) WaitForReadWrite(maxWaitTime time.Duration) *CuratorFrameworkBuilder {
+	b.ReadWriteWaitTime = maxWaitTime
+	return b
+}
+
+// Enforce the schemas by create, setData, transactions and the operations with watches
+func (b *CuratorFrameworkBuilder) Schemas(schemaSet *SchemaSet) *CuratorFrameworkBuilder {
+	b.SchemaSet = schemaSet
+	return b
+}
+
+// Set the codec of Encoded() and Into()
+func (b *CuratorFrameworkBuilder) Codec(codec Codec) *CuratorFrameworkBuilder {
+	b.DataCodec = codec
+	return b
+}
+
 type curatorFramework struct {
+	root                    *curatorFramework // the client owning the connection if it's a namespace facade
 	client                  *curatorZookeeperClient
 	stateManager            *connectionStateManager
 	state                   State
@@ -181,11 +324,18 @@ type curatorFramework struct {
 	defaultData             []byte
 	namespace               *namespaceImpl
 	namespaceFacadeCache    *namespaceFacadeCache
-	fixForNamespace         func(path string, isSequential bool) string
+	fixForNamespace         func(path string, isSequential bool) (string, error)
 	unfixForNamespace       func(path string) string
 	retryPolicy             RetryPolicy
 	compressionProvider     CompressionProvider
+	compressionEnabled      bool
+	encryptionProvider      EncryptionProvider
+	chunkSize               int
+	schemaSet               *SchemaSet
+	codec                   Codec
 	aclProvider             ACLProvider
+	readWriteWaitTime       time.Duration
+	readWriteProbe          *readWriteProbe
 }
 
 func newCuratorFramework(b *CuratorFrameworkBuilder) *curatorFramework {
@@ -195,7 +345,13 @@ func newCuratorFramework(b *CuratorFrameworkBuilder) *curatorFramework {
 		defaultData:             b.DefaultData,
 		retryPolicy:             b.RetryPolicy,
 		compressionProvider:     b.CompressionProvider,
+		compressionEnabled:      b.CompressionEnabled,
+		encryptionProvider:      b.EncryptionProvider,
+		chunkSize:               b.ChunkSize,
+		schemaSet:               b.SchemaSet,
+		codec:                   b.DataCodec,
 		aclProvider:             b.AclProvider,
+		readWriteWaitTime:       b.ReadWriteWaitTime,
 	}
 
 	watcher := NewWatcher(func(event *zk.Event) {
@@ -208,8 +364,24 @@ func newCuratorFramework(b *CuratorFrameworkBuilder) *curatorFramework {
 	})
 
 	c.client = NewCuratorZookeeperClient(b.ZookeeperDialer, b.EnsembleProvider, b.SessionTimeout, b.ConnectionTimeout, watcher, b.RetryPolicy, b.CanBeReadOnly, b.AuthInfos)
+	c.client.credentialsProvider = b.CredentialsProvider
 	c.stateManager = newConnectionStateManager(c)
+	c.stateManager.tracer = c.client.TracerDriver
 	c.namespace = newNamespace(c, b.Namespace)
+
+	if b.CanBeReadOnly && b.ReadWriteProbe > 0 {
+		c.readWriteProbe = newReadWriteProbe(c, b.ReadWriteProbe)
+		c.stateManager.listeners.AddListener(c.readWriteProbe)
+	}
+
+	if b.Admission != nil {
+		c.client.admission = newAdmission(b.Admission, c.client.TracerDriver)
+
+		if c.client.admission.breaker != nil {
+			c.stateManager.listeners.AddListener(c.client.admission.breaker)
+		}
+	}
+
 	c.namespaceFacadeCache = newNamespaceFacadeCache(c)
 	c.fixForNamespace = c.namespace.fixForNamespace
 	c.unfixForNamespace = c.namespace.unfixForNamespace
@@ -226,6 +398,10 @@ func (c *curatorFramework) Start() error {
 		return fmt.Errorf("fail to start client, %s", err)
 	}
 
+	if tracker, ok := c.client.state.ensembleProvider.(ensembleTracker); ok {
+		tracker.track(c)
+	}
+
 	return nil
 }
 
@@ -240,10 +416,18 @@ func (c *curatorFramework) Close() error {
 		listener.(CuratorListener).EventReceived(c, evt)
 	})
 
+	for _, facade := range c.namespaceFacadeCache.facades() {
+		facade.Close()
+	}
+
 	c.listeners.Clear()
 	c.unhandledErrorListeners.Clear()
 	c.stateManager.Close()
 
+	if c.readWriteProbe != nil {
+		c.readWriteProbe.Close()
+	}
+
 	return c.client.Close()
 }
 
@@ -255,6 +439,59 @@ func (c *curatorFramework) Started() bool {
 	return c.State() == STARTED
 }
 
+// Decide whether the data of the path should be compressed or decompressed
+func (c *curatorFramework) shouldCompress(path string, explicit, skipped bool) bool {
+	if skipped {
+		return false
+	} else if explicit {
+		return true
+	} else if !c.compressionEnabled {
+		return false
+	} else if policy, ok := c.compressionProvider.(CompressionPolicy); ok {
+		return policy.ShouldCompress(path)
+	}
+
+	return true
+}
+
+// Compress and encrypt the data before written
+func (c *curatorFramework) encodeData(path string, payload []byte, compress bool) ([]byte, error) {
+	var err error
+
+	if compress {
+		if payload, err = c.compressionProvider.Compress(path, payload); err != nil {
+			return nil, err
+		}
+	}
+
+	if c.encryptionProvider != nil {
+		if payload, err = c.encryptionProvider.Encrypt(path, payload); err != nil {
+			return nil, err
+		}
+	}
+
+	return payload, nil
+}
+
+// Decrypt and decompress the data after read
+func (c *curatorFramework) decodeData(path string, data []byte, decrypt, decompress bool) ([]byte, error) {
+	var err error
+
+	if decrypt && c.encryptionProvider != nil {
+		if data, err = c.encryptionProvider.Decrypt(path, data); err != nil {
+			return nil, err
+		}
+	}
+
+	if decompress {
+		if data, err = c.compressionProvider.Decompress(path, data); err != nil {
+			return nil, err
+		}
+	}
+
+	return data, nil
+}
+
 func (c *curatorFramework) Create() CreateBuilder {
 	c.state.Check(STARTED, "instance must be started before calling Create")
 	return &createBuilder{client: c, acling: acling{aclProvider: c.aclProvider}}
@@ -285,6 +522,26 @@ func (c *curatorFramework) GetChildren() GetChildrenBuilder {
 	return &getChildrenBuilder{client: c}
 }
 
+func (c *curatorFramework) BatchGet() BatchGetBuilder {
+	c.state.Check(STARTED, "instance must be started before calling BatchGet")
+	return &batchGetBuilder{client: c}
+}
+
+func (c *curatorFramework) MultiRead() MultiReadBuilder {
+	c.state.Check(STARTED, "instance must be started before calling MultiRead")
+	return &multiReadBuilder{client: c}
+}
+
+func (c *curatorFramework) GetEphemerals() GetEphemeralsBuilder {
+	c.state.Check(STARTED, "instance must be started before calling GetEphemerals")
+	return &getEphemeralsBuilder{client: c}
+}
+
+func (c *curatorFramework) GetAllChildrenNumber() GetAllChildrenNumberBuilder {
+	c.state.Check(STARTED, "instance must be started before calling GetAllChildrenNumber")
+	return &getAllChildrenNumberBuilder{client: c}
+}
+
 func (c *curatorFramework) GetACL() GetACLBuilder {
 	c.state.Check(STARTED, "instance must be started before calling GetACL")
 	return &getACLBuilder{client: c}
@@ -309,10 +566,24 @@ func (c *curatorFramework) Sync() SyncBuilder {
 	return &syncBuilder{client: c}
 }
 
+func (c *curatorFramework) GetConfig() GetConfigBuilder {
+	c.state.Check(STARTED, "instance must be started before calling GetConfig")
+	return &getConfigBuilder{client: c}
+}
+
+func (c *curatorFramework) Reconfig() ReconfigBuilder {
+	c.state.Check(STARTED, "instance must be started before calling Reconfig")
+	return &reconfigBuilder{client: c, fromConfig: -1}
+}
+
 func (c *curatorFramework) ConnectionStateListenable() ConnectionStateListenable {
 	return c.stateManager.Listenable()
 }
 
+func (c *curatorFramework) SubscribeConnectionState(ctx context.Context) <-chan ConnectionState {
+	return c.stateManager.Subscribe(ctx)
+}
+
 func (c *curatorFramework) CuratorListenable() CuratorListenable {
 	return c.listeners
 }
@@ -335,6 +606,10 @@ func (c *curatorFramework) processEvent(event CuratorEvent) {
 			c.logError(fmt.Errorf("Event listener threw exception, %s", err))
 		}
 	})
+
+	for _, facade := range c.namespaceFacadeCache.facades() {
+		facade.processEvent(event)
+	}
 }
 
 func (c *curatorFramework) validateConnection(state zk.State) {
@@ -347,6 +622,8 @@ func (c *curatorFramework) validateConnection(state zk.State) {
 		c.stateManager.AddStateChange(RECONNECTED)
 	case zk.StateConnectedReadOnly:
 		c.stateManager.AddStateChange(READ_ONLY)
+	case zk.StateAuthFailed:
+		c.stateManager.AddStateChange(AUTH_FAILED)
 	}
 }
 
@@ -385,6 +662,13 @@ func (c *curatorFramework) logError(err error) {
 	c.unhandledErrorListeners.ForEach(func(listener interface{}) {
 		listener.(UnhandledErrorListener).UnhandledError(err)
 	})
+
+	// the errors of a namespace facade are reported to the client as well
+	if c.root != nil {
+		c.root.unhandledErrorListeners.ForEach(func(listener interface{}) {
+			listener.(UnhandledErrorListener).UnhandledError(err)
+		})
+	}
 }
 
 func (c *curatorFramework) NonNamespaceView() CuratorFramework {
@@ -396,12 +680,25 @@ func (c *curatorFramework) UsingNamespace(newNamespace string) CuratorFramework
 	return c.namespaceFacadeCache.Get(newNamespace)
 }
 
+func (c *curatorFramework) Codec() Codec {
+	return c.codec
+}
+
 func (c *curatorFramework) Namespace() string {
 	return c.namespace.namespace
 }
 
+// Return the framework behind the client, which is the embedded one of a namespace facade
+func (c *curatorFramework) framework() *curatorFramework {
+	return c
+}
+
 func (c *curatorFramework) getNamespaceWatcher(watcher Watcher) Watcher {
-	return watcher
+	return c.namespace.newNamespaceWatcher(watcher)
+}
+
+func (c *curatorFramework) ReadOnly() bool {
+	return c.stateManager.CurrentState() == READ_ONLY
 }
 
 func (c *curatorFramework) ZookeeperClient() CuratorZookeeperClient {
@@ -409,7 +706,13 @@ func (c *curatorFramework) ZookeeperClient() CuratorZookeeperClient {
 }
 
 func (c *curatorFramework) NewNamespaceAwareEnsurePath(path string) EnsurePath {
-	return NewEnsurePathWithAcl(c.fixForNamespace(path, false), c.aclProvider)
+	adjustedPath, _ := FixForNamespace(c.namespace.namespace, path, false)
+
+	p := NewEnsurePathWithAcl(adjustedPath, c.aclProvider)
+
+	p.namespace = c.namespace
+
+	return p
 }
 
 func (c *curatorFramework) BlockUntilConnected() error {
@@ -1,12 +1,30 @@
 module github.com/yxdrlitao/curator
 
-go 1.16
+go 1.22
 
 require (
 	github.com/bkaradzic/go-lz4 v1.0.0
-	github.com/fanliao/go-promise v0.0.0-20141029170127-1890db352a72 // indirect
-	github.com/smartystreets/goconvey v1.6.4 // indirect
+	github.com/fanliao/go-promise v0.0.0-20141029170127-1890db352a72
+	github.com/golang/snappy v1.0.0
+	github.com/klauspost/compress v1.18.0
+	github.com/pmezard/go-difflib v1.0.0
+	github.com/smartystreets/goconvey v1.6.4
 	github.com/stretchr/testify v1.7.0
-	github.com/tevino/abool v1.2.0 // indirect
-	github.com/yxdrlitao/go-zookeeper v1.0.0 // indirect
+	github.com/tevino/abool v1.2.0
+	github.com/yxdrlitao/go-zookeeper v1.0.0
+	gopkg.in/yaml.v3 v3.0.0-20200313102051-9f266ea9e77c
+)
+
+require (
+	github.com/davecgh/go-spew v1.1.0 // indirect
+	github.com/gopherjs/gopherjs v0.0.0-20181017120253-0766667cb4d1 // indirect
+	github.com/jtolds/gls v4.20.0+incompatible // indirect
+	github.com/smartystreets/assertions v0.0.0-20180927180507-b2de0cb4f26d // indirect
+	github.com/stretchr/objx v0.1.0 // indirect
+	golang.org/x/crypto v0.0.0-20190308221718-c2843e01d9a2 // indirect
+	golang.org/x/net v0.0.0-20190311183353-d8887717615a // indirect
+	golang.org/x/sys v0.0.0-20190215142949-d0b11bdaac8a // indirect
+	golang.org/x/text v0.3.0 // indirect
+	golang.org/x/tools v0.0.0-20190328211700-ab21143f2384 // indirect
+	gopkg.in/check.v1 v0.0.0-20161208181325-20d25e280405 // indirect
 )
@@ -0,0 +1,101 @@
+package curator
+
+import (
+	"encoding/json"
+	"net/http"
+	"sync/atomic"
+	"time"
+
+	"github.com/yxdrlitao/go-zookeeper/zk"
+)
+
+const SESSION_STATE_HISTORY_SIZE = 25
+
+// A session state received from ZooKeeper and the time it was received
+type SessionStateChange struct {
+	State zk.State
+	Time  time.Time
+}
+
+func (c SessionStateChange) MarshalJSON() ([]byte, error) {
+	return json.Marshal(struct {
+		State string    `json:"state"`
+		Time  time.Time `json:"time"`
+	}{c.State.String(), c.Time})
+}
+
+// Snapshot of the session and connection of a CuratorZookeeperClient
+type ZookeeperClientInfo struct {
+	SessionID            int64                `json:"sessionId"`            // the session id, 0 if no session was established
+	HasPassword          bool                 `json:"hasPassword"`          // whether the session password is known, which comes with the established session
+	SessionTimeout       time.Duration        `json:"sessionTimeout"`       // the requested session timeout, go-zookeeper doesn't expose the negotiated one
+	Server               string               `json:"server"`               // the currently connected server
+	LastZxid             int64                `json:"lastZxid"`             // the largest zxid in the stats received by the framework, 0 if none
+	InstanceIndex        int64                `json:"instanceIndex"`        // incremented every time the connection is reset
+	ConnectionString     string               `json:"connectionString"`     // the connection string currently in use
+	Connected            bool                 `json:"connected"`            // whether the client is currently connected
+	StateHistory         []SessionStateChange `json:"stateHistory"`         // the most recent session state changes, oldest first
+	OutstandingWatches   int64                `json:"outstandingWatches"`   // watchers registered by the framework which have not fired yet
+	BackgroundOperations int64                `json:"backgroundOperations"` // background operations which have not completed yet
+}
+
+// The optional interfaces a ZookeeperConnection may implement to expose its session, *zk.Conn implements them.
+type sessionIDProvider interface {
+	SessionID() int64
+}
+
+type serverProvider interface {
+	Server() string
+}
+
+func (c *curatorZookeeperClient) Info() *ZookeeperClientInfo {
+	info := &ZookeeperClientInfo{
+		SessionTimeout:       c.state.sessionTimeout,
+		LastZxid:             atomic.LoadInt64(&c.lastZxid),
+		InstanceIndex:        c.state.InstanceIndex(),
+		ConnectionString:     c.state.zooKeeper.getConnectionString(),
+		Connected:            c.state.Connected(),
+		StateHistory:         c.state.StateHistory(),
+		OutstandingWatches:   c.OutstandingWatches(),
+		BackgroundOperations: c.BackgroundOperations(),
+	}
+
+	// never dial for the diagnostics, only the connection already established is inspected
+	if conn := c.state.zooKeeper.cachedConnection(); conn != nil {
+		if p, ok := conn.(sessionIDProvider); ok {
+			info.SessionID = p.SessionID()
+			info.HasPassword = info.SessionID != 0
+		}
+		if p, ok := conn.(serverProvider); ok {
+			info.Server = p.Server()
+		}
+	}
+
+	return info
+}
+
+type infoHandler struct {
+	client CuratorZookeeperClient
+}
+
+// Create a debug HTTP handler which renders CuratorZookeeperClient.Info() as JSON
+func NewInfoHandler(client CuratorZookeeperClient) http.Handler {
+	return &infoHandler{client}
+}
+
+func (h *infoHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet && r.Method != http.MethodHead {
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+
+	encoder := json.NewEncoder(w)
+	encoder.SetIndent("", "  ")
+
+	if err := encoder.Encode(h.client.Info()); err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+	}
+}
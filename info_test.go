@@ -0,0 +1,187 @@
+package curator
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/yxdrlitao/go-zookeeper/zk"
+)
+
+type mockSessionConn struct {
+	mockConn
+}
+
+func (c *mockSessionConn) SessionID() int64 { return 1234 }
+
+func (c *mockSessionConn) Server() string { return "127.0.0.1:2181" }
+
+func TestZookeeperClientInfo(t *testing.T) {
+	newMockContainer().Test(t, func(client CuratorFramework, ensembleProvider *mockEnsembleProvider, events chan zk.Event) {
+		zkClient := client.ZookeeperClient()
+
+		ensembleProvider.On("ConnectionString").Return("connStr")
+
+		events <- zk.Event{Type: zk.EventSession, State: zk.StateConnecting}
+		events <- zk.Event{Type: zk.EventSession, State: zk.StateHasSession}
+
+		time.Sleep(100 * time.Millisecond)
+
+		info := zkClient.Info()
+
+		assert.NotNil(t, info)
+		assert.Equal(t, int64(0), info.SessionID)
+		assert.False(t, info.HasPassword)
+		assert.Equal(t, DEFAULT_SESSION_TIMEOUT, info.SessionTimeout)
+		assert.Equal(t, int64(0), info.LastZxid)
+		assert.Equal(t, int64(1), info.InstanceIndex)
+		assert.Equal(t, "connStr", info.ConnectionString)
+		assert.True(t, info.Connected)
+
+		if assert.Len(t, info.StateHistory, 2) {
+			assert.Equal(t, zk.StateConnecting, info.StateHistory[0].State)
+			assert.Equal(t, zk.StateHasSession, info.StateHistory[1].State)
+			assert.False(t, info.StateHistory[1].Time.Before(info.StateHistory[0].Time))
+		}
+	})
+}
+
+func TestZookeeperClientInfoFromSession(t *testing.T) {
+	conn := &mockSessionConn{}
+	dialer := &mockZookeeperDialer{log: t.Logf}
+
+	newMockContainer().Prepare(func(builder *CuratorFrameworkBuilder) {
+		builder.ZookeeperDialer = dialer
+	}).Test(t, func(builder *CuratorFrameworkBuilder, ensembleProvider *mockEnsembleProvider) {
+		ensembleProvider.On("ConnectionString").Return("connStr").Once()
+		ensembleProvider.On("Start").Return(nil).Once()
+		ensembleProvider.On("Close").Return(nil).Once()
+		dialer.On("Dial", "connStr", DEFAULT_SESSION_TIMEOUT, false).Return(conn, nil, nil).Once()
+		conn.On("Close").Return().Once()
+
+		client := builder.Build()
+
+		assert.NoError(t, client.Start())
+
+		conn.On("Exists", "/node").Return(true, &zk.Stat{Czxid: 30, Mzxid: 42, Pzxid: 40}, nil).Once()
+		conn.On("Get", "/old").Return([]byte("data"), &zk.Stat{Czxid: 10, Mzxid: 20, Pzxid: 10}, nil).Once()
+
+		_, err := client.CheckExists().ForPath("/node")
+
+		assert.NoError(t, err)
+
+		_, err = client.GetData().ForPath("/old")
+
+		assert.NoError(t, err)
+
+		info := client.ZookeeperClient().Info()
+
+		assert.Equal(t, int64(1234), info.SessionID)
+		assert.True(t, info.HasPassword)
+		assert.Equal(t, DEFAULT_SESSION_TIMEOUT, info.SessionTimeout)
+		assert.Equal(t, "127.0.0.1:2181", info.Server)
+		assert.Equal(t, int64(42), info.LastZxid, "the largest zxid seen")
+
+		assert.NoError(t, client.Close())
+	})
+
+	conn.AssertExpectations(t)
+	dialer.AssertExpectations(t)
+}
+
+func TestInfoWithoutDialing(t *testing.T) {
+	dialer := &mockZookeeperDialer{log: t.Logf}
+	zkClient := NewCuratorZookeeperClient(dialer, NewFixedEnsembleProvider("connStr"), time.Second, time.Second, nil, nil, false, nil)
+
+	zkClient.state.zooKeeper.SetHelper(&zookeeperFactory{holder: zkClient.state.zooKeeper})
+
+	info := zkClient.Info()
+
+	assert.Equal(t, int64(0), info.SessionID)
+	assert.Equal(t, "", info.Server)
+
+	dialer.AssertExpectations(t) // never dial
+}
+
+func TestOutstandingOperations(t *testing.T) {
+	zkClient := NewCuratorZookeeperClient(nil, NewFixedEnsembleProvider("connStr"), time.Second, time.Second, nil, nil, false, nil)
+
+	events := make(chan zk.Event)
+	release := make(chan struct{})
+	fired := make(chan struct{})
+
+	zkClient.inBackground(func() { <-release })
+	zkClient.watch(NewWatcher(func(event *zk.Event) { close(fired) }), events)
+
+	info := zkClient.Info()
+
+	assert.Equal(t, int64(1), info.OutstandingWatches)
+	assert.Equal(t, int64(1), info.BackgroundOperations)
+
+	close(release)
+	events <- zk.Event{Type: zk.EventNodeDataChanged, Path: "/node"}
+	close(events)
+	<-fired
+
+	time.Sleep(100 * time.Millisecond)
+
+	assert.Equal(t, int64(0), zkClient.OutstandingWatches())
+	assert.Equal(t, int64(0), zkClient.BackgroundOperations())
+}
+
+func TestStateHistory(t *testing.T) {
+	state := newConnectionState(nil, nil, time.Second, time.Second, nil, newDefaultTracerDriver(), false)
+
+	for i := 0; i < SESSION_STATE_HISTORY_SIZE+5; i++ {
+		state.recordState(zk.StateConnecting)
+	}
+
+	state.recordState(zk.StateHasSession)
+
+	history := state.StateHistory()
+
+	assert.Len(t, history, SESSION_STATE_HISTORY_SIZE)
+	assert.Equal(t, zk.StateHasSession, history[len(history)-1].State)
+}
+
+func TestInfoHandler(t *testing.T) {
+	zkClient := &mockCuratorZookeeperClient{log: t.Logf}
+
+	zkClient.On("Info").Return(&ZookeeperClientInfo{
+		SessionID:        1234,
+		InstanceIndex:    2,
+		ConnectionString: "connStr",
+		StateHistory:     []SessionStateChange{{State: zk.StateHasSession}},
+	}).Once()
+
+	server := httptest.NewServer(NewInfoHandler(zkClient))
+	defer server.Close()
+
+	res, err := http.Get(server.URL)
+
+	if assert.NoError(t, err) {
+		defer res.Body.Close()
+
+		var info map[string]interface{}
+
+		assert.Equal(t, http.StatusOK, res.StatusCode)
+		assert.Equal(t, "application/json", res.Header.Get("Content-Type"))
+		assert.NoError(t, json.NewDecoder(res.Body).Decode(&info))
+		assert.Equal(t, float64(1234), info["sessionId"])
+		assert.Equal(t, "connStr", info["connectionString"])
+		assert.Equal(t, "StateHasSession", info["stateHistory"].([]interface{})[0].(map[string]interface{})["state"])
+	}
+
+	res, err = http.Post(server.URL, "application/json", nil)
+
+	if assert.NoError(t, err) {
+		res.Body.Close()
+
+		assert.Equal(t, http.StatusMethodNotAllowed, res.StatusCode)
+	}
+
+	zkClient.AssertExpectations(t)
+}
@@ -125,6 +125,10 @@ func (c *ListenerContainer) Len() int {
 	if c == nil {
 		return 0
 	}
+
+	c.lock.RLock()
+	defer c.lock.RUnlock()
+
 	return len(c.listeners)
 }
 
@@ -1,6 +1,7 @@
 package curator
 
 import (
+	"context"
 	"errors"
 	"math/rand"
 	"reflect"
@@ -321,6 +322,71 @@ func (c *mockConn) Sync(path string) (string, error) {
 	return path, err
 }
 
+func (c *mockConn) IncrementalReconfig(joining, leaving []string, version int64) (*zk.Stat, error) {
+	args := c.Called(joining, leaving, version)
+
+	stat, _ := args.Get(0).(*zk.Stat)
+	err := args.Error(1)
+
+	if c.log != nil {
+		c.log("ZookeeperConnection.IncrementalReconfig(joining=%v, leaving=%v, version=%d)(stat=%v, error=%v)", joining, leaving, version, stat, err)
+	}
+
+	return stat, err
+}
+
+func (c *mockConn) Reconfig(members []string, version int64) (*zk.Stat, error) {
+	args := c.Called(members, version)
+
+	stat, _ := args.Get(0).(*zk.Stat)
+	err := args.Error(1)
+
+	if c.log != nil {
+		c.log("ZookeeperConnection.Reconfig(members=%v, version=%d)(stat=%v, error=%v)", members, version, stat, err)
+	}
+
+	return stat, err
+}
+
+func (c *mockConn) MultiRead(ops ...interface{}) ([]MultiReadResponse, error) {
+	args := c.Called(ops)
+
+	responses, _ := args.Get(0).([]MultiReadResponse)
+	err := args.Error(1)
+
+	if c.log != nil {
+		c.log("ZookeeperConnection.MultiRead(ops=%v)(responses=%v, error=%v)", ops, responses, err)
+	}
+
+	return responses, err
+}
+
+func (c *mockConn) GetEphemerals(prefix string) ([]string, error) {
+	args := c.Called(prefix)
+
+	paths, _ := args.Get(0).([]string)
+	err := args.Error(1)
+
+	if c.log != nil {
+		c.log("ZookeeperConnection.GetEphemerals(prefix=\"%s\")(paths=%v, error=%v)", prefix, paths, err)
+	}
+
+	return paths, err
+}
+
+func (c *mockConn) GetAllChildrenNumber(path string) (int32, error) {
+	args := c.Called(path)
+
+	number, _ := args.Get(0).(int32)
+	err := args.Error(1)
+
+	if c.log != nil {
+		c.log("ZookeeperConnection.GetAllChildrenNumber(path=\"%s\")(number=%d, error=%v)", path, number, err)
+	}
+
+	return number, err
+}
+
 type mockZookeeperDialer struct {
 	mock.Mock
 
@@ -421,6 +487,18 @@ func (e *mockEnsurePath) Ensure(client CuratorZookeeperClient) error {
 	return err
 }
 
+func (e *mockEnsurePath) EnsureWithContext(ctx context.Context, client CuratorZookeeperClient) error {
+	args := e.Mock.Called(ctx, client)
+
+	err := args.Error(0)
+
+	if e.log != nil {
+		e.log("EnsurePath.EnsureWithContext(ctx=%v, client=%p) error=%v", ctx, client, err)
+	}
+
+	return err
+}
+
 func (e *mockEnsurePath) ExcludingLast() EnsurePath {
 	args := e.Mock.Called()
 
@@ -433,6 +511,30 @@ func (e *mockEnsurePath) ExcludingLast() EnsurePath {
 	return ret
 }
 
+func (e *mockEnsurePath) UsingContainers() EnsurePath {
+	args := e.Mock.Called()
+
+	ret, _ := args.Get(0).(EnsurePath)
+
+	if e.log != nil {
+		e.log("EnsurePath.UsingContainers() EnsurePath=%p", ret)
+	}
+
+	return ret
+}
+
+func (e *mockEnsurePath) WithACLProvider(aclProvider ACLProvider) EnsurePath {
+	args := e.Mock.Called(aclProvider)
+
+	ret, _ := args.Get(0).(EnsurePath)
+
+	if e.log != nil {
+		e.log("EnsurePath.WithACLProvider(aclProvider=%v) EnsurePath=%p", aclProvider, ret)
+	}
+
+	return ret
+}
+
 type mockEnsurePathHelper struct {
 	mock.Mock
 
@@ -451,6 +553,22 @@ func (h *mockEnsurePathHelper) Ensure(client CuratorZookeeperClient, path string
 	return err
 }
 
+type mockContextEnsurePathHelper struct {
+	mockEnsurePathHelper
+}
+
+func (h *mockContextEnsurePathHelper) EnsureWithContext(ctx context.Context, client CuratorZookeeperClient, path string, makeLastNode bool, aclProvider ACLProvider, asContainers bool) error {
+	args := h.Called(ctx, client, path, makeLastNode, aclProvider, asContainers)
+
+	err := args.Error(0)
+
+	if h.log != nil {
+		h.log("EnsurePathHelper.EnsureWithContext(ctx=%v, client=%p, path=\"%s\", makeLastNode=%v, aclProvider=%v, asContainers=%v) error=%v", ctx, client, path, makeLastNode, aclProvider, asContainers, err)
+	}
+
+	return err
+}
+
 type mockCuratorZookeeperClient struct {
 	mock.Mock
 
@@ -540,6 +658,16 @@ func (c *mockCuratorZookeeperClient) StartTracer(name string) Tracer {
 	return tracer
 }
 
+func (c *mockCuratorZookeeperClient) Info() *ZookeeperClientInfo {
+	info, _ := c.Called().Get(0).(*ZookeeperClientInfo)
+
+	if c.log != nil {
+		c.log("CuratorZookeeperClient.Info() info=%v", info)
+	}
+
+	return info
+}
+
 type mockCuratorFramework struct {
 	mock.Mock
 
@@ -646,6 +774,46 @@ func (c *mockCuratorFramework) GetChildren() GetChildrenBuilder {
 	return builder
 }
 
+func (c *mockCuratorFramework) BatchGet() BatchGetBuilder {
+	builder, _ := c.Called().Get(0).(BatchGetBuilder)
+
+	if c.log != nil {
+		c.log("CuratorFramework.BatchGet() BatchGetBuilder=%v", builder)
+	}
+
+	return builder
+}
+
+func (c *mockCuratorFramework) MultiRead() MultiReadBuilder {
+	builder, _ := c.Called().Get(0).(MultiReadBuilder)
+
+	if c.log != nil {
+		c.log("CuratorFramework.MultiRead() MultiReadBuilder=%v", builder)
+	}
+
+	return builder
+}
+
+func (c *mockCuratorFramework) GetEphemerals() GetEphemeralsBuilder {
+	builder, _ := c.Called().Get(0).(GetEphemeralsBuilder)
+
+	if c.log != nil {
+		c.log("CuratorFramework.GetEphemerals() GetEphemeralsBuilder=%v", builder)
+	}
+
+	return builder
+}
+
+func (c *mockCuratorFramework) GetAllChildrenNumber() GetAllChildrenNumberBuilder {
+	builder, _ := c.Called().Get(0).(GetAllChildrenNumberBuilder)
+
+	if c.log != nil {
+		c.log("CuratorFramework.GetAllChildrenNumber() GetAllChildrenNumberBuilder=%v", builder)
+	}
+
+	return builder
+}
+
 func (c *mockCuratorFramework) GetACL() GetACLBuilder {
 	builder, _ := c.Called().Get(0).(GetACLBuilder)
 
@@ -694,6 +862,26 @@ func (c *mockCuratorFramework) Sync() SyncBuilder {
 	return builder
 }
 
+func (c *mockCuratorFramework) GetConfig() GetConfigBuilder {
+	builder, _ := c.Called().Get(0).(GetConfigBuilder)
+
+	if c.log != nil {
+		c.log("CuratorFramework.GetConfig() GetConfigBuilder=%v", builder)
+	}
+
+	return builder
+}
+
+func (c *mockCuratorFramework) Reconfig() ReconfigBuilder {
+	builder, _ := c.Called().Get(0).(ReconfigBuilder)
+
+	if c.log != nil {
+		c.log("CuratorFramework.Reconfig() ReconfigBuilder=%v", builder)
+	}
+
+	return builder
+}
+
 func (c *mockCuratorFramework) ConnectionStateListenable() ConnectionStateListenable {
 	listenable, _ := c.Called().Get(0).(ConnectionStateListenable)
 
@@ -704,6 +892,16 @@ func (c *mockCuratorFramework) ConnectionStateListenable() ConnectionStateListen
 	return listenable
 }
 
+func (c *mockCuratorFramework) SubscribeConnectionState(ctx context.Context) <-chan ConnectionState {
+	states, _ := c.Called(ctx).Get(0).(<-chan ConnectionState)
+
+	if c.log != nil {
+		c.log("CuratorFramework.SubscribeConnectionState(ctx: %v) States=%v", ctx, states)
+	}
+
+	return states
+}
+
 func (c *mockCuratorFramework) CuratorListenable() CuratorListenable {
 	listenable, _ := c.Called().Get(0).(CuratorListenable)
 
@@ -744,6 +942,16 @@ func (c *mockCuratorFramework) UsingNamespace(newNamespace string) CuratorFramew
 	return framework
 }
 
+func (c *mockCuratorFramework) Codec() Codec {
+	codec, _ := c.Called().Get(0).(Codec)
+
+	if c.log != nil {
+		c.log("CuratorFramework.Codec() Codec=%v", codec)
+	}
+
+	return codec
+}
+
 func (c *mockCuratorFramework) Namespace() string {
 	namespace := c.Called().String(0)
 
@@ -794,6 +1002,16 @@ func (c *mockCuratorFramework) BlockUntilConnectedTimeout(maxWaitTime time.Durat
 	return err
 }
 
+func (c *mockCuratorFramework) ReadOnly() bool {
+	readOnly := c.Called().Bool(0)
+
+	if c.log != nil {
+		c.log("CuratorFramework.ReadOnly() readOnly=%v", readOnly)
+	}
+
+	return readOnly
+}
+
 type mockContainer struct {
 	builder *CuratorFrameworkBuilder
 }
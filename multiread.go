@@ -0,0 +1,264 @@
+package curator
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/yxdrlitao/go-zookeeper/zk"
+)
+
+// The max number of attempts of an emulated MultiRead to take a consistent snapshot
+const MULTI_READ_MAX_ATTEMPTS = 5
+
+// The nodes kept changing while an emulated MultiRead was taking a snapshot
+var ErrMultiReadConflict = errors.New("the nodes kept changing during the multi read")
+
+// Read the data of the node in a MultiRead
+type GetDataRequest struct {
+	Path string
+}
+
+// Read the children of the node in a MultiRead
+type GetChildrenRequest struct {
+	Path string
+}
+
+// The response of an operation of ZookeeperConnection.MultiRead
+type MultiReadResponse struct {
+	Data     []byte   // the data of the node of a *GetDataRequest
+	Children []string // the children of the node of a *GetChildrenRequest
+	Stat     *zk.Stat // the stat of the node
+	Error    error    // the error of the operation, e.g. zk.ErrNoNode
+}
+
+// Emulate MultiRead with the plain reads, the reads are repeated until
+// the stats of all the nodes are unchanged after the reads, which means there was
+// an instant when all the nodes had the values which have been read.
+func emulateMultiRead(conn ZookeeperConnection, ops ...interface{}) ([]MultiReadResponse, error) {
+	paths := make([]string, len(ops))
+
+	for i, op := range ops {
+		switch op := op.(type) {
+		case *GetDataRequest:
+			paths[i] = op.Path
+		case *GetChildrenRequest:
+			paths[i] = op.Path
+		default:
+			return nil, fmt.Errorf("unknown operation of multi read: %T", op)
+		}
+	}
+
+	for attempt := 0; attempt < MULTI_READ_MAX_ATTEMPTS; attempt++ {
+		responses := make([]MultiReadResponse, len(ops))
+
+		for i, op := range ops {
+			var err error
+
+			switch op.(type) {
+			case *GetDataRequest:
+				responses[i].Data, responses[i].Stat, err = conn.Get(paths[i])
+			case *GetChildrenRequest:
+				responses[i].Children, responses[i].Stat, err = conn.Children(paths[i])
+			}
+
+			if err != nil && !isNodeError(err) {
+				return nil, err
+			}
+
+			responses[i].Error = err
+		}
+
+		if consistent, err := verifyMultiRead(conn, paths, responses); err != nil {
+			return nil, err
+		} else if consistent {
+			return responses, nil
+		}
+	}
+
+	return nil, ErrMultiReadConflict
+}
+
+func verifyMultiRead(conn ZookeeperConnection, paths []string, responses []MultiReadResponse) (bool, error) {
+	for i, path := range paths {
+		if responses[i].Error == zk.ErrNoAuth {
+			continue
+		}
+
+		exists, stat, err := conn.Exists(path)
+
+		if err != nil && !isNodeError(err) {
+			return false, err
+		}
+
+		if read := responses[i].Stat; read == nil || responses[i].Error == zk.ErrNoNode {
+			if exists && stat != nil {
+				return false, nil
+			}
+		} else if stat == nil || stat.Czxid != read.Czxid || stat.Mzxid != read.Mzxid || stat.Pzxid != read.Pzxid {
+			return false, nil
+		}
+	}
+
+	return true, nil
+}
+
+// The error belongs to the node instead of the connection
+func isNodeError(err error) bool {
+	return err == zk.ErrNoNode || err == zk.ErrNoAuth
+}
+
+// The result of an operation of CuratorFramework.MultiRead()
+type MultiReadResult struct {
+	Type     CuratorEventType // GET_DATA or CHILDREN
+	Path     string           // the given path of the operation
+	Data     []byte           // the data of the node, decrypted and decompressed as GetData()
+	Children []string         // the children of the node
+	Stat     *zk.Stat         // the stat of the node
+	Err      error            // the error of the operation, e.g. zk.ErrNoNode
+}
+
+type multiReadBuilder struct {
+	client         *curatorFramework
+	backgrounding  backgrounding
+	decompress     bool
+	skipDecompress bool
+	ops            []interface{}
+	results        []*MultiReadResult
+	err            error
+}
+
+func (b *multiReadBuilder) GetData(givenPath string) MultiReadBuilder {
+	adjustedPath, err := b.client.fixForNamespace(givenPath, false)
+
+	if err != nil && b.err == nil {
+		b.err = err
+	}
+
+	b.ops = append(b.ops, &GetDataRequest{Path: adjustedPath})
+	b.results = append(b.results, &MultiReadResult{Type: GET_DATA, Path: givenPath})
+
+	return b
+}
+
+func (b *multiReadBuilder) GetChildren(givenPath string) MultiReadBuilder {
+	adjustedPath, err := b.client.fixForNamespace(givenPath, false)
+
+	if err != nil && b.err == nil {
+		b.err = err
+	}
+
+	b.ops = append(b.ops, &GetChildrenRequest{Path: adjustedPath})
+	b.results = append(b.results, &MultiReadResult{Type: CHILDREN, Path: givenPath})
+
+	return b
+}
+
+func (b *multiReadBuilder) Commit() ([]*MultiReadResult, error) {
+	if b.err != nil {
+		return nil, b.err
+	}
+
+	if b.backgrounding.inBackground {
+		b.client.client.inBackground(b.pathInBackground)
+
+		return nil, nil
+	}
+
+	return b.pathInForeground()
+}
+
+func (b *multiReadBuilder) pathInBackground() {
+	tracer := b.client.ZookeeperClient().StartTracer("multiReadBuilder.pathInBackground")
+
+	defer tracer.Commit()
+
+	results, err := b.pathInForeground()
+
+	if b.backgrounding.callback != nil {
+		b.backgrounding.callback(b.client, &curatorEvent{
+			eventType:        MULTI_READ,
+			err:              err,
+			multiReadResults: results,
+			context:          b.backgrounding.context,
+			stale:            b.client.ReadOnly(),
+		})
+	}
+}
+
+func (b *multiReadBuilder) pathInForeground() ([]*MultiReadResult, error) {
+	zkClient := b.client.ZookeeperClient()
+
+	_, err := zkClient.NewRetryLoop().CallWithRetry(func() (interface{}, error) {
+		if conn, err := zkClient.Conn(); err != nil {
+			return nil, err
+		} else if responses, err := conn.MultiRead(b.ops...); err != nil {
+			return nil, err
+		} else {
+			for i, response := range responses {
+				result := b.results[i]
+
+				result.Data, result.Children, result.Stat, result.Err = nil, response.Children, response.Stat, response.Error
+
+				if result.Type == GET_DATA && response.Error == nil {
+					result.Data, result.Err = b.decodeData(conn, b.ops[i].(*GetDataRequest).Path, result.Path, response.Data, result.Stat)
+				}
+			}
+
+			return nil, nil
+		}
+	})
+
+	if err != nil {
+		return nil, err
+	}
+
+	return b.results, nil
+}
+
+func (b *multiReadBuilder) decodeData(conn ZookeeperConnection, path, givenPath string, data []byte, stat *zk.Stat) ([]byte, error) {
+	if manifest, ok := parseChunkManifest(data); ok && b.client.chunkSize > 0 {
+		var err error
+
+		if data, err = b.client.readChunks(conn, path, manifest, stat); err != nil {
+			return nil, err
+		}
+	}
+
+	return b.client.decodeData(givenPath, data, true, b.client.shouldCompress(givenPath, b.decompress, b.skipDecompress))
+}
+
+func (b *multiReadBuilder) Decompressed() MultiReadBuilder {
+	b.decompress = true
+
+	return b
+}
+
+func (b *multiReadBuilder) SkipDecompression() MultiReadBuilder {
+	b.skipDecompress = true
+
+	return b
+}
+
+func (b *multiReadBuilder) InBackground() MultiReadBuilder {
+	b.backgrounding = backgrounding{inBackground: true}
+
+	return b
+}
+
+func (b *multiReadBuilder) InBackgroundWithContext(context interface{}) MultiReadBuilder {
+	b.backgrounding = backgrounding{inBackground: true, context: context}
+
+	return b
+}
+
+func (b *multiReadBuilder) InBackgroundWithCallback(callback BackgroundCallback) MultiReadBuilder {
+	b.backgrounding = backgrounding{inBackground: true, callback: callback}
+
+	return b
+}
+
+func (b *multiReadBuilder) InBackgroundWithCallbackAndContext(callback BackgroundCallback, context interface{}) MultiReadBuilder {
+	b.backgrounding = backgrounding{inBackground: true, context: context, callback: callback}
+
+	return b
+}
@@ -0,0 +1,118 @@
+package curator
+
+import (
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/suite"
+	"github.com/yxdrlitao/go-zookeeper/zk"
+)
+
+func TestEmulateMultiRead(t *testing.T) {
+	conn := &mockConn{log: t.Logf}
+
+	dataStat := &zk.Stat{Czxid: 1, Mzxid: 2, Pzxid: 1}
+	childrenStat := &zk.Stat{Czxid: 3, Mzxid: 3, Pzxid: 4}
+
+	conn.On("Get", "/a").Return([]byte("data"), dataStat, nil).Twice()
+	conn.On("Children", "/b").Return([]string{"c"}, childrenStat, nil).Twice()
+	conn.On("Get", "/d").Return(nil, nil, zk.ErrNoNode).Twice()
+
+	// the node was changed after it has been read, the reads are repeated
+	conn.On("Exists", "/a").Return(true, &zk.Stat{Czxid: 1, Mzxid: 5, Pzxid: 1}, nil).Once()
+	conn.On("Exists", "/a").Return(true, dataStat, nil).Once()
+	conn.On("Exists", "/b").Return(true, childrenStat, nil).Once()
+	conn.On("Exists", "/d").Return(false, nil, nil).Once()
+
+	responses, err := emulateMultiRead(conn, &GetDataRequest{"/a"}, &GetChildrenRequest{"/b"}, &GetDataRequest{"/d"})
+
+	assert.NoError(t, err)
+	assert.Equal(t, []MultiReadResponse{
+		{Data: []byte("data"), Stat: dataStat},
+		{Children: []string{"c"}, Stat: childrenStat},
+		{Error: zk.ErrNoNode},
+	}, responses)
+
+	conn.AssertExpectations(t)
+
+	_, err = emulateMultiRead(conn, "/a")
+
+	assert.Error(t, err)
+}
+
+func TestEmulateMultiReadConflict(t *testing.T) {
+	conn := &mockConn{log: t.Logf}
+
+	conn.On("Get", "/a").Return(nil, nil, zk.ErrNoNode).Times(MULTI_READ_MAX_ATTEMPTS)
+	conn.On("Exists", "/a").Return(true, &zk.Stat{}, nil).Times(MULTI_READ_MAX_ATTEMPTS)
+
+	_, err := emulateMultiRead(conn, &GetDataRequest{"/a"})
+
+	assert.Equal(t, ErrMultiReadConflict, err)
+
+	conn.AssertExpectations(t)
+}
+
+type MultiReadBuilderTestSuite struct {
+	mockContainerTestSuite
+}
+
+func TestMultiReadBuilder(t *testing.T) {
+	suite.Run(t, new(MultiReadBuilderTestSuite))
+}
+
+func (s *MultiReadBuilderTestSuite) TestMultiRead() {
+	s.WithNamespace("parent", func(client CuratorFramework, conn *mockConn, data []byte, stat *zk.Stat) {
+		conn.On("Exists", "/parent").Return(true, nil, nil).Once()
+		conn.On("MultiRead", []interface{}{&GetDataRequest{"/parent/a"}, &GetChildrenRequest{"/parent/b"}}).Return([]MultiReadResponse{
+			{Data: data, Stat: stat},
+			{Error: zk.ErrNoNode},
+		}, nil).Once()
+
+		results, err := client.MultiRead().GetData("/a").GetChildren("/b").Commit()
+
+		s.NoError(err)
+		s.Equal([]*MultiReadResult{
+			{Type: GET_DATA, Path: "/a", Data: data, Stat: stat},
+			{Type: CHILDREN, Path: "/b", Err: zk.ErrNoNode},
+		}, results)
+	})
+}
+
+func (s *MultiReadBuilderTestSuite) TestDecompressed() {
+	s.With(func(client CuratorFramework, conn *mockConn, compress *mockCompressionProvider, data []byte, stat *zk.Stat) {
+		conn.On("MultiRead", []interface{}{&GetDataRequest{"/node"}}).Return([]MultiReadResponse{
+			{Data: []byte("compressed(data)"), Stat: stat},
+		}, nil).Once()
+		compress.On("Decompress", "/node", []byte("compressed(data)")).Return(data, nil).Once()
+
+		results, err := client.MultiRead().GetData("/node").Decompressed().Commit()
+
+		s.NoError(err)
+		s.Equal(data, results[0].Data)
+	})
+}
+
+func (s *MultiReadBuilderTestSuite) TestBackground() {
+	s.With(func(client CuratorFramework, conn *mockConn, wg *sync.WaitGroup, data []byte, stat *zk.Stat) {
+		ctxt := "context"
+
+		conn.On("MultiRead", []interface{}{&GetDataRequest{"/node"}}).Return([]MultiReadResponse{{Data: data, Stat: stat}}, nil).Once()
+
+		_, err := client.MultiRead().GetData("/node").InBackgroundWithCallbackAndContext(
+			func(client CuratorFramework, event CuratorEvent) error {
+				defer wg.Done()
+
+				s.Equal(MULTI_READ, event.Type())
+				s.NoError(event.Err())
+				s.Equal(ctxt, event.Context())
+				s.Len(event.MultiReadResults(), 1)
+				s.Equal(data, event.MultiReadResults()[0].Data)
+
+				return nil
+			}, ctxt).Commit()
+
+		s.NoError(err)
+	})
+}
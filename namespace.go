@@ -1,16 +1,19 @@
 package curator
 
 import (
+	"context"
 	"errors"
 	"fmt"
 	"strings"
 	"sync"
+
+	"github.com/yxdrlitao/go-zookeeper/zk"
 )
 
 type namespaceImpl struct {
 	client     *curatorFramework
 	namespace  string
-	ensurePath EnsurePath
+	ensurePath *ensurePath
 }
 
 func newNamespace(client *curatorFramework, namespace string) *namespaceImpl {
@@ -41,14 +44,42 @@ func FixForNamespace(namespace, path string, isSequential bool) (string, error)
 	return path, nil
 }
 
-func (n *namespaceImpl) fixForNamespace(path string, isSequential bool) string {
-	if n.ensurePath != nil {
-		n.ensurePath.Ensure(n.client.ZookeeperClient())
+// Apply the namespace to the given path, after the namespace is ensured
+func (n *namespaceImpl) fixForNamespace(path string, isSequential bool) (string, error) {
+	if err := n.ensure(context.Background()); err != nil {
+		return "", err
+	}
+
+	return FixForNamespace(n.namespace, path, isSequential)
+}
+
+// Make sure the root of the namespace is created, the failure is retried next time
+func (n *namespaceImpl) ensure(ctx context.Context) error {
+	if n.ensurePath == nil {
+		return nil
 	}
 
-	s, _ := FixForNamespace(n.namespace, path, isSequential)
+	if err := n.ensurePath.EnsureWithContext(ctx, n.client.ZookeeperClient()); err != nil {
+		return fmt.Errorf("fail to ensure namespace %s, %w", n.namespace, err)
+	}
 
-	return s
+	return nil
+}
+
+// Ensure the namespace again if its root has been deleted, which fails the creation with ErrNoNode.
+// Return true if the root is created again, so the creation could be retried.
+func (n *namespaceImpl) recreate(conn ZookeeperConnection, err error) bool {
+	if err != zk.ErrNoNode || n.ensurePath == nil {
+		return false
+	}
+
+	if exists, _, err := conn.Exists(n.ensurePath.path); err != nil || exists {
+		return false
+	}
+
+	n.ensurePath.reset()
+
+	return n.ensure(context.Background()) == nil
 }
 
 func (n *namespaceImpl) unfixForNamespace(path string) string {
@@ -67,6 +98,72 @@ func (n *namespaceImpl) unfixForNamespace(path string) string {
 	return path
 }
 
+// Whether the full path is the namespace itself or a node within it
+func (n *namespaceImpl) contains(path string) bool {
+	if len(n.namespace) == 0 {
+		return true
+	}
+
+	prefix := JoinPath(n.namespace)
+
+	return path == prefix || strings.HasPrefix(path, prefix+PATH_SEPARATOR)
+}
+
+// Wrap the watcher to receive the paths relative to the namespace,
+// the watcher already wrapped for the namespace is returned as it is.
+func (n *namespaceImpl) newNamespaceWatcher(watcher Watcher) Watcher {
+	if watcher == nil || len(n.namespace) == 0 {
+		return watcher
+	}
+
+	if w, ok := watcher.(*namespaceWatcher); ok && w.namespace.namespace == n.namespace {
+		return w
+	}
+
+	return &namespaceWatcher{namespace: n, watcher: watcher}
+}
+
+// The watcher registered with a namespaced client,
+// which drops the events outside the namespace and strips the namespace from the paths of the events.
+type namespaceWatcher struct {
+	namespace *namespaceImpl
+	watcher   Watcher
+}
+
+func (w *namespaceWatcher) process(event *zk.Event) {
+	// the session events have no path
+	if len(event.Path) > 0 {
+		if !w.namespace.contains(event.Path) {
+			return
+		}
+
+		e := *event
+		e.Path = w.namespace.unfixForNamespace(event.Path)
+		event = &e
+	}
+
+	w.watcher.process(event)
+}
+
+// Whether the watchers are the same one, regardless of the namespace wrapper
+func sameWatcher(w1, w2 Watcher) bool {
+	if w1 == w2 {
+		return true
+	}
+
+	if w, ok := w1.(*namespaceWatcher); ok {
+		w1 = w.watcher
+	}
+
+	if w, ok := w2.(*namespaceWatcher); ok {
+		w2 = w.watcher
+	}
+
+	return w1 == w2
+}
+
+// A view of the client using another namespace, which shares the connection of the client
+// but owns the listeners receiving only the events within its namespace.
 type namespaceFacade struct {
 	curatorFramework
 }
@@ -76,6 +173,9 @@ func newNamespaceFacade(client *curatorFramework, namespace string) *namespaceFa
 		curatorFramework: *client,
 	}
 
+	facade.root = client
+	facade.listeners = &curatorListenerContainer{}
+	facade.unhandledErrorListeners = &UnhandledErrorListenerContainer{}
 	facade.namespace = newNamespace(client, namespace)
 	facade.fixForNamespace = facade.namespace.fixForNamespace
 	facade.unfixForNamespace = facade.namespace.unfixForNamespace
@@ -87,20 +187,80 @@ func (f *namespaceFacade) Start() error {
 	return errors.New("the requested operation is not supported")
 }
 
+// Detach the listeners of the facade, the underlying client is left open
 func (f *namespaceFacade) Close() error {
-	return errors.New("the requested operation is not supported")
-}
+	if !f.state.Change(STARTED, STOPPED) {
+		return nil
+	}
+
+	f.root.namespaceFacadeCache.remove(f)
+
+	evt := &curatorEvent{eventType: CLOSING}
+
+	f.listeners.ForEach(func(listener interface{}) {
+		listener.(CuratorListener).EventReceived(f, evt)
+	})
 
-func (f *namespaceFacade) CuratorListenable() CuratorListenable {
-	f.logError(errors.New("CuratorListenable() is only available from a non-namespaced CuratorFramework instance"))
+	f.listeners.Clear()
+	f.unhandledErrorListeners.Clear()
 
-	return f.curatorFramework.listeners
+	return nil
 }
 
 func (f *namespaceFacade) Namespace() string {
 	return f.namespace.namespace
 }
 
+// Returns a facade using the namespace nested in the current one, or no namespace if newNamespace is empty.
+func (f *namespaceFacade) UsingNamespace(newNamespace string) CuratorFramework {
+	if len(newNamespace) == 0 || len(f.namespace.namespace) == 0 {
+		return f.root.UsingNamespace(newNamespace)
+	}
+
+	return f.root.UsingNamespace(JoinPath(f.namespace.namespace, newNamespace)[1:])
+}
+
+// Deliver the event of the client to the listeners of the facade if it's within the namespace
+func (f *namespaceFacade) processEvent(event CuratorEvent) {
+	if f.listeners.Len() == 0 {
+		return
+	}
+
+	path := event.Path()
+
+	if watchedEvent := event.WatchedEvent(); watchedEvent != nil {
+		path = watchedEvent.Path
+	}
+
+	if len(path) > 0 {
+		if !f.namespace.contains(path) {
+			return
+		}
+
+		if e, ok := event.(*curatorEvent); ok {
+			evt := *e
+			evt.path = f.unfixForNamespace(path)
+
+			if e.watchedEvent != nil {
+				watchedEvent := *e.watchedEvent
+				watchedEvent.Path = evt.path
+				evt.watchedEvent = &watchedEvent
+			}
+
+			event = &evt
+		}
+	}
+
+	f.listeners.ForEach(func(l interface{}) {
+		tracer := f.client.StartTracer("EventListener")
+		defer tracer.Commit()
+
+		if err := l.(CuratorListener).EventReceived(f, event); err != nil {
+			f.logError(fmt.Errorf("Event listener threw exception, %s", err))
+		}
+	})
+}
+
 type namespaceFacadeCache struct {
 	client *curatorFramework
 	cache  map[string]*namespaceFacade
@@ -128,3 +288,25 @@ func (c *namespaceFacadeCache) Get(namespace string) *namespaceFacade {
 
 	return facade
 }
+
+func (c *namespaceFacadeCache) remove(facade *namespaceFacade) {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+
+	if c.cache[facade.namespace.namespace] == facade {
+		delete(c.cache, facade.namespace.namespace)
+	}
+}
+
+func (c *namespaceFacadeCache) facades() []*namespaceFacade {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+
+	facades := make([]*namespaceFacade, 0, len(c.cache))
+
+	for _, facade := range c.cache {
+		facades = append(facades, facade)
+	}
+
+	return facades
+}
@@ -0,0 +1,142 @@
+package curator
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/suite"
+	"github.com/yxdrlitao/go-zookeeper/zk"
+)
+
+func TestUnfixForNamespace(t *testing.T) {
+	n := &namespaceImpl{namespace: "app"}
+
+	assert.Equal(t, "/node", n.unfixForNamespace("/app/node"))
+	assert.Equal(t, "/", n.unfixForNamespace("/app"))
+	assert.True(t, n.contains("/app/node"))
+	assert.False(t, n.contains("/application"))
+	assert.True(t, (&namespaceImpl{}).contains("/other"))
+}
+
+type NamespaceFacadeTestSuite struct {
+	mockContainerTestSuite
+}
+
+func TestNamespaceFacade(t *testing.T) {
+	suite.Run(t, new(NamespaceFacadeTestSuite))
+}
+
+func (s *NamespaceFacadeTestSuite) TestNested() {
+	s.With(func(client CuratorFramework, conn *mockConn, data []byte, stat *zk.Stat) {
+		app := client.UsingNamespace("app")
+		nested := app.UsingNamespace("service")
+
+		s.Equal("app", app.Namespace())
+		s.Equal("app/service", nested.Namespace())
+		s.Equal(nested, client.UsingNamespace("app/service"), "cached facade")
+		s.Equal("", nested.UsingNamespace("").Namespace())
+		s.Equal("", app.NonNamespaceView().Namespace())
+
+		conn.On("Exists", "/app").Return(true, nil, nil).Once()
+		conn.On("Exists", "/app/service").Return(true, nil, nil).Once()
+		conn.On("Get", "/app/service/node").Return(data, stat, nil).Once()
+
+		data2, err := nested.GetData().ForPath("/node")
+
+		s.NoError(err)
+		s.Equal(data, data2)
+	})
+}
+
+func (s *NamespaceFacadeTestSuite) TestEnsureNamespace() {
+	s.WithNamespace("parent", func(client CuratorFramework, conn *mockConn, data []byte, stat *zk.Stat) {
+		// the failure is returned and retried next time
+		conn.On("Exists", "/parent").Return(false, nil, zk.ErrAPIError).Once()
+
+		_, err := client.GetData().ForPath("/node")
+
+		s.True(errors.Is(err, zk.ErrAPIError))
+
+		conn.On("Exists", "/parent").Return(false, nil, nil).Once()
+		conn.On("Create", "/parent", []byte{}, int32(PERSISTENT), OPEN_ACL_UNSAFE).Return("/parent", nil).Once()
+		conn.On("Get", "/parent/node").Return(data, stat, nil).Once()
+
+		_, err = client.GetData().ForPath("/node")
+
+		s.NoError(err)
+
+		// ensure again after the root is deleted
+		conn.On("Create", "/parent/node", data, int32(PERSISTENT), OPEN_ACL_UNSAFE).Return("", zk.ErrNoNode).Once()
+		conn.On("Exists", "/parent").Return(false, nil, nil).Twice()
+		conn.On("Create", "/parent", []byte{}, int32(PERSISTENT), OPEN_ACL_UNSAFE).Return("/parent", nil).Once()
+		conn.On("Create", "/parent/node", data, int32(PERSISTENT), OPEN_ACL_UNSAFE).Return("/parent/node", nil).Once()
+
+		path, err := client.Create().WithACL(OPEN_ACL_UNSAFE...).ForPathWithData("/node", data)
+
+		s.NoError(err)
+		s.Equal("/node", path)
+	})
+}
+
+func (s *NamespaceFacadeTestSuite) TestListeners() {
+	s.With(func(client CuratorFramework, events chan zk.Event) {
+		received := make(chan string, 10)
+
+		listener := func(name string) CuratorListener {
+			return NewCuratorListener(func(c CuratorFramework, event CuratorEvent) error {
+				received <- name + ":" + event.Type().String() + ":" + event.Path()
+
+				return nil
+			})
+		}
+
+		app := client.UsingNamespace("app")
+		other := client.UsingNamespace("other")
+
+		s.False(client.CuratorListenable() == app.CuratorListenable())
+
+		app.CuratorListenable().AddListener(listener("app"))
+		other.CuratorListenable().AddListener(listener("other"))
+
+		events <- zk.Event{Type: zk.EventNodeDataChanged, State: zk.StateHasSession, Path: "/application/node"}
+		events <- zk.Event{Type: zk.EventNodeDataChanged, State: zk.StateHasSession, Path: "/app/node"}
+
+		s.Equal("app:WATCHED:/node", <-received)
+
+		s.NoError(other.Close())
+		s.NoError(other.Close(), "closed")
+		s.Equal(STOPPED, other.State())
+		s.Equal("other:CLOSING:", <-received)
+		s.False(other == client.UsingNamespace("other"), "closed facade is detached")
+
+		events <- zk.Event{Type: zk.EventNodeDataChanged, State: zk.StateHasSession, Path: "/other/node"}
+		events <- zk.Event{Type: zk.EventNodeDataChanged, State: zk.StateHasSession, Path: "/app/last"}
+
+		s.Equal("app:WATCHED:/last", <-received)
+		s.Empty(received)
+	})
+}
+
+func (s *NamespaceFacadeTestSuite) TestUnhandledErrorListeners() {
+	s.With(func(client CuratorFramework) {
+		var errs []string
+
+		app := client.UsingNamespace("app").(*namespaceFacade)
+		other := client.UsingNamespace("other")
+
+		client.UnhandledErrorListenable().AddListener(NewUnhandledErrorListener(func(err error) {
+			errs = append(errs, "client:"+err.Error())
+		}))
+		app.UnhandledErrorListenable().AddListener(NewUnhandledErrorListener(func(err error) {
+			errs = append(errs, "app:"+err.Error())
+		}))
+		other.UnhandledErrorListenable().AddListener(NewUnhandledErrorListener(func(err error) {
+			errs = append(errs, "other:"+err.Error())
+		}))
+
+		app.logError(errors.New("failed"))
+
+		s.Equal([]string{"app:failed", "client:failed"}, errs)
+	})
+}
@@ -2,6 +2,7 @@ package curator
 
 import (
 	"bytes"
+	"context"
 	"errors"
 	"fmt"
 	"strings"
@@ -12,7 +13,8 @@ import (
 )
 
 const (
-	PATH_SEPARATOR = "/"
+	PATH_SEPARATOR        = "/"
+	ZOOKEEPER_SYSTEM_NODE = "/zookeeper" // the node of the quotas and configuration of ZooKeeper
 )
 
 type PathAndNode struct {
@@ -138,6 +140,16 @@ func ValidatePath(path string) error {
 
 // Make sure all the nodes in the path are created
 func MakeDirs(conn ZookeeperConnection, path string, makeLastNode bool, aclProvider ACLProvider) error {
+	return makeDirs(conn, path, makeLastNode, aclProvider, false)
+}
+
+// Make sure all the nodes in the path are created as containers,
+// which fall back to persistent nodes if the server doesn't support them
+func MakeContainerDirs(conn ZookeeperConnection, path string, makeLastNode bool, aclProvider ACLProvider) error {
+	return makeDirs(conn, path, makeLastNode, aclProvider, true)
+}
+
+func makeDirs(conn ZookeeperConnection, path string, makeLastNode bool, aclProvider ACLProvider, asContainers bool) error {
 	if err := ValidatePath(path); err != nil {
 		return err
 	}
@@ -172,7 +184,21 @@ func MakeDirs(conn ZookeeperConnection, path string, makeLastNode bool, aclProvi
 				acls = OPEN_ACL_UNSAFE
 			}
 
-			if _, err := conn.Create(subPath, []byte{}, int32(PERSISTENT), acls); err != nil && err != zk.ErrNodeExists {
+			mode := PERSISTENT
+
+			if asContainers {
+				mode = CONTAINER
+			}
+
+			_, err := conn.Create(subPath, []byte{}, int32(mode), acls)
+
+			if err == zk.ErrBadArguments && asContainers {
+				asContainers = false
+
+				_, err = conn.Create(subPath, []byte{}, int32(PERSISTENT), acls)
+			}
+
+			if err != nil && err != zk.ErrNodeExists {
 				return err
 			}
 		}
@@ -215,17 +241,35 @@ func DeleteChildren(conn ZookeeperConnection, path string, deleteSelf bool) erro
 
 type EnsurePath interface {
 	// First time, synchronizes and makes sure all nodes in the path are created.
-	// Subsequent calls with this instance are NOPs.
+	// Subsequent calls with this instance are NOPs once it succeeded, the failures are retried next time.
 	Ensure(client CuratorZookeeperClient) error
 
+	// Same as Ensure, but gives up once the context is done
+	EnsureWithContext(ctx context.Context, client CuratorZookeeperClient) error
+
 	// Returns a view of this EnsurePath instance that does not make the last node.
 	ExcludingLast() EnsurePath
+
+	// Returns a view of this EnsurePath instance that creates the nodes as containers,
+	// which fall back to persistent nodes if the server doesn't support them
+	UsingContainers() EnsurePath
+
+	// Returns a view of this EnsurePath instance that uses the ACL provider to get the ACLs of each level of the path
+	WithACLProvider(aclProvider ACLProvider) EnsurePath
 }
 
 type EnsurePathHelper interface {
 	Ensure(client CuratorZookeeperClient, path string, makeLastNode bool) error
 }
 
+// The optional interface of EnsurePathHelper to ensure the path with the context and the settings of the EnsurePath view,
+// otherwise the helper is asked to Ensure() the path once the context is checked.
+type ContextEnsurePathHelper interface {
+	EnsurePathHelper
+
+	EnsureWithContext(ctx context.Context, client CuratorZookeeperClient, path string, makeLastNode bool, aclProvider ACLProvider, asContainers bool) error
+}
+
 type ensurePathHelper struct {
 	owner   *ensurePath
 	lock    sync.Mutex
@@ -233,28 +277,41 @@ type ensurePathHelper struct {
 }
 
 func (h *ensurePathHelper) Ensure(client CuratorZookeeperClient, path string, makeLastNode bool) error {
+	return h.EnsureWithContext(context.Background(), client, path, makeLastNode, h.owner.aclProvider, h.owner.asContainers)
+}
+
+func (h *ensurePathHelper) EnsureWithContext(ctx context.Context, client CuratorZookeeperClient, path string, makeLastNode bool, aclProvider ACLProvider, asContainers bool) error {
 	h.lock.Lock()
 	defer h.lock.Unlock()
 
-	if !h.started {
-		_, err := client.NewRetryLoop().CallWithRetry(func() (interface{}, error) {
-			if conn, err := client.Conn(); err != nil {
-				return nil, err
-			} else if err := MakeDirs(conn, path, makeLastNode, h.owner.aclProvider); err != nil {
-				return nil, err
-			} else {
-				return nil, nil
-			}
-		})
+	if h.started {
+		return nil
+	}
 
-		h.started = true
+	_, err := client.NewRetryLoop().CallWithRetry(func() (interface{}, error) {
+		if err := ctx.Err(); err != nil {
+			return nil, err
+		} else if conn, err := client.Conn(); err != nil {
+			return nil, err
+		} else if err := makeDirs(conn, path, makeLastNode, aclProvider, asContainers); err != nil {
+			return nil, err
+		} else {
+			return nil, nil
+		}
+	})
 
-		h.owner.helper = nil
+	// only remember the success, so the failure is retried next time
+	h.started = err == nil
 
-		return err
-	}
+	return err
+}
 
-	return nil
+// Ensure the path again next time, e.g. after the nodes were deleted
+func (h *ensurePathHelper) reset() {
+	h.lock.Lock()
+	defer h.lock.Unlock()
+
+	h.started = false
 }
 
 // Utility to ensure that a particular path is created.
@@ -262,7 +319,9 @@ type ensurePath struct {
 	path         string
 	aclProvider  ACLProvider
 	makeLastNode bool
+	asContainers bool
 	helper       EnsurePathHelper
+	namespace    *namespaceImpl // ensured before the path
 }
 
 func NewEnsurePath(path string) *ensurePath {
@@ -289,18 +348,51 @@ func NewEnsurePathWithAclAndHelper(path string, aclProvider ACLProvider, helper
 	return p
 }
 
+// The views share the helper, so the path is ensured only once by any of them
 func (p *ensurePath) ExcludingLast() EnsurePath {
-	return &ensurePath{
-		path:         p.path,
-		aclProvider:  p.aclProvider,
-		makeLastNode: false,
-		helper:       p.helper,
-	}
+	view := *p
+	view.makeLastNode = false
+
+	return &view
+}
+
+func (p *ensurePath) UsingContainers() EnsurePath {
+	view := *p
+	view.asContainers = true
+
+	return &view
+}
+
+func (p *ensurePath) WithACLProvider(aclProvider ACLProvider) EnsurePath {
+	view := *p
+	view.aclProvider = aclProvider
+
+	return &view
 }
 
 func (p *ensurePath) Ensure(client CuratorZookeeperClient) error {
-	if p.helper != nil {
-		return p.helper.Ensure(client, p.path, p.makeLastNode)
+	return p.EnsureWithContext(context.Background(), client)
+}
+
+func (p *ensurePath) EnsureWithContext(ctx context.Context, client CuratorZookeeperClient) error {
+	if p.namespace != nil {
+		if err := p.namespace.ensure(ctx); err != nil {
+			return err
+		}
+	}
+
+	if helper, ok := p.helper.(ContextEnsurePathHelper); ok {
+		return helper.EnsureWithContext(ctx, client, p.path, p.makeLastNode, p.aclProvider, p.asContainers)
+	} else if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	return p.helper.Ensure(client, p.path, p.makeLastNode)
+}
+
+// Ensure the path again next time
+func (p *ensurePath) reset() {
+	if h, ok := p.helper.(*ensurePathHelper); ok {
+		h.reset()
 	}
-	return nil
 }
@@ -1,6 +1,7 @@
 package curator
 
 import (
+	"context"
 	"testing"
 
 	"github.com/stretchr/testify/assert"
@@ -118,6 +119,19 @@ func TestMakeDirs(t *testing.T) {
 
 	conn.AssertExpectations(t)
 	acls.AssertExpectations(t)
+
+	// fall back to persistent nodes if containers are not supported
+	conn = &mockConn{}
+
+	conn.On("Exists", "/parent").Return(false, nil, nil).Once()
+	conn.On("Create", "/parent", []byte{}, int32(CONTAINER), OPEN_ACL_UNSAFE).Return("", zk.ErrBadArguments).Once()
+	conn.On("Create", "/parent", []byte{}, int32(PERSISTENT), OPEN_ACL_UNSAFE).Return("/parent", nil).Once()
+	conn.On("Exists", "/parent/child").Return(false, nil, nil).Once()
+	conn.On("Create", "/parent/child", []byte{}, int32(PERSISTENT), OPEN_ACL_UNSAFE).Return("/parent/child", nil).Once()
+
+	assert.NoError(t, MakeContainerDirs(conn, "/parent/child/node", false, nil))
+
+	conn.AssertExpectations(t)
 }
 
 func TestDeleteChildren(t *testing.T) {
@@ -169,3 +183,28 @@ func TestEnsurePath(t *testing.T) {
 	helper.AssertExpectations(t)
 	client.AssertExpectations(t)
 }
+
+func TestEnsurePathWithContext(t *testing.T) {
+	helper := &mockContextEnsurePathHelper{mockEnsurePathHelper{log: t.Logf}}
+	client := &mockCuratorZookeeperClient{log: t.Logf}
+	ensure := NewEnsurePathWithAclAndHelper("/parent/child", nil, helper)
+	ctx := context.Background()
+
+	// the views pass their own settings to the helper, without changing the original one
+	aclProvider := NewDefaultACLProvider()
+
+	helper.On("EnsureWithContext", ctx, client, "/parent/child", false, aclProvider, true).Return(nil).Once()
+
+	assert.NoError(t, ensure.ExcludingLast().UsingContainers().WithACLProvider(aclProvider).EnsureWithContext(ctx, client))
+	assert.False(t, ensure.asContainers)
+	assert.Nil(t, ensure.aclProvider)
+
+	// the helper without the context is never called once the context is done
+	ctx, cancel := context.WithCancel(ctx)
+	cancel()
+
+	assert.Equal(t, context.Canceled, NewEnsurePathWithAclAndHelper("/parent/child", nil, &mockEnsurePathHelper{log: t.Logf}).EnsureWithContext(ctx, client))
+
+	helper.AssertExpectations(t)
+	client.AssertExpectations(t)
+}
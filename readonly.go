@@ -0,0 +1,160 @@
+package curator
+
+import (
+	"bufio"
+	"errors"
+	"net"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	DEFAULT_READ_WRITE_PROBE_INTERVAL = 10 * time.Second
+	READ_WRITE_PROBE_TIMEOUT          = 3 * time.Second
+)
+
+// The write is rejected as the connection is read-only, see CuratorFrameworkBuilder.CanBeReadOnly
+var ErrReadOnly = errors.New("the connection is read-only")
+
+// Whether the state allows to write, a read-only connection is connected but not writable
+func (s ConnectionState) Writable() bool {
+	return s == CONNECTED || s == RECONNECTED
+}
+
+// Fail the write with ErrReadOnly if the connection is read-only,
+// or wait for a read-write connection if CuratorFrameworkBuilder.WaitForReadWrite() is set
+func (c *curatorFramework) ensureWritable() error {
+	if !c.ReadOnly() {
+		return nil
+	}
+
+	if c.readWriteWaitTime > 0 {
+		if err := c.stateManager.blockUntil(c.readWriteWaitTime, ConnectionState.Writable); err == nil {
+			return nil
+		}
+	}
+
+	return ErrReadOnly
+}
+
+// Ask the server whether it's serving read-only with the `isro` four letter word
+func IsReadWriteServer(server string, timeout time.Duration) (bool, error) {
+	conn, err := net.DialTimeout("tcp", server, timeout)
+
+	if err != nil {
+		return false, err
+	}
+
+	defer conn.Close()
+
+	if err := conn.SetDeadline(time.Now().Add(timeout)); err != nil {
+		return false, err
+	}
+
+	if _, err := conn.Write([]byte("isro")); err != nil {
+		return false, err
+	}
+
+	reply, err := bufio.NewReader(conn).ReadString('\n')
+
+	if err != nil && len(reply) == 0 {
+		return false, err
+	}
+
+	switch strings.TrimSpace(reply) {
+	case "rw":
+		return true, nil
+	case "ro":
+		return false, nil
+	}
+
+	return false, errors.New("unexpected reply of isro: " + reply)
+}
+
+// Probe the servers of the ensemble while the connection is read-only,
+// and reconnect once a read-write server is found, which starts a new session.
+// The dialer picks the server of the new session, so the probe goes on until the connection is writable.
+type readWriteProbe struct {
+	client   *curatorFramework
+	interval time.Duration
+	probe    func(server string) (bool, error)
+	lock     sync.Mutex
+	stop     chan struct{}
+}
+
+func newReadWriteProbe(client *curatorFramework, interval time.Duration) *readWriteProbe {
+	return &readWriteProbe{
+		client:   client,
+		interval: interval,
+		probe: func(server string) (bool, error) {
+			return IsReadWriteServer(server, READ_WRITE_PROBE_TIMEOUT)
+		},
+	}
+}
+
+func (p *readWriteProbe) StateChanged(client CuratorFramework, newState ConnectionState) {
+	p.lock.Lock()
+	defer p.lock.Unlock()
+
+	if newState == READ_ONLY {
+		if p.stop == nil {
+			p.stop = make(chan struct{})
+
+			go p.run(p.stop)
+		}
+	} else if p.stop != nil {
+		close(p.stop)
+
+		p.stop = nil
+	}
+}
+
+func (p *readWriteProbe) Close() {
+	p.StateChanged(p.client, UNKNOWN)
+}
+
+func (p *readWriteProbe) run(stop <-chan struct{}) {
+	ticker := time.NewTicker(p.interval)
+
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-stop:
+			return
+		case <-ticker.C:
+			// wait for the session of the reconnection, which may land on a read-only server again
+			if !p.client.client.Connected() {
+				continue
+			}
+
+			if server := p.findReadWriteServer(p.client.client.CurrentConnectionString()); len(server) > 0 {
+				p.client.client.state.tracer.AddCount("read-write-probe-found", 1)
+
+				if err := p.client.client.state.reset(); err != nil {
+					p.client.logError(err)
+				}
+			}
+		}
+	}
+}
+
+func (p *readWriteProbe) findReadWriteServer(connString string) string {
+	for _, server := range strings.Split(connString, ",") {
+		if server = strings.TrimSpace(server); len(server) == 0 {
+			continue
+		}
+
+		// skip the chroot suffix of the connection string
+		if i := strings.Index(server, "/"); i >= 0 {
+			server = server[:i]
+		}
+
+		if rw, err := p.probe(server); err == nil && rw {
+			return server
+		}
+	}
+
+	return ""
+}
@@ -0,0 +1,211 @@
+package curator
+
+import (
+	"net"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/suite"
+	"github.com/yxdrlitao/go-zookeeper/zk"
+)
+
+func TestFindReadWriteServer(t *testing.T) {
+	var probed []string
+
+	p := &readWriteProbe{probe: func(server string) (bool, error) {
+		probed = append(probed, server)
+
+		return server == "host2:2181", nil
+	}}
+
+	assert.Equal(t, "host2:2181", p.findReadWriteServer("host1:2181, host2:2181,host3:2181/chroot"))
+	assert.Equal(t, []string{"host1:2181", "host2:2181"}, probed)
+	assert.Equal(t, "", p.findReadWriteServer("host3:2181/chroot"))
+	assert.Equal(t, "host3:2181", probed[2])
+}
+
+func TestConnectionStateWritable(t *testing.T) {
+	assert.True(t, CONNECTED.Writable())
+	assert.True(t, RECONNECTED.Writable())
+	assert.True(t, READ_ONLY.Connected())
+	assert.False(t, READ_ONLY.Writable())
+	assert.False(t, SUSPENDED.Writable())
+}
+
+func TestIsReadWriteServer(t *testing.T) {
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+
+	assert.NoError(t, err)
+
+	defer l.Close()
+
+	replies := make(chan string, 2)
+
+	replies <- "rw"
+	replies <- "ro"
+
+	go func() {
+		for reply := range replies {
+			if conn, err := l.Accept(); err == nil {
+				buf := make([]byte, 4)
+
+				if _, err := conn.Read(buf); err == nil && string(buf) == "isro" {
+					conn.Write([]byte(reply))
+				}
+
+				conn.Close()
+			}
+		}
+	}()
+
+	rw, err := IsReadWriteServer(l.Addr().String(), time.Second)
+
+	assert.NoError(t, err)
+	assert.True(t, rw)
+
+	rw, err = IsReadWriteServer(l.Addr().String(), time.Second)
+
+	assert.NoError(t, err)
+	assert.False(t, rw)
+
+	close(replies)
+}
+
+type ReadOnlyTestSuite struct {
+	mockContainerTestSuite
+}
+
+func TestReadOnly(t *testing.T) {
+	suite.Run(t, new(ReadOnlyTestSuite))
+}
+
+func (s *ReadOnlyTestSuite) readOnly(builder *CuratorFrameworkBuilder) {
+	builder.CanBeReadOnly = true
+}
+
+func (s *ReadOnlyTestSuite) changeState(client CuratorFramework, events chan zk.Event, state zk.State, readOnly bool) {
+	events <- zk.Event{Type: zk.EventSession, State: state}
+
+	s.Eventually(func() bool { return client.ReadOnly() == readOnly }, time.Second, time.Millisecond)
+}
+
+func (s *ReadOnlyTestSuite) TestWrites() {
+	s.WithPrepare(s.readOnly, func(client CuratorFramework, conn *mockConn, ensembleProvider *mockEnsembleProvider, events chan zk.Event, acls []zk.ACL, data []byte, stat *zk.Stat) {
+		ensembleProvider.On("ConnectionString").Return("connStr")
+
+		s.False(client.ReadOnly())
+
+		s.changeState(client, events, zk.StateConnectedReadOnly, true)
+
+		_, err := client.Create().WithACL(acls...).ForPathWithData("/node", data)
+
+		s.Equal(ErrReadOnly, err)
+
+		_, err = client.SetData().ForPathWithData("/node", data)
+
+		s.Equal(ErrReadOnly, err)
+		s.Equal(ErrReadOnly, client.Delete().ForPath("/node"))
+
+		_, err = client.SetACL().WithACL(acls...).ForPath("/node")
+
+		s.Equal(ErrReadOnly, err)
+
+		_, err = client.InTransaction().Delete().ForPath("/node").Commit()
+
+		s.Equal(ErrReadOnly, err)
+
+		// the reads are served but may be stale
+		conn.On("Get", "/node").Return(data, stat, nil).Once()
+
+		var stale bool
+
+		data2, err := client.GetData().StoringStaleIn(&stale).ForPath("/node")
+
+		s.NoError(err)
+		s.Equal(data, data2)
+		s.True(stale)
+
+		s.changeState(client, events, zk.StateConnected, false)
+
+		conn.On("Exists", "/node").Return(true, stat, nil).Once()
+
+		_, err = client.CheckExists().StoringStaleIn(&stale).ForPath("/node")
+
+		s.NoError(err)
+		s.False(stale)
+	})
+}
+
+func (s *ReadOnlyTestSuite) TestWaitForReadWrite() {
+	s.WithPrepare(func(builder *CuratorFrameworkBuilder) {
+		s.readOnly(builder)
+		builder.WaitForReadWrite(time.Second)
+	}, func(client CuratorFramework, conn *mockConn, ensembleProvider *mockEnsembleProvider, events chan zk.Event) {
+		ensembleProvider.On("ConnectionString").Return("connStr")
+
+		s.changeState(client, events, zk.StateConnectedReadOnly, true)
+
+		conn.On("Delete", "/node", int32(AnyVersion)).Return(nil).Once()
+
+		go func() {
+			time.Sleep(50 * time.Millisecond)
+
+			events <- zk.Event{Type: zk.EventSession, State: zk.StateConnected}
+		}()
+
+		s.NoError(client.Delete().ForPath("/node"))
+	})
+}
+
+func (s *ReadOnlyTestSuite) TestProbe() {
+	s.WithPrepare(func(builder *CuratorFrameworkBuilder) {
+		builder.EnableReadOnly(10 * time.Millisecond)
+	}, func(client CuratorFramework, conn *mockConn, dialer *mockZookeeperDialer, ensembleProvider *mockEnsembleProvider, events chan zk.Event) {
+		s.True(client.(*curatorFramework).client.state.zooKeeper.canBeReadOnly)
+
+		probed := make(chan string, 10)
+		found := false
+
+		// only the first probe finds a read-write server
+		client.(*curatorFramework).readWriteProbe.probe = func(server string) (bool, error) {
+			probed <- server
+
+			rw := !found
+			found = true
+
+			return rw, nil
+		}
+
+		reconnected := make(chan struct{})
+
+		ensembleProvider.On("ConnectionString").Return("connStr")
+		conn.On("Close").Return().Once()
+		dialer.On("Dial", "connStr", DEFAULT_SESSION_TIMEOUT, true).Return(conn, nil, nil).Run(func(args mock.Arguments) {
+			close(reconnected)
+		}).Once()
+
+		s.changeState(client, events, zk.StateConnectedReadOnly, true)
+
+		select {
+		case <-reconnected:
+		case <-time.After(time.Second):
+			s.Fail("not reconnected")
+		}
+
+		s.Equal("connStr", <-probed)
+
+		// the new session is read-only again, keep probing
+		events <- zk.Event{Type: zk.EventSession, State: zk.StateConnectedReadOnly}
+
+		select {
+		case server := <-probed:
+			s.Equal("connStr", server)
+		case <-time.After(time.Second):
+			s.Fail("not probed after reconnection")
+		}
+
+		s.changeState(client, events, zk.StateConnected, false)
+	})
+}
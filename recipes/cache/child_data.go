@@ -1,6 +1,9 @@
 package cache
 
-import "github.com/yxdrlitao/go-zookeeper/zk"
+import (
+	"github.com/yxdrlitao/curator"
+	"github.com/yxdrlitao/go-zookeeper/zk"
+)
 
 // ChildData contains data of a node including: stat, data, path
 type ChildData struct {
@@ -37,3 +40,8 @@ func (cd ChildData) Stat() *zk.Stat {
 func (cd ChildData) Data() []byte {
 	return cd.data
 }
+
+// DecodeInto decodes the node data into v with the codec, returns *curator.CodecError with the path if failed
+func (cd ChildData) DecodeInto(codec curator.Codec, v interface{}) error {
+	return curator.DecodeData(codec, cd.path, cd.data, v)
+}
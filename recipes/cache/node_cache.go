@@ -122,6 +122,21 @@ func (c *NodeCache) NodeCacheListenable() NodeCacheListenable {
 	return c.listeners
 }
 
+// Return the current data, or nil if the node doesn't exist.
+// There are no guarantees of accuracy. This is merely the most recent view of the data.
+func (c *NodeCache) CurrentData() *ChildData {
+	return (*ChildData)(atomic.LoadPointer((*unsafe.Pointer)(unsafe.Pointer(&c.data))))
+}
+
+// Decode the current data into v with the codec of the client, return zk.ErrNoNode if the node doesn't exist
+func (c *NodeCache) CurrentDataInto(v interface{}) error {
+	if data := c.CurrentData(); data != nil {
+		return data.DecodeInto(c.client.Codec(), v)
+	}
+
+	return zk.ErrNoNode
+}
+
 func (c *NodeCache) internalRebuild() error {
 	var stat zk.Stat
 
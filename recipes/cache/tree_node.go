@@ -84,8 +84,12 @@ func (tn *TreeNode) RemoveChild(path string) {
 	delete(tn.children, path)
 }
 
+func (tn *TreeNode) traverseChildren() bool {
+	return (tn.depth < tn.tree.maxDepth) && tn.tree.selector.TraverseChildren(tn.path)
+}
+
 func (tn *TreeNode) refresh() {
-	if (tn.depth < tn.tree.maxDepth) && tn.tree.selector.TraverseChildren(tn.path) {
+	if tn.traverseChildren() {
 		atomic.AddUint64(&tn.tree.outstandingOps, 2)
 		tn.doRefreshData()
 		tn.doRefreshChildren()
@@ -95,7 +99,7 @@ func (tn *TreeNode) refresh() {
 }
 
 func (tn *TreeNode) refreshChildren() {
-	if (tn.depth < tn.tree.maxDepth) && tn.tree.selector.TraverseChildren(tn.path) {
+	if tn.traverseChildren() {
 		atomic.AddUint64(&tn.tree.outstandingOps, 1)
 		tn.doRefreshChildren()
 	}
@@ -213,6 +217,7 @@ func (tn *TreeNode) processResult(client curator.CuratorFramework, evt curator.C
 			// Present new children in sorted order for test determinism.
 			children := sort.StringSlice(evt.Children())
 			sort.Sort(children)
+			var created []*TreeNode
 			for _, child := range children {
 				if accepted := tn.tree.selector.AcceptChild(path.Join(tn.path, child)); !accepted {
 					continue
@@ -222,48 +227,21 @@ func (tn *TreeNode) processResult(client curator.CuratorFramework, evt curator.C
 					fullPath := path.Join(tn.path, child)
 					node := NewTreeNode(tn.tree, fullPath, tn)
 					tn.children[child] = node
-					node.wasCreated()
+					created = append(created, node)
 				}
 				tn.Unlock()
 			}
-		}
-	case curator.GET_DATA:
-		switch evt.Err() {
-		case zk.ErrNoNode:
-			tn.wasDeleted()
-		case nil:
-			newChildData := NewChildData(evt.Path(), newStat, evt.Data())
-			oldChildData := tn.ChildData()
-			if tn.tree.cacheData {
-				tn.SwapChildData(newChildData)
-			} else {
-				tn.SwapChildData(NewChildData(evt.Path(), newStat, nil))
-			}
-
-			var added bool
-			if tn.parent == nil {
-				// We're the singleton root.
-				added = tn.state.Swap(NodeStateLIVE) != NodeStateLIVE
-			} else {
-				added = tn.state.CompareAndSwap(NodeStatePENDING, NodeStateLIVE)
-				if !added {
-					// Ordinary nodes are not allowed to transition from dead -> live;
-					// make sure this isn't a delayed response that came in after death.
-					if tn.state.Load() != NodeStateLIVE {
-						return nil
-					}
-				}
-			}
-
-			if added {
-				tn.tree.publishEvent(TreeCacheEventNodeAdded, newChildData)
+			if tn.tree.batchLoad && len(created) > 1 {
+				tn.tree.loadNodes(created)
 			} else {
-				if oldChildData == nil || oldChildData.Stat().Mzxid != newStat.Mzxid {
-					tn.tree.publishEvent(TreeCacheEventNodeUpdated, newChildData)
+				for _, node := range created {
+					node.wasCreated()
 				}
 			}
-		default:
-			tn.tree.logger.Printf("Unknown GET_DATA event[%v]: %s", evt.Path(), evt.Err())
+		}
+	case curator.GET_DATA:
+		if !tn.processData(evt.Path(), newStat, evt.Data(), evt.Err()) {
+			return nil
 		}
 	default:
 		// An unknown event, probably an error of some sort like connection loss.
@@ -274,12 +252,49 @@ func (tn *TreeNode) processResult(client curator.CuratorFramework, evt curator.C
 		return nil
 	}
 
-	// Decrease by 1
-	if atomic.AddUint64(&tn.tree.outstandingOps, ^uint64(0)) == 0 {
-		if !tn.tree.isInitialized.IsSet() {
-			tn.tree.isInitialized.Set()
-			tn.tree.publishEvent(TreeCacheEventInitialized, nil)
+	tn.tree.operationCompleted()
+	return nil
+}
+
+// processData processes the result of reading the data of the node,
+// returns false if it's a delayed response that came in after death.
+func (tn *TreeNode) processData(nodePath string, newStat *zk.Stat, data []byte, err error) bool {
+	switch err {
+	case zk.ErrNoNode:
+		tn.wasDeleted()
+	case nil:
+		newChildData := NewChildData(nodePath, newStat, data)
+		oldChildData := tn.ChildData()
+		if tn.tree.cacheData {
+			tn.SwapChildData(newChildData)
+		} else {
+			tn.SwapChildData(NewChildData(nodePath, newStat, nil))
+		}
+
+		var added bool
+		if tn.parent == nil {
+			// We're the singleton root.
+			added = tn.state.Swap(NodeStateLIVE) != NodeStateLIVE
+		} else {
+			added = tn.state.CompareAndSwap(NodeStatePENDING, NodeStateLIVE)
+			if !added {
+				// Ordinary nodes are not allowed to transition from dead -> live;
+				// make sure this isn't a delayed response that came in after death.
+				if tn.state.Load() != NodeStateLIVE {
+					return false
+				}
+			}
+		}
+
+		if added {
+			tn.tree.publishEvent(TreeCacheEventNodeAdded, newChildData)
+		} else {
+			if oldChildData == nil || oldChildData.Stat().Mzxid != newStat.Mzxid {
+				tn.tree.publishEvent(TreeCacheEventNodeUpdated, newChildData)
+			}
 		}
+	default:
+		tn.tree.logger.Printf("Unknown GET_DATA event[%v]: %s", nodePath, err)
 	}
-	return nil
+	return true
 }
@@ -25,6 +25,7 @@ type InterProcessLock interface {
 	Release() error
 
 	// Returns true if the mutex is acquired by a go-routine in this process
+	// and the connection is not read-only, which can't prove the ownership
 	IsAcquiredInThisProcess() bool
 }
 
@@ -149,7 +150,7 @@ func (m *InterProcessMutex) AcquireTimeout(expires time.Duration) (bool, error)
 }
 
 func (m *InterProcessMutex) Release() error {
-	if !m.IsAcquiredInThisProcess() {
+	if !m.isHeld() {
 		return fmt.Errorf("You do not own the lock: %s", m.basePath)
 	}
 
@@ -166,11 +167,20 @@ func (m *InterProcessMutex) Release() error {
 }
 
 func (m *InterProcessMutex) IsAcquiredInThisProcess() bool {
+	return m.isHeld() && !m.internals.client.ReadOnly()
+}
+
+// Whether the lock node is held, regardless of the connection
+func (m *InterProcessMutex) isHeld() bool {
 	return atomic.LoadInt32(&m.lockCount) > 0
 }
 
 func (m *InterProcessMutex) internalLock(expires time.Duration) (bool, error) {
-	if m.IsAcquiredInThisProcess() {
+	if m.isHeld() {
+		if m.internals.client.ReadOnly() {
+			return false, curator.ErrReadOnly
+		}
+
 		// re-entering
 		atomic.AddInt32(&m.lockCount, 1)
 
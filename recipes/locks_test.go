@@ -2,9 +2,11 @@ package recipes
 
 import (
 	"testing"
+	"time"
 
 	. "github.com/smartystreets/goconvey/convey"
 	"github.com/yxdrlitao/curator"
+	"github.com/yxdrlitao/go-zookeeper/zk"
 )
 
 func TestLockInternalsDriver(t *testing.T) {
@@ -144,3 +146,47 @@ func TestInterProcessMutex(t *testing.T) {
 
 	})
 }
+
+func TestInterProcessMutexReadOnly(t *testing.T) {
+	Convey("Given an InterProcessMutex of a read-only client", t, func() {
+		mocks := newMockBuilder(t)
+
+		mocks.builder.CanBeReadOnly = true
+
+		client := mocks.Build()
+
+		So(client.Start(), ShouldBeNil)
+
+		mocks.events <- zk.Event{Type: zk.EventSession, State: zk.StateConnectedReadOnly}
+
+		for i := 0; i < 100 && !client.ReadOnly(); i++ {
+			time.Sleep(10 * time.Millisecond)
+		}
+
+		So(client.ReadOnly(), ShouldBeTrue)
+
+		m, err := NewInterProcessMutex(client, "/lock")
+
+		So(err, ShouldBeNil)
+
+		Convey("The lock held before is not owned", func() {
+			m.lockCount = 1
+
+			So(m.IsAcquiredInThisProcess(), ShouldBeFalse)
+
+			locked, err := m.Acquire()
+
+			So(locked, ShouldBeFalse)
+			So(err, ShouldEqual, curator.ErrReadOnly)
+		})
+
+		Convey("The lock can't be acquired", func() {
+			locked, err := m.AcquireTimeout(time.Second)
+
+			So(locked, ShouldBeFalse)
+			So(err, ShouldEqual, curator.ErrReadOnly)
+		})
+
+		mocks.Check(t)
+	})
+}
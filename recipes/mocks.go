@@ -216,6 +216,71 @@ func (c *mockZookeeperConnection) Sync(path string) (string, error) {
 	return path, err
 }
 
+func (c *mockZookeeperConnection) IncrementalReconfig(joining, leaving []string, version int64) (*zk.Stat, error) {
+	args := c.Called(joining, leaving, version)
+
+	stat, _ := args.Get(0).(*zk.Stat)
+	err := args.Error(1)
+
+	if c.log != nil {
+		c.log("ZookeeperConnection.IncrementalReconfig(joining=%v, leaving=%v, version=%d)(stat=%v, error=%v)", joining, leaving, version, stat, err)
+	}
+
+	return stat, err
+}
+
+func (c *mockZookeeperConnection) Reconfig(members []string, version int64) (*zk.Stat, error) {
+	args := c.Called(members, version)
+
+	stat, _ := args.Get(0).(*zk.Stat)
+	err := args.Error(1)
+
+	if c.log != nil {
+		c.log("ZookeeperConnection.Reconfig(members=%v, version=%d)(stat=%v, error=%v)", members, version, stat, err)
+	}
+
+	return stat, err
+}
+
+func (c *mockZookeeperConnection) MultiRead(ops ...interface{}) ([]curator.MultiReadResponse, error) {
+	args := c.Called(ops)
+
+	responses, _ := args.Get(0).([]curator.MultiReadResponse)
+	err := args.Error(1)
+
+	if c.log != nil {
+		c.log("ZookeeperConnection.MultiRead(ops=%v)(responses=%v, error=%v)", ops, responses, err)
+	}
+
+	return responses, err
+}
+
+func (c *mockZookeeperConnection) GetEphemerals(prefix string) ([]string, error) {
+	args := c.Called(prefix)
+
+	paths, _ := args.Get(0).([]string)
+	err := args.Error(1)
+
+	if c.log != nil {
+		c.log("ZookeeperConnection.GetEphemerals(prefix=\"%s\")(paths=%v, error=%v)", prefix, paths, err)
+	}
+
+	return paths, err
+}
+
+func (c *mockZookeeperConnection) GetAllChildrenNumber(path string) (int32, error) {
+	args := c.Called(path)
+
+	number, _ := args.Get(0).(int32)
+	err := args.Error(1)
+
+	if c.log != nil {
+		c.log("ZookeeperConnection.GetAllChildrenNumber(path=\"%s\")(number=%d, error=%v)", path, number, err)
+	}
+
+	return number, err
+}
+
 type mockZookeeperDialer struct {
 	mock.Mock
 
@@ -0,0 +1,400 @@
+package curator
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"sync"
+
+	"gopkg.in/yaml.v3"
+)
+
+// Whether a feature is allowed by the schema, the zero value is ALLOWANCE_CAN
+type Allowance int32
+
+const (
+	ALLOWANCE_CAN    Allowance = iota // the feature is allowed
+	ALLOWANCE_MUST                    // the feature is required
+	ALLOWANCE_CANNOT                  // the feature is forbidden
+)
+
+func (a Allowance) String() string {
+	switch a {
+	case ALLOWANCE_CAN:
+		return "can"
+	case ALLOWANCE_MUST:
+		return "must"
+	case ALLOWANCE_CANNOT:
+		return "cannot"
+	}
+
+	return "unknown"
+}
+
+func ParseAllowance(s string) (Allowance, error) {
+	switch strings.ToLower(s) {
+	case "", "can":
+		return ALLOWANCE_CAN, nil
+	case "must":
+		return ALLOWANCE_MUST, nil
+	case "cannot":
+		return ALLOWANCE_CANNOT, nil
+	}
+
+	return ALLOWANCE_CAN, fmt.Errorf("invalid allowance `%s`", s)
+}
+
+func (a Allowance) check(enabled bool, feature string) string {
+	if a == ALLOWANCE_MUST && !enabled {
+		return fmt.Sprintf("must be %s", feature)
+	} else if a == ALLOWANCE_CANNOT && enabled {
+		return fmt.Sprintf("cannot be %s", feature)
+	}
+
+	return ""
+}
+
+// Validate the data written to the paths of a schema
+type DataValidator interface {
+	Validate(path string, data []byte) error
+}
+
+type DataValidatorFunc func(path string, data []byte) error
+
+func (f DataValidatorFunc) Validate(path string, data []byte) error {
+	return f(path, data)
+}
+
+// Create a DataValidator from its config in the schema file
+type DataValidatorFactory func(config string) (DataValidator, error)
+
+var (
+	dataValidatorsLock sync.RWMutex
+	dataValidators     = map[string]DataValidatorFactory{
+		"json":  newJSONValidator,
+		"regex": newRegexValidator,
+	}
+)
+
+// Register a validator used by the schema file, e.g. a JSON Schema validator which compiles the schema from the config
+func RegisterDataValidator(name string, factory DataValidatorFactory) {
+	dataValidatorsLock.Lock()
+	defer dataValidatorsLock.Unlock()
+
+	dataValidators[name] = factory
+}
+
+func newDataValidator(name, config string) (DataValidator, error) {
+	dataValidatorsLock.RLock()
+	factory, exists := dataValidators[name]
+	dataValidatorsLock.RUnlock()
+
+	if !exists {
+		return nil, fmt.Errorf("unknown data validator `%s`", name)
+	}
+
+	return factory(config)
+}
+
+// Validate the data is well-formed JSON
+func newJSONValidator(config string) (DataValidator, error) {
+	return DataValidatorFunc(func(path string, data []byte) error {
+		if !json.Valid(data) {
+			return fmt.Errorf("data is not valid JSON")
+		}
+
+		return nil
+	}), nil
+}
+
+// Validate the data matches the regular expression of the config
+func newRegexValidator(config string) (DataValidator, error) {
+	re, err := regexp.Compile(config)
+
+	if err != nil {
+		return nil, err
+	}
+
+	return DataValidatorFunc(func(path string, data []byte) error {
+		if !re.Match(data) {
+			return fmt.Errorf("data doesn't match `%s`", config)
+		}
+
+		return nil
+	}), nil
+}
+
+// Define the rules of the nodes matched by the exact path or the regular expression
+type Schema struct {
+	Name          string         // the name of the schema, used in the violations
+	Path          string         // the exact full path (including the namespace) of the nodes
+	PathRegex     *regexp.Regexp // or the regular expression matching the full paths of the nodes
+	Documentation string         // the description of the nodes
+	Ephemeral     Allowance      // whether the nodes could be ephemeral
+	Sequential    Allowance      // whether the nodes could be sequential
+	Watched       Allowance      // whether the nodes could be watched, ALLOWANCE_MUST is treated as ALLOWANCE_CAN
+	Validator     DataValidator  // validate the data of the nodes if set
+}
+
+func (s *Schema) String() string {
+	if len(s.Name) > 0 {
+		return s.Name
+	} else if s.PathRegex != nil {
+		return s.PathRegex.String()
+	}
+
+	return s.Path
+}
+
+// The error returned when an operation violates the schema
+type SchemaViolationError struct {
+	Schema    *Schema // the schema violated, nil if no schema matches the path of a strict SchemaSet
+	Path      string  // the full path of the node
+	Violation string  // the description of the violation
+}
+
+func (e *SchemaViolationError) Error() string {
+	if e.Schema == nil {
+		return fmt.Sprintf("schema violation of %s: %s", e.Path, e.Violation)
+	}
+
+	return fmt.Sprintf("schema violation of %s (%s): %s", e.Path, e.Schema, e.Violation)
+}
+
+// A set of schemas registered with CuratorFrameworkBuilder.Schemas(), which is enforced by
+// create, setData and transactions, and by getData, getChildren and checkExists with watches.
+//
+// The schema of exact path wins, then the first schema whose regular expression matches the path.
+type SchemaSet struct {
+	paths   map[string]*Schema
+	regexes []*Schema
+	strict  bool
+}
+
+func NewSchemaSet(schemas ...*Schema) *SchemaSet {
+	s := &SchemaSet{paths: make(map[string]*Schema)}
+
+	for _, schema := range schemas {
+		if schema.PathRegex != nil {
+			s.regexes = append(s.regexes, schema)
+		} else {
+			s.paths[schema.Path] = schema
+		}
+	}
+
+	return s
+}
+
+// Reject the operations on the paths without a matched schema
+func (s *SchemaSet) Strict() *SchemaSet {
+	s.strict = true
+
+	return s
+}
+
+// Return the schema of the full path, or nil if no schema matches
+func (s *SchemaSet) GetSchema(path string) *Schema {
+	if schema, exists := s.paths[path]; exists {
+		return schema
+	}
+
+	for _, schema := range s.regexes {
+		if schema.PathRegex.MatchString(path) {
+			return schema
+		}
+	}
+
+	return nil
+}
+
+func (s *SchemaSet) schemaFor(path string) (*Schema, error) {
+	schema := s.GetSchema(path)
+
+	if schema == nil && s.strict {
+		return nil, &SchemaViolationError{Path: path, Violation: "no schema matches the path"}
+	}
+
+	return schema, nil
+}
+
+// Validate the create mode and the data of the node to create
+func (s *SchemaSet) ValidateCreate(path string, mode CreateMode, data []byte) error {
+	if s == nil {
+		return nil
+	}
+
+	schema, err := s.schemaFor(path)
+
+	if schema == nil {
+		return err
+	}
+
+	if violation := schema.Ephemeral.check(mode.IsEphemeral(), "ephemeral"); len(violation) > 0 {
+		return &SchemaViolationError{schema, path, violation}
+	}
+
+	if violation := schema.Sequential.check(mode.IsSequential(), "sequential"); len(violation) > 0 {
+		return &SchemaViolationError{schema, path, violation}
+	}
+
+	return s.validateData(schema, path, data)
+}
+
+// Validate the data of the node
+func (s *SchemaSet) ValidateData(path string, data []byte) error {
+	if s == nil {
+		return nil
+	}
+
+	schema, err := s.schemaFor(path)
+
+	if schema == nil {
+		return err
+	}
+
+	return s.validateData(schema, path, data)
+}
+
+func (s *SchemaSet) validateData(schema *Schema, path string, data []byte) error {
+	if schema.Validator != nil {
+		if err := schema.Validator.Validate(path, data); err != nil {
+			return &SchemaViolationError{schema, path, err.Error()}
+		}
+	}
+
+	return nil
+}
+
+// Validate the node could be watched
+func (s *SchemaSet) ValidateWatch(path string) error {
+	if s == nil {
+		return nil
+	}
+
+	schema, err := s.schemaFor(path)
+
+	if schema == nil {
+		return err
+	}
+
+	if schema.Watched == ALLOWANCE_CANNOT {
+		return &SchemaViolationError{schema, path, "cannot be watched"}
+	}
+
+	return nil
+}
+
+// The file format of the schemas
+type schemaSetConfig struct {
+	Strict  bool           `json:"strict" yaml:"strict"`
+	Schemas []schemaConfig `json:"schemas" yaml:"schemas"`
+}
+
+type schemaConfig struct {
+	Name          string           `json:"name" yaml:"name"`
+	Path          string           `json:"path" yaml:"path"`
+	PathRegex     string           `json:"pathRegex" yaml:"pathRegex"`
+	Documentation string           `json:"documentation" yaml:"documentation"`
+	Ephemeral     string           `json:"ephemeral" yaml:"ephemeral"`
+	Sequential    string           `json:"sequential" yaml:"sequential"`
+	Watched       string           `json:"watched" yaml:"watched"`
+	Validator     *validatorConfig `json:"validator" yaml:"validator"`
+}
+
+type validatorConfig struct {
+	Type   string `json:"type" yaml:"type"`
+	Config string `json:"config" yaml:"config"`
+}
+
+func (c *schemaConfig) toSchema() (*Schema, error) {
+	schema := &Schema{Name: c.Name, Path: c.Path, Documentation: c.Documentation}
+
+	if len(c.PathRegex) > 0 {
+		re, err := regexp.Compile(c.PathRegex)
+
+		if err != nil {
+			return nil, err
+		}
+
+		schema.PathRegex = re
+	} else if err := ValidatePath(c.Path); err != nil {
+		return nil, err
+	}
+
+	var err error
+
+	if schema.Ephemeral, err = ParseAllowance(c.Ephemeral); err != nil {
+		return nil, err
+	}
+
+	if schema.Sequential, err = ParseAllowance(c.Sequential); err != nil {
+		return nil, err
+	}
+
+	if schema.Watched, err = ParseAllowance(c.Watched); err != nil {
+		return nil, err
+	}
+
+	if c.Validator != nil {
+		if schema.Validator, err = newDataValidator(c.Validator.Type, c.Validator.Config); err != nil {
+			return nil, err
+		}
+	}
+
+	return schema, nil
+}
+
+// Load the schemas from a YAML (.yaml or .yml) or JSON file, e.g.
+//
+//	strict: true
+//	schemas:
+//	  - name: config
+//	    pathRegex: ^/app/config/[^/]+$
+//	    ephemeral: cannot
+//	    sequential: cannot
+//	    validator: {type: json}
+//	  - name: locks
+//	    pathRegex: ^/app/locks/.+$
+//	    ephemeral: must
+//	    watched: can
+func LoadSchemaSet(filename string) (*SchemaSet, error) {
+	content, err := ioutil.ReadFile(filename)
+
+	if err != nil {
+		return nil, err
+	}
+
+	var config schemaSetConfig
+
+	switch strings.ToLower(filepath.Ext(filename)) {
+	case ".yaml", ".yml":
+		err = yaml.Unmarshal(content, &config)
+	default:
+		err = json.Unmarshal(content, &config)
+	}
+
+	if err != nil {
+		return nil, fmt.Errorf("fail to parse schemas from %s, %s", filename, err)
+	}
+
+	var schemas []*Schema
+
+	for i, c := range config.Schemas {
+		if schema, err := c.toSchema(); err != nil {
+			return nil, fmt.Errorf("invalid schema #%d `%s` in %s, %s", i, c.Name, filename, err)
+		} else {
+			schemas = append(schemas, schema)
+		}
+	}
+
+	set := NewSchemaSet(schemas...)
+
+	if config.Strict {
+		set.Strict()
+	}
+
+	return set, nil
+}
@@ -0,0 +1,220 @@
+package curator
+
+import (
+	"errors"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"regexp"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/suite"
+	"github.com/yxdrlitao/go-zookeeper/zk"
+)
+
+func TestAllowance(t *testing.T) {
+	for s, a := range map[string]Allowance{"": ALLOWANCE_CAN, "can": ALLOWANCE_CAN, "MUST": ALLOWANCE_MUST, "cannot": ALLOWANCE_CANNOT} {
+		allowance, err := ParseAllowance(s)
+
+		assert.NoError(t, err)
+		assert.Equal(t, a, allowance, s)
+	}
+
+	_, err := ParseAllowance("may")
+
+	assert.Error(t, err)
+	assert.Equal(t, "must", ALLOWANCE_MUST.String())
+}
+
+func newTestSchemaSet() *SchemaSet {
+	jsonValidator, _ := newJSONValidator("")
+
+	return NewSchemaSet(
+		&Schema{Name: "config", PathRegex: regexp.MustCompile(`^/app/config/[^/]+$`), Ephemeral: ALLOWANCE_CANNOT, Sequential: ALLOWANCE_CANNOT, Validator: jsonValidator},
+		&Schema{Name: "locks", PathRegex: regexp.MustCompile(`^/app/locks/.+$`), Ephemeral: ALLOWANCE_MUST, Watched: ALLOWANCE_CANNOT},
+		&Schema{Name: "root", Path: "/app/config/root"},
+	)
+}
+
+func TestSchemaSet(t *testing.T) {
+	s := newTestSchemaSet()
+
+	assert.Equal(t, "root", s.GetSchema("/app/config/root").Name, "exact path wins")
+	assert.Equal(t, "config", s.GetSchema("/app/config/db").Name)
+	assert.Nil(t, s.GetSchema("/app/config/db/url"))
+
+	assert.NoError(t, s.ValidateCreate("/app/config/db", PERSISTENT, []byte(`{"url": "..."}`)))
+	assert.NoError(t, s.ValidateCreate("/app/config/root", EPHEMERAL, []byte("anything")))
+	assert.NoError(t, s.ValidateCreate("/other", EPHEMERAL_SEQUENTIAL, nil), "no schema matches")
+
+	err := s.ValidateCreate("/app/config/db", EPHEMERAL, []byte(`{}`))
+
+	assert.EqualError(t, err, "schema violation of /app/config/db (config): cannot be ephemeral")
+	assert.IsType(t, (*SchemaViolationError)(nil), err)
+	assert.Equal(t, "config", err.(*SchemaViolationError).Schema.Name)
+
+	assert.EqualError(t, s.ValidateCreate("/app/config/db", PERSISTENT_SEQUENTIAL, []byte(`{}`)),
+		"schema violation of /app/config/db (config): cannot be sequential")
+	assert.EqualError(t, s.ValidateCreate("/app/config/db", PERSISTENT, []byte(`{`)),
+		"schema violation of /app/config/db (config): data is not valid JSON")
+	assert.EqualError(t, s.ValidateCreate("/app/locks/lock", PERSISTENT, nil),
+		"schema violation of /app/locks/lock (locks): must be ephemeral")
+
+	assert.NoError(t, s.ValidateData("/app/config/db", []byte(`[]`)))
+	assert.Error(t, s.ValidateData("/app/config/db", []byte(`[`)))
+
+	assert.NoError(t, s.ValidateWatch("/app/config/db"))
+	assert.EqualError(t, s.ValidateWatch("/app/locks/lock"), "schema violation of /app/locks/lock (locks): cannot be watched")
+
+	assert.EqualError(t, s.Strict().ValidateData("/other", nil), "schema violation of /other: no schema matches the path")
+
+	var nilSet *SchemaSet
+
+	assert.NoError(t, nilSet.ValidateCreate("/app/locks/lock", PERSISTENT, nil))
+}
+
+func TestLoadSchemaSet(t *testing.T) {
+	dir, err := ioutil.TempDir("", "schema")
+
+	assert.NoError(t, err)
+
+	defer os.RemoveAll(dir)
+
+	RegisterDataValidator("not-empty", func(config string) (DataValidator, error) {
+		return DataValidatorFunc(func(path string, data []byte) error {
+			if len(data) == 0 {
+				return errors.New("data is empty")
+			}
+
+			return nil
+		}), nil
+	})
+
+	yamlFile := filepath.Join(dir, "schema.yaml")
+
+	assert.NoError(t, ioutil.WriteFile(yamlFile, []byte(`
+strict: true
+schemas:
+  - name: config
+    pathRegex: ^/app/config/[^/]+$
+    ephemeral: cannot
+    validator: {type: regex, config: "^[0-9]+$"}
+  - name: leader
+    path: /app/leader
+    watched: cannot
+    validator: {type: not-empty}
+`), 0644))
+
+	s, err := LoadSchemaSet(yamlFile)
+
+	assert.NoError(t, err)
+	assert.NoError(t, s.ValidateData("/app/config/port", []byte("2181")))
+	assert.EqualError(t, s.ValidateData("/app/config/port", []byte("port")), "schema violation of /app/config/port (config): data doesn't match `^[0-9]+$`")
+	assert.EqualError(t, s.ValidateCreate("/app/config/port", EPHEMERAL, []byte("1")), "schema violation of /app/config/port (config): cannot be ephemeral")
+	assert.EqualError(t, s.ValidateData("/app/leader", nil), "schema violation of /app/leader (leader): data is empty")
+	assert.Error(t, s.ValidateWatch("/app/leader"))
+	assert.Error(t, s.ValidateData("/app", nil), "strict")
+
+	jsonFile := filepath.Join(dir, "schema.json")
+
+	for _, content := range []string{
+		`{"schemas": [{"name": "bad", "pathRegex": "["}]}`,
+		`{"schemas": [{"name": "bad", "path": "relative"}]}`,
+		`{"schemas": [{"name": "bad", "path": "/app", "ephemeral": "may"}]}`,
+		`{"schemas": [{"name": "bad", "path": "/app", "validator": {"type": "unknown"}}]}`,
+		`{"schemas": [`,
+	} {
+		assert.NoError(t, ioutil.WriteFile(jsonFile, []byte(content), 0644))
+
+		_, err = LoadSchemaSet(jsonFile)
+
+		assert.Error(t, err, content)
+	}
+
+	_, err = LoadSchemaSet(filepath.Join(dir, "missing.json"))
+
+	assert.True(t, os.IsNotExist(err))
+}
+
+type SchemaSetTestSuite struct {
+	mockContainerTestSuite
+}
+
+func TestSchemaSetWithClient(t *testing.T) {
+	suite.Run(t, new(SchemaSetTestSuite))
+}
+
+func (s *SchemaSetTestSuite) prepare(builder *CuratorFrameworkBuilder) {
+	builder.Schemas(newTestSchemaSet())
+}
+
+func (s *SchemaSetTestSuite) TestCreateAndSetData() {
+	s.WithPrepare(s.prepare, func(client CuratorFramework, conn *mockConn, acls []zk.ACL, stat *zk.Stat) {
+		conn.On("Create", "/app/config/db", []byte(`{}`), int32(PERSISTENT), acls).Return("/app/config/db", nil).Once()
+		conn.On("Set", "/app/config/db", []byte(`[]`), int32(AnyVersion)).Return(stat, nil).Once()
+
+		_, err := client.Create().WithACL(acls...).ForPathWithData("/app/config/db", []byte(`{}`))
+
+		s.NoError(err)
+
+		_, err = client.Create().WithMode(EPHEMERAL).WithACL(acls...).ForPathWithData("/app/config/db", []byte(`{}`))
+
+		s.IsType((*SchemaViolationError)(nil), err)
+
+		_, err = client.SetData().ForPathWithData("/app/config/db", []byte(`[]`))
+
+		s.NoError(err)
+
+		_, err = client.SetData().ForPathWithData("/app/config/db", []byte(`[`))
+
+		s.IsType((*SchemaViolationError)(nil), err)
+	})
+}
+
+func (s *SchemaSetTestSuite) TestNamespace() {
+	s.WithPrepare(func(builder *CuratorFrameworkBuilder) {
+		s.prepare(builder)
+		builder.Namespace = "app"
+	}, func(client CuratorFramework, conn *mockConn) {
+		conn.On("Exists", "/app").Return(true, nil, nil).Once()
+
+		// the schemas match the full path
+		_, err := client.SetData().ForPathWithData("/config/db", []byte(`[`))
+
+		s.EqualError(err, "schema violation of /app/config/db (config): data is not valid JSON")
+	})
+}
+
+func (s *SchemaSetTestSuite) TestTransaction() {
+	s.WithPrepare(s.prepare, func(client CuratorFramework, conn *mockConn, acls []zk.ACL) {
+		_, err := client.InTransaction().
+			Create().WithACL(acls...).ForPathWithData("/app/locks/lock", nil).
+			SetData().ForPathWithData("/app/config/db", []byte(`[`)).
+			Commit()
+
+		s.EqualError(err, "schema violation of /app/locks/lock (locks): must be ephemeral")
+	})
+}
+
+func (s *SchemaSetTestSuite) TestWatch() {
+	s.WithPrepare(s.prepare, func(client CuratorFramework, conn *mockConn, data []byte, stat *zk.Stat) {
+		conn.On("Get", "/app/locks/lock").Return(data, stat, nil).Once()
+
+		_, err := client.GetData().ForPath("/app/locks/lock")
+
+		s.NoError(err)
+
+		_, err = client.GetData().Watched().ForPath("/app/locks/lock")
+
+		s.IsType((*SchemaViolationError)(nil), err)
+
+		_, err = client.GetChildren().Watched().ForPath("/app/locks/lock")
+
+		s.IsType((*SchemaViolationError)(nil), err)
+
+		_, err = client.CheckExists().Watched().ForPath("/app/locks/lock")
+
+		s.IsType((*SchemaViolationError)(nil), err)
+	})
+}
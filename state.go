@@ -1,6 +1,7 @@
 package curator
 
 import (
+	"context"
 	"errors"
 	"fmt"
 	"log"
@@ -105,6 +106,15 @@ func (h *handleHolder) getZookeeperConnection() (ZookeeperConnection, error) {
 	return nil, nil
 }
 
+// Return the connection if it has been established, without dialing
+func (h *handleHolder) cachedConnection() ZookeeperConnection {
+	if cache, ok := h.Helper().(*zookeeperCache); ok {
+		return cache.conn
+	}
+
+	return nil
+}
+
 func (h *handleHolder) closeAndClear() error {
 	if _, ok := h.Helper().(*zookeeperFactory); ok {
 		return nil
@@ -150,6 +160,8 @@ type connectionState struct {
 	connectionStart   *atomic.Value
 	isConnected       AtomicBool
 	backgroundErrors  chan error
+	historyLock       sync.Mutex
+	history           []SessionStateChange
 }
 
 func newConnectionState(zookeeperDialer ZookeeperDialer, ensembleProvider EnsembleProvider, sessionTimeout, connectionTimeout time.Duration,
@@ -193,6 +205,25 @@ func (s *connectionState) InstanceIndex() int64 {
 	return atomic.LoadInt64(&s.instanceIndex)
 }
 
+// Return the most recent session state changes, oldest first
+func (s *connectionState) StateHistory() []SessionStateChange {
+	s.historyLock.Lock()
+	defer s.historyLock.Unlock()
+
+	return append([]SessionStateChange(nil), s.history...)
+}
+
+func (s *connectionState) recordState(state zk.State) {
+	s.historyLock.Lock()
+	defer s.historyLock.Unlock()
+
+	if len(s.history) >= SESSION_STATE_HISTORY_SIZE {
+		s.history = append(s.history[:0], s.history[len(s.history)-SESSION_STATE_HISTORY_SIZE+1:]...)
+	}
+
+	s.history = append(s.history, SessionStateChange{State: state, Time: time.Now()})
+}
+
 func (s *connectionState) Conn() (ZookeeperConnection, error) {
 	if err := s.dequeBackgroundException(); err != nil {
 		return nil, err
@@ -293,6 +324,8 @@ func (s *connectionState) process(event *zk.Event) {
 	}
 
 	if event.Type == zk.EventSession {
+		s.recordState(event.State)
+
 		wasConnected := s.isConnected.Load()
 		if newIsConnected := s.checkState(event.State, event.Err, wasConnected); newIsConnected != wasConnected {
 			s.isConnected.Set(newIsConnected)
@@ -306,7 +339,7 @@ func (s *connectionState) checkState(state zk.State, err error, wasConnected boo
 	checkNewConnectionString := true
 
 	switch state {
-	case zk.StateHasSession:
+	case zk.StateHasSession, zk.StateConnectedReadOnly:
 		isConnected = true
 	case zk.StateExpired:
 		isConnected = false
@@ -381,10 +414,11 @@ const (
 	RECONNECTED                 // A suspended, lost, or read-only connection has been re-established
 	LOST                        // The connection is confirmed to be lost. Close any locks, leaders, etc.
 	READ_ONLY                   // The connection has gone into read-only mode.
+	AUTH_FAILED                 // The server rejected the credentials of the connection.
 )
 
 var connectionStateNames = []string{
-	"UNKNOWN", "CONNECTED", "SUSPENDED", "RECONNECTED", "LOST", "READ_ONLY",
+	"UNKNOWN", "CONNECTED", "SUSPENDED", "RECONNECTED", "LOST", "READ_ONLY", "AUTH_FAILED",
 }
 
 func (s ConnectionState) Connected() bool {
@@ -404,16 +438,22 @@ type connectionStateManager struct {
 	currentConnectionState    ConnectionState
 	lock                      sync.Mutex
 	initialConnectMessageSent AtomicBool
-	events                    chan ConnectionState
 	QueueSize                 int
+	tracer                    TracerDriver
 	closed                    chan struct{}
+	dispatchers               map[interface{}]*stateDispatcher // keyed by the listeners
+	subscribers               map[*stateDispatcher]struct{}
 }
 
 func newConnectionStateManager(client CuratorFramework) *connectionStateManager {
 	return &connectionStateManager{
-		client:    client,
-		listeners: new(connectionStateListenerContainer),
-		QueueSize: STATE_QUEUE_SIZE,
+		client:      client,
+		listeners:   new(connectionStateListenerContainer),
+		QueueSize:   STATE_QUEUE_SIZE,
+		tracer:      newDefaultTracerDriver(),
+		closed:      make(chan struct{}),
+		dispatchers: make(map[interface{}]*stateDispatcher),
+		subscribers: make(map[*stateDispatcher]struct{}),
 	}
 }
 
@@ -422,11 +462,6 @@ func (m *connectionStateManager) Start() error {
 		return fmt.Errorf("Cannot be started more than once")
 	}
 
-	m.events = make(chan ConnectionState, m.QueueSize)
-	m.closed = make(chan struct{})
-
-	go m.processEvents()
-
 	return nil
 }
 
@@ -434,7 +469,24 @@ func (m *connectionStateManager) Close() {
 	if !m.state.Change(STARTED, STOPPED) {
 		return
 	}
+
+	m.lock.Lock()
+
 	close(m.closed)
+
+	for _, dispatcher := range m.dispatchers {
+		dispatcher.stop()
+	}
+
+	for dispatcher := range m.subscribers {
+		dispatcher.stop()
+	}
+
+	m.dispatchers = make(map[interface{}]*stateDispatcher)
+	m.subscribers = make(map[*stateDispatcher]struct{})
+
+	m.lock.Unlock()
+
 	m.listeners.Clear()
 }
 
@@ -481,7 +533,7 @@ func (m *connectionStateManager) AddStateChange(newConnectionState ConnectionSta
 	localState := newConnectionState
 
 	switch newConnectionState {
-	case LOST, SUSPENDED, READ_ONLY:
+	case LOST, SUSPENDED, READ_ONLY, AUTH_FAILED:
 		break
 	default:
 		if m.initialConnectMessageSent.CompareAndSwap(false, true) {
@@ -495,13 +547,18 @@ func (m *connectionStateManager) AddStateChange(newConnectionState ConnectionSta
 }
 
 func (m *connectionStateManager) BlockUntilConnected(maxWaitTime time.Duration) error {
-	if m.currentConnectionState.Connected() {
+	return m.blockUntil(maxWaitTime, ConnectionState.Connected)
+}
+
+// Block until the connection state satisfies the condition, or wait forever if maxWaitTime is 0
+func (m *connectionStateManager) blockUntil(maxWaitTime time.Duration, cond func(ConnectionState) bool) error {
+	if cond(m.CurrentState()) {
 		return nil
 	}
 
 	var isConnected = make(chan struct{}, 1)
 	listener := NewConnectionStateListener(func(client CuratorFramework, newState ConnectionState) {
-		if newState.Connected() {
+		if cond(newState) {
 			select {
 			case isConnected <- struct{}{}:
 			default:
@@ -513,7 +570,7 @@ func (m *connectionStateManager) BlockUntilConnected(maxWaitTime time.Duration)
 
 	// Double-check that we are still not connected.
 	// To make sure we didn't miss the event while adding listener.
-	if m.currentConnectionState.Connected() {
+	if cond(m.CurrentState()) {
 		return nil
 	}
 
@@ -530,6 +587,14 @@ func (m *connectionStateManager) BlockUntilConnected(maxWaitTime time.Duration)
 	}
 }
 
+// Return the current connection state
+func (m *connectionStateManager) CurrentState() ConnectionState {
+	m.lock.Lock()
+	defer m.lock.Unlock()
+
+	return m.currentConnectionState
+}
+
 func (m *connectionStateManager) Connected() bool {
 	m.lock.Lock()
 	defer m.lock.Unlock()
@@ -537,30 +602,183 @@ func (m *connectionStateManager) Connected() bool {
 	return m.currentConnectionState.Connected()
 }
 
+// Dispatch the state to the listeners and subscribers, the caller must hold the lock
 func (m *connectionStateManager) postState(state ConnectionState) {
-	for {
+	select {
+	case <-m.closed:
+		return
+	default:
+	}
+
+	listeners := make(map[interface{}]bool)
+
+	m.listeners.ForEach(func(listener interface{}) {
+		listeners[listener] = true
+
+		dispatcher, ok := m.dispatchers[listener]
+
+		if !ok {
+			stateListener := listener.(ConnectionStateListener)
+
+			dispatcher = m.newDispatcher(func(state ConnectionState) {
+				stateListener.StateChanged(m.client, state)
+			}, nil)
+
+			m.dispatchers[listener] = dispatcher
+		}
+
+		dispatcher.post(state)
+	})
+
+	// stop the dispatchers of the removed listeners
+	for listener, dispatcher := range m.dispatchers {
+		if !listeners[listener] {
+			dispatcher.stop()
+
+			delete(m.dispatchers, listener)
+		}
+	}
+
+	for dispatcher := range m.subscribers {
+		dispatcher.post(state)
+	}
+}
+
+// Subscribe the connection states, the channel receives the current state first and then every change.
+// The channel is closed after the context is done or the manager is closed.
+func (m *connectionStateManager) Subscribe(ctx context.Context) <-chan ConnectionState {
+	states := make(chan ConnectionState)
+
+	m.lock.Lock()
+	defer m.lock.Unlock()
+
+	var dispatcher *stateDispatcher
+
+	dispatcher = m.newDispatcher(func(state ConnectionState) {
 		select {
-		case <-m.closed:
-			return
-		case m.events <- state:
-			return
-		default:
-			// Event queue is full - dropping events to make room.
-			<-m.events
+		case states <- state:
+		case <-dispatcher.stopped:
 		}
+	}, func() { close(states) })
+
+	select {
+	case <-m.closed:
+		dispatcher.stop()
+
+		return states
+	default:
+	}
+
+	m.subscribers[dispatcher] = struct{}{}
+
+	dispatcher.post(m.currentConnectionState)
+
+	go func() {
+		select {
+		case <-ctx.Done():
+			m.unsubscribe(dispatcher)
+		case <-dispatcher.stopped:
+		}
+	}()
+
+	return states
+}
+
+func (m *connectionStateManager) unsubscribe(dispatcher *stateDispatcher) {
+	m.lock.Lock()
+	defer m.lock.Unlock()
+
+	if _, ok := m.subscribers[dispatcher]; ok {
+		delete(m.subscribers, dispatcher)
+
+		dispatcher.stop()
+	}
+}
+
+func (m *connectionStateManager) newDispatcher(deliver func(state ConnectionState), done func()) *stateDispatcher {
+	dispatcher := &stateDispatcher{
+		deliver:   deliver,
+		queueSize: m.QueueSize,
+		tracer:    m.tracer,
+		wakeup:    make(chan struct{}, 1),
+		stopped:   make(chan struct{}),
 	}
+
+	go dispatcher.run(done)
+
+	return dispatcher
 }
 
-func (m *connectionStateManager) processEvents() {
+// Deliver the states on its own goroutine, so a slow listener can't stall the others.
+// When the listener falls behind, the latest states are coalesced instead of dropped silently,
+// so it always receives the latest state.
+type stateDispatcher struct {
+	deliver   func(state ConnectionState)
+	queueSize int
+	tracer    TracerDriver
+	lock      sync.Mutex
+	pending   []ConnectionState
+	wakeup    chan struct{}
+	stopped   chan struct{}
+}
+
+func (d *stateDispatcher) post(state ConnectionState) {
+	d.lock.Lock()
+
+	if n := len(d.pending); n > 0 && n >= d.queueSize {
+		d.pending[n-1] = state
+
+		d.tracer.AddCount("connection-state-coalesced", 1)
+	} else {
+		d.pending = append(d.pending, state)
+	}
+
+	d.lock.Unlock()
+
+	select {
+	case d.wakeup <- struct{}{}:
+	default:
+	}
+}
+
+func (d *stateDispatcher) next() (ConnectionState, bool) {
+	d.lock.Lock()
+	defer d.lock.Unlock()
+
+	if len(d.pending) == 0 {
+		return UNKNOWN, false
+	}
+
+	state := d.pending[0]
+
+	d.pending = d.pending[1:]
+
+	return state, true
+}
+
+func (d *stateDispatcher) run(done func()) {
+	if done != nil {
+		defer done()
+	}
+
 	for {
 		select {
-		case <-m.closed:
+		case <-d.stopped:
 			return
-		case newState := <-m.events:
-			m.listeners.ForEach(func(listener interface{}) {
-				listener.(ConnectionStateListener).StateChanged(m.client, newState)
-			})
+		case <-d.wakeup:
+			for state, ok := d.next(); ok; state, ok = d.next() {
+				select {
+				case <-d.stopped:
+					return
+				default:
+					d.deliver(state)
+				}
+			}
 		}
 	}
+}
 
+// Stop the dispatcher, which must be called only once
+func (d *stateDispatcher) stop() {
+	close(d.stopped)
 }
@@ -1,6 +1,7 @@
 package curator
 
 import (
+	"context"
 	"sync"
 	"testing"
 	"time"
@@ -382,6 +383,7 @@ type ConnectionStateManagerTestSuite struct {
 
 	client         *mockCuratorFramework
 	state          *connectionStateManager
+	listener       ConnectionStateListener
 	receivedStates []ConnectionState
 }
 
@@ -392,9 +394,10 @@ func TestConnectionStateManagerTestSuite(t *testing.T) {
 func (s *ConnectionStateManagerTestSuite) SetupTest() {
 	s.client = &mockCuratorFramework{}
 	s.state = newConnectionStateManager(s.client)
-	s.state.Listenable().AddListener(NewConnectionStateListener(func(client CuratorFramework, newState ConnectionState) {
+	s.listener = NewConnectionStateListener(func(client CuratorFramework, newState ConnectionState) {
 		s.receivedStates = append(s.receivedStates, newState)
-	}))
+	})
+	s.state.Listenable().AddListener(s.listener)
 }
 
 func (s *ConnectionStateManagerTestSuite) TearDownTest() {
@@ -406,23 +409,99 @@ func (s *ConnectionStateManagerTestSuite) TearDownTest() {
 func (s *ConnectionStateManagerTestSuite) TestPostState() {
 	assert.NoError(s.T(), s.state.Start())
 
-	for i := 0; i < STATE_QUEUE_SIZE; i++ {
-		s.state.postState(CONNECTED)
-	}
+	s.state.QueueSize = 2
+
+	// only the listeners below are dispatched, whose deliveries are awaited
+	s.state.Listenable().RemoveListener(s.listener)
+
+	var received []ConnectionState
+	var wg sync.WaitGroup
+
+	started := make(chan struct{})
+	blocked := make(chan struct{})
+	delivered := make(chan ConnectionState, 5)
+
+	wg.Add(3)
+
+	s.state.Listenable().AddListener(NewConnectionStateListener(func(client CuratorFramework, newState ConnectionState) {
+		if received == nil {
+			close(started)
+		}
+
+		<-blocked
 
-	for i := 0; i < STATE_QUEUE_SIZE; i++ {
-		s.state.postState(RECONNECTED)
+		received = append(received, newState)
+
+		wg.Done()
+	}))
+
+	s.state.Listenable().AddListener(NewConnectionStateListener(func(client CuratorFramework, newState ConnectionState) {
+		delivered <- newState
+	}))
+
+	// the slow listener blocks on the first state, the later ones are coalesced
+	for i, state := range []ConnectionState{CONNECTED, SUSPENDED, RECONNECTED, SUSPENDED, LOST} {
+		s.state.lock.Lock()
+		s.state.postState(state)
+		s.state.lock.Unlock()
+
+		if i == 0 {
+			<-started
+		}
+
+		// the other listeners aren't stalled
+		assert.Equal(s.T(), state, <-delivered)
 	}
 
-	state := <-s.state.events
+	close(blocked)
 
-	assert.Equal(s.T(), RECONNECTED, state)
+	wg.Wait()
+
+	assert.Equal(s.T(), []ConnectionState{CONNECTED, SUSPENDED, LOST}, received)
+	assert.Equal(s.T(), 2, s.state.tracer.(*defaultTracerDriver).counters["connection-state-coalesced"])
 
 	s.state.Close()
 
 	s.state.postState(LOST)
 }
 
+func (s *ConnectionStateManagerTestSuite) TestSubscribe() {
+	assert.NoError(s.T(), s.state.Start())
+
+	assert.True(s.T(), s.state.AddStateChange(CONNECTED))
+
+	ctx, cancel := context.WithCancel(context.Background())
+
+	states := s.state.Subscribe(ctx)
+
+	// the current state is received first
+	assert.Equal(s.T(), CONNECTED, <-states)
+
+	assert.True(s.T(), s.state.SetToSuspended())
+	assert.Equal(s.T(), SUSPENDED, <-states)
+
+	cancel()
+
+	_, ok := <-states
+
+	assert.False(s.T(), ok)
+
+	states = s.state.Subscribe(context.Background())
+
+	assert.Equal(s.T(), SUSPENDED, <-states)
+
+	s.state.Close()
+
+	_, ok = <-states
+
+	assert.False(s.T(), ok)
+
+	// closed immediately after the manager is closed
+	_, ok = <-s.state.Subscribe(context.Background())
+
+	assert.False(s.T(), ok)
+}
+
 func (s *ConnectionStateManagerTestSuite) TestStateChange() {
 	// return false before StateManager.Start
 	assert.False(s.T(), s.state.AddStateChange(CONNECTED))
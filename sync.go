@@ -6,10 +6,14 @@ type syncBuilder struct {
 }
 
 func (b *syncBuilder) ForPath(givenPath string) (string, error) {
-	adjustedPath := b.client.fixForNamespace(givenPath, false)
+	adjustedPath, err := b.client.fixForNamespace(givenPath, false)
+
+	if err != nil {
+		return "", err
+	}
 
 	if b.backgrounding.inBackground {
-		go b.pathInBackground(adjustedPath, givenPath)
+		b.client.client.inBackground(func() { b.pathInBackground(adjustedPath, givenPath) })
 
 		return givenPath, nil
 	} else {
@@ -0,0 +1,128 @@
+package curator
+
+import (
+	"crypto/tls"
+	"log"
+	"net"
+	"os"
+	"sync"
+	"time"
+)
+
+// Dial the servers of the ensemble with TLS, e.g. through the secureClientPort of ZooKeeper.
+//
+// The client certificate is reloaded from disk when the files change,
+// so a rotated certificate is used by the next connection without restarting.
+type TLSDialer struct {
+	config      *tls.Config
+	serverNames map[string]string
+	keyPair     *keyPairReloader
+}
+
+// Create a TLS dialer based on the config, which could be nil to use the default settings
+func NewTLSDialer(config *tls.Config) *TLSDialer {
+	if config == nil {
+		config = &tls.Config{}
+	}
+
+	return &TLSDialer{
+		config:      config,
+		serverNames: make(map[string]string),
+	}
+}
+
+// Present the client certificate loaded from the PEM encoded files
+func (d *TLSDialer) WithClientCertificate(certFile, keyFile string) *TLSDialer {
+	d.keyPair = &keyPairReloader{certFile: certFile, keyFile: keyFile}
+
+	return d
+}
+
+// Verify the certificate of the host with the server name instead of the host itself
+func (d *TLSDialer) WithServerName(host, serverName string) *TLSDialer {
+	d.serverNames[host] = serverName
+
+	return d
+}
+
+// Dial the address with TLS, it could be used as zk.Dialer
+func (d *TLSDialer) Dial(network, address string, timeout time.Duration) (net.Conn, error) {
+	config := d.config.Clone()
+
+	if host, _, err := net.SplitHostPort(address); err != nil {
+		return nil, err
+	} else if serverName, exists := d.serverNames[host]; exists {
+		config.ServerName = serverName
+	} else if len(config.ServerName) == 0 {
+		config.ServerName = host
+	}
+
+	if d.keyPair != nil {
+		if _, err := d.keyPair.load(); err != nil {
+			return nil, err
+		}
+
+		config.GetClientCertificate = func(*tls.CertificateRequestInfo) (*tls.Certificate, error) {
+			return d.keyPair.load()
+		}
+	}
+
+	return tls.DialWithDialer(&net.Dialer{Timeout: timeout}, network, address, config)
+}
+
+type keyPairReloader struct {
+	certFile    string
+	keyFile     string
+	lock        sync.Mutex
+	certModTime time.Time
+	keyModTime  time.Time
+	cert        *tls.Certificate
+}
+
+func (r *keyPairReloader) load() (*tls.Certificate, error) {
+	r.lock.Lock()
+	defer r.lock.Unlock()
+
+	certInfo, err := os.Stat(r.certFile)
+
+	if err != nil {
+		return r.fallback(err)
+	}
+
+	keyInfo, err := os.Stat(r.keyFile)
+
+	if err != nil {
+		return r.fallback(err)
+	}
+
+	if r.cert != nil && certInfo.ModTime().Equal(r.certModTime) && keyInfo.ModTime().Equal(r.keyModTime) {
+		return r.cert, nil
+	}
+
+	cert, err := tls.LoadX509KeyPair(r.certFile, r.keyFile)
+
+	if err != nil {
+		return r.fallback(err)
+	}
+
+	if r.cert != nil {
+		log.Printf("client certificate reloaded from %s", r.certFile)
+	}
+
+	r.cert = &cert
+	r.certModTime = certInfo.ModTime()
+	r.keyModTime = keyInfo.ModTime()
+
+	return r.cert, nil
+}
+
+// keep using the loaded certificate when the files are being rotated
+func (r *keyPairReloader) fallback(err error) (*tls.Certificate, error) {
+	if r.cert != nil {
+		log.Printf("fail to reload client certificate from %s, %s", r.certFile, err)
+
+		return r.cert, nil
+	}
+
+	return nil, err
+}
@@ -0,0 +1,242 @@
+package curator
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"io/ioutil"
+	"math/big"
+	"net"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/suite"
+)
+
+type testCA struct {
+	cert *x509.Certificate
+	key  *ecdsa.PrivateKey
+	pool *x509.CertPool
+}
+
+func newTestCA(t *testing.T) *testCA {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+
+	assert.NoError(t, err)
+
+	template := &x509.Certificate{
+		SerialNumber:          big.NewInt(1),
+		Subject:               pkix.Name{CommonName: "test ca"},
+		NotBefore:             time.Now().Add(-time.Hour),
+		NotAfter:              time.Now().Add(time.Hour),
+		KeyUsage:              x509.KeyUsageCertSign,
+		BasicConstraintsValid: true,
+		IsCA:                  true,
+	}
+
+	der, err := x509.CreateCertificate(rand.Reader, template, template, &key.PublicKey, key)
+
+	assert.NoError(t, err)
+
+	cert, err := x509.ParseCertificate(der)
+
+	assert.NoError(t, err)
+
+	pool := x509.NewCertPool()
+	pool.AddCert(cert)
+
+	return &testCA{cert, key, pool}
+}
+
+// issue a certificate, return the PEM encoded certificate and key
+func (ca *testCA) issue(t *testing.T, commonName string, dnsNames ...string) ([]byte, []byte) {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+
+	assert.NoError(t, err)
+
+	serial, err := rand.Int(rand.Reader, big.NewInt(1<<62))
+
+	assert.NoError(t, err)
+
+	template := &x509.Certificate{
+		SerialNumber: serial,
+		Subject:      pkix.Name{CommonName: commonName},
+		DNSNames:     dnsNames,
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     time.Now().Add(time.Hour),
+		KeyUsage:     x509.KeyUsageDigitalSignature,
+		ExtKeyUsage:  []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth, x509.ExtKeyUsageClientAuth},
+	}
+
+	der, err := x509.CreateCertificate(rand.Reader, template, ca.cert, &key.PublicKey, ca.key)
+
+	assert.NoError(t, err)
+
+	keyDer, err := x509.MarshalECPrivateKey(key)
+
+	assert.NoError(t, err)
+
+	return pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der}),
+		pem.EncodeToMemory(&pem.Block{Type: "EC PRIVATE KEY", Bytes: keyDer})
+}
+
+type TLSDialerTestSuite struct {
+	suite.Suite
+
+	ca       *testCA
+	dir      string
+	listener net.Listener
+	peers    chan string
+}
+
+func TestTLSDialer(t *testing.T) {
+	suite.Run(t, new(TLSDialerTestSuite))
+}
+
+func (s *TLSDialerTestSuite) SetupTest() {
+	var err error
+
+	s.ca = newTestCA(s.T())
+	s.dir, err = ioutil.TempDir("", "tls")
+
+	s.NoError(err)
+
+	certPEM, keyPEM := s.ca.issue(s.T(), "zookeeper", "zk.example.com")
+	cert, err := tls.X509KeyPair(certPEM, keyPEM)
+
+	s.NoError(err)
+
+	s.listener, err = tls.Listen("tcp", "127.0.0.1:0", &tls.Config{
+		Certificates: []tls.Certificate{cert},
+		ClientAuth:   tls.RequireAndVerifyClientCert,
+		ClientCAs:    s.ca.pool,
+	})
+
+	s.NoError(err)
+
+	s.peers = make(chan string, 10)
+
+	go func() {
+		for {
+			conn, err := s.listener.Accept()
+
+			if err != nil {
+				return
+			}
+
+			go func(conn *tls.Conn) {
+				defer conn.Close()
+
+				if err := conn.Handshake(); err == nil {
+					s.peers <- conn.ConnectionState().PeerCertificates[0].Subject.CommonName
+				}
+			}(conn.(*tls.Conn))
+		}
+	}()
+}
+
+func (s *TLSDialerTestSuite) TearDownTest() {
+	s.listener.Close()
+
+	os.RemoveAll(s.dir)
+}
+
+func (s *TLSDialerTestSuite) writeClientCertificate(commonName string, modTime time.Time) (string, string) {
+	certPEM, keyPEM := s.ca.issue(s.T(), commonName)
+
+	certFile := filepath.Join(s.dir, "client.crt")
+	keyFile := filepath.Join(s.dir, "client.key")
+
+	s.NoError(ioutil.WriteFile(certFile, certPEM, 0600))
+	s.NoError(ioutil.WriteFile(keyFile, keyPEM, 0600))
+	s.NoError(os.Chtimes(certFile, modTime, modTime))
+	s.NoError(os.Chtimes(keyFile, modTime, modTime))
+
+	return certFile, keyFile
+}
+
+func (s *TLSDialerTestSuite) dial(dialer *TLSDialer) (string, error) {
+	conn, err := dialer.Dial("tcp", s.listener.Addr().String(), time.Second)
+
+	if err != nil {
+		return "", err
+	}
+
+	defer conn.Close()
+
+	select {
+	case peer := <-s.peers:
+		return peer, nil
+	case <-time.After(time.Second):
+		return "", ErrTimeout
+	}
+}
+
+func (s *TLSDialerTestSuite) TestMutualTLS() {
+	certFile, keyFile := s.writeClientCertificate("client1", time.Now())
+
+	dialer := NewTLSDialer(&tls.Config{RootCAs: s.ca.pool}).
+		WithServerName("127.0.0.1", "zk.example.com").
+		WithClientCertificate(certFile, keyFile)
+
+	peer, err := s.dial(dialer)
+
+	s.NoError(err)
+	s.Equal("client1", peer)
+
+	// the rotated certificate is used by the next connection
+	s.writeClientCertificate("client2", time.Now().Add(time.Minute))
+
+	peer, err = s.dial(dialer)
+
+	s.NoError(err)
+	s.Equal("client2", peer)
+
+	// the loaded certificate is kept if the new one is broken
+	s.NoError(ioutil.WriteFile(certFile, []byte("broken"), 0600))
+	s.NoError(os.Chtimes(certFile, time.Now().Add(2*time.Minute), time.Now().Add(2*time.Minute)))
+
+	peer, err = s.dial(dialer)
+
+	s.NoError(err)
+	s.Equal("client2", peer)
+}
+
+func (s *TLSDialerTestSuite) TestServerName() {
+	certFile, keyFile := s.writeClientCertificate("client", time.Now())
+
+	// the certificate of the server doesn't match 127.0.0.1
+	_, err := s.dial(NewTLSDialer(&tls.Config{RootCAs: s.ca.pool}).WithClientCertificate(certFile, keyFile))
+
+	s.Error(err)
+
+	// the server is not trusted
+	_, err = s.dial(NewTLSDialer(nil).WithServerName("127.0.0.1", "zk.example.com").WithClientCertificate(certFile, keyFile))
+
+	s.Error(err)
+}
+
+func (s *TLSDialerTestSuite) TestMissingClientCertificate() {
+	dialer := NewTLSDialer(&tls.Config{RootCAs: s.ca.pool}).
+		WithServerName("127.0.0.1", "zk.example.com").
+		WithClientCertificate(filepath.Join(s.dir, "missing.crt"), filepath.Join(s.dir, "missing.key"))
+
+	_, err := s.dial(dialer)
+
+	s.True(os.IsNotExist(err))
+}
+
+func TestTLSBuilder(t *testing.T) {
+	builder := &CuratorFrameworkBuilder{}
+
+	assert.Equal(t, builder, builder.TLS(NewTLSDialer(nil)))
+	assert.IsType(t, (*DefaultZookeeperDialer)(nil), builder.ZookeeperDialer)
+	assert.NotNil(t, builder.ZookeeperDialer.(*DefaultZookeeperDialer).Dialer)
+}
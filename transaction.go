@@ -73,6 +73,7 @@ type TransactionBridge interface {
 type curatorTransaction struct {
 	client     *curatorFramework
 	operations []interface{}
+	err        error // the first error of building the operations
 }
 
 func (t *curatorTransaction) Create() TransactionCreateBuilder {
@@ -96,6 +97,14 @@ func (t *curatorTransaction) And() TransactionFinal {
 }
 
 func (t *curatorTransaction) Commit() ([]TransactionResult, error) {
+	if t.err != nil {
+		return nil, t.err
+	}
+
+	if err := t.client.ensureWritable(); err != nil {
+		return nil, err
+	}
+
 	zkClient := t.client.ZookeeperClient()
 
 	result, err := zkClient.NewRetryLoop().CallWithRetry(func() (interface{}, error) {
@@ -123,6 +132,8 @@ func (t *curatorTransaction) Commit() ([]TransactionResult, error) {
 					ForPath: req.Path,
 				})
 			case *zk.SetDataRequest:
+				t.client.client.recordStat(res.Stat)
+
 				results = append(results, TransactionResult{
 					Type:       OP_SET_DATA,
 					ForPath:    req.Path,
@@ -141,10 +152,11 @@ func (t *curatorTransaction) Commit() ([]TransactionResult, error) {
 }
 
 type transactionCreateBuilder struct {
-	transaction *curatorTransaction
-	createMode  CreateMode
-	compress    bool
-	acling      acling
+	transaction  *curatorTransaction
+	createMode   CreateMode
+	compress     bool
+	skipCompress bool
+	acling       acling
 }
 
 func (b *transactionCreateBuilder) ForPath(path string) TransactionBridge {
@@ -152,18 +164,30 @@ func (b *transactionCreateBuilder) ForPath(path string) TransactionBridge {
 }
 
 func (b *transactionCreateBuilder) ForPathWithData(path string, payload []byte) TransactionBridge {
+	adjustedPath, err := b.transaction.client.fixForNamespace(path, false)
+
+	if err == nil {
+		err = b.transaction.client.schemaSet.ValidateCreate(adjustedPath, b.createMode, payload)
+	}
+
 	var data []byte
 
-	if b.compress {
-		data, _ = b.transaction.client.compressionProvider.Compress(path, payload)
-	} else {
-		data = payload
+	if err == nil {
+		data, err = b.transaction.client.encodeData(path, payload, b.transaction.client.shouldCompress(path, b.compress, b.skipCompress))
+	}
+
+	if err == nil && b.transaction.client.shouldChunk(data) {
+		err = ErrChunkedTransaction
+	}
+
+	if err != nil && b.transaction.err == nil {
+		b.transaction.err = err
 	}
 
 	b.transaction.operations = append(b.transaction.operations, &zk.CreateRequest{
-		Path:  b.transaction.client.fixForNamespace(path, false),
+		Path:  adjustedPath,
 		Data:  data,
-		Acl:   b.acling.getAclList(path),
+		Acl:   b.acling.getAclList(adjustedPath),
 		Flags: int32(b.createMode),
 	})
 
@@ -188,14 +212,26 @@ func (b *transactionCreateBuilder) Compressed() TransactionCreateBuilder {
 	return b
 }
 
+func (b *transactionCreateBuilder) SkipCompression() TransactionCreateBuilder {
+	b.skipCompress = true
+
+	return b
+}
+
 type transactionDeleteBuilder struct {
 	transaction *curatorTransaction
 	version     int32
 }
 
 func (b *transactionDeleteBuilder) ForPath(path string) TransactionBridge {
+	adjustedPath, err := b.transaction.client.fixForNamespace(path, false)
+
+	if err != nil && b.transaction.err == nil {
+		b.transaction.err = err
+	}
+
 	b.transaction.operations = append(b.transaction.operations, &zk.DeleteRequest{
-		Path:    b.transaction.client.fixForNamespace(path, false),
+		Path:    adjustedPath,
 		Version: b.version,
 	})
 
@@ -209,9 +245,10 @@ func (b *transactionDeleteBuilder) WithVersion(version int32) TransactionDeleteB
 }
 
 type transactionSetDataBuilder struct {
-	transaction *curatorTransaction
-	version     int32
-	compress    bool
+	transaction  *curatorTransaction
+	version      int32
+	compress     bool
+	skipCompress bool
 }
 
 func (b *transactionSetDataBuilder) ForPath(path string) TransactionBridge {
@@ -219,16 +256,28 @@ func (b *transactionSetDataBuilder) ForPath(path string) TransactionBridge {
 }
 
 func (b *transactionSetDataBuilder) ForPathWithData(path string, payload []byte) TransactionBridge {
+	adjustedPath, err := b.transaction.client.fixForNamespace(path, false)
+
+	if err == nil {
+		err = b.transaction.client.schemaSet.ValidateData(adjustedPath, payload)
+	}
+
 	var data []byte
 
-	if b.compress {
-		data, _ = b.transaction.client.compressionProvider.Compress(path, payload)
-	} else {
-		data = payload
+	if err == nil {
+		data, err = b.transaction.client.encodeData(path, payload, b.transaction.client.shouldCompress(path, b.compress, b.skipCompress))
+	}
+
+	if err == nil && b.transaction.client.shouldChunk(data) {
+		err = ErrChunkedTransaction
+	}
+
+	if err != nil && b.transaction.err == nil {
+		b.transaction.err = err
 	}
 
 	b.transaction.operations = append(b.transaction.operations, &zk.SetDataRequest{
-		Path:    b.transaction.client.fixForNamespace(path, false),
+		Path:    adjustedPath,
 		Data:    data,
 		Version: b.version,
 	})
@@ -248,14 +297,26 @@ func (b *transactionSetDataBuilder) Compressed() TransactionSetDataBuilder {
 	return b
 }
 
+func (b *transactionSetDataBuilder) SkipCompression() TransactionSetDataBuilder {
+	b.skipCompress = true
+
+	return b
+}
+
 type transactionCheckBuilder struct {
 	transaction *curatorTransaction
 	version     int32
 }
 
 func (b *transactionCheckBuilder) ForPath(path string) TransactionBridge {
+	adjustedPath, err := b.transaction.client.fixForNamespace(path, false)
+
+	if err != nil && b.transaction.err == nil {
+		b.transaction.err = err
+	}
+
 	b.transaction.operations = append(b.transaction.operations, &zk.CheckVersionRequest{
-		Path:    b.transaction.client.fixForNamespace(path, false),
+		Path:    adjustedPath,
 		Version: b.version,
 	})
 
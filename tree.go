@@ -0,0 +1,384 @@
+package curator
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"strings"
+
+	"github.com/yxdrlitao/go-zookeeper/zk"
+)
+
+const (
+	DEFAULT_TREE_TRANSACTION_OPS   = 1000       // the max number of the operations of a transaction of CopyTree() and MoveTree()
+	DEFAULT_TREE_TRANSACTION_BYTES = 512 * 1024 // the max size of a transaction of CopyTree() and MoveTree(), below the default jute.maxbuffer
+	TREE_OPERATION_OVERHEAD        = 64         // the estimated size of an operation besides its path and data
+)
+
+// The ephemeral owner of the special nodes, which are not ephemeral nodes, see EphemeralType of ZooKeeper
+const (
+	containerEphemeralOwner int64  = math.MinInt64      // the container node, 0x8000000000000000
+	ttlEphemeralOwnerMask   uint64 = 0xff00000000000000 // the high byte of the ephemeral owner of the TTL node
+)
+
+// MoveTree() refuses the subtree with an ephemeral node, including the nodes of an active lock
+var ErrEphemeralInTree = errors.New("the subtree has ephemeral nodes")
+
+// The options of CopyTree() and MoveTree()
+type TreeOptions struct {
+	MaxTransactionOps   int // the max number of the operations of a transaction, DEFAULT_TREE_TRANSACTION_OPS by default
+	MaxTransactionBytes int // the max size of a transaction, DEFAULT_TREE_TRANSACTION_BYTES by default
+
+	// Called after each transaction is committed with the number of the nodes copied so far,
+	// the subtree is copied in several transactions when it doesn't fit a transaction.
+	Checkpoint func(copied int) error
+
+	// Skip the nodes copied by an interrupted run as reported to Checkpoint,
+	// which requires that the source subtree hasn't been changed since.
+	Resume int
+}
+
+type treeNode struct {
+	path     string // the path relative to the root of the subtree
+	data     []byte
+	acls     []zk.ACL
+	mode     CreateMode
+	version  int32
+	children int32 // the number of the children, including the hidden chunk nodes
+	chunked  bool  // the node has the hidden chunk nodes, which can't be deleted in a transaction
+}
+
+// An operation of a transaction of CopyTree() or MoveTree()
+type treeOperation struct {
+	size     int
+	add      func(txn Transaction) TransactionFinal
+	fallback func(txn Transaction) TransactionFinal // add the container as a persistent node if the server doesn't support containers
+	apply    func(client CuratorFramework) error    // apply the operation of a chunked node outside of the transactions
+}
+
+// Copy the subtree from src of a client to dst of another one or the same one, e.g. to copy between clusters,
+// the paths are relative to the namespaces of the clients and the parents of dst are created if needed.
+//
+// The data and ACLs of the nodes are preserved, and the ephemeral nodes are created as ephemeral nodes of the target client.
+// The containers are created as containers, falling back to persistent nodes before ZooKeeper 3.5, and the TTL nodes as persistent nodes.
+// The subtree is copied in a single transaction if it fits, otherwise in several transactions, see TreeOptions.Checkpoint.
+// The large data chunked by the target client is written outside of the transactions.
+func CopyTree(from CuratorFramework, src string, to CuratorFramework, dst string, options *TreeOptions) error {
+	if err := checkTreePaths(from, src, to, dst); err != nil {
+		return err
+	}
+
+	nodes, err := readTree(from, src, false)
+
+	if err != nil {
+		return err
+	}
+
+	return commitTree(to, dst, newTreeOptions(options), createTreeOperations(to, dst, nodes), nil)
+}
+
+// Move the subtree from src of a client to dst of another one or the same one, the source subtree is deleted after it's copied.
+//
+// The subtree is moved atomically if the clients share the connection and the namespace, it fits a transaction and has no chunked data.
+// The subtree with ephemeral nodes is refused with ErrEphemeralInTree, which includes the nodes of an active lock
+// but not the containers and TTL nodes, which are recreated as CopyTree() does,
+// and the move fails with zk.ErrBadVersion if a source node is changed during the move.
+func MoveTree(from CuratorFramework, src string, to CuratorFramework, dst string, options *TreeOptions) error {
+	if err := checkTreePaths(from, src, to, dst); err != nil {
+		return err
+	}
+
+	nodes, err := readTree(from, src, true)
+
+	if err != nil {
+		return err
+	}
+
+	opts := newTreeOptions(options)
+	creates := createTreeOperations(to, dst, nodes)
+	deletes := deleteTreeOperations(src, nodes)
+
+	if from.ZookeeperClient() == to.ZookeeperClient() && from.Namespace() == to.Namespace() && opts.Resume == 0 && fitsTransaction(append(creates, deletes...), opts) {
+		return commitTree(to, dst, opts, creates, deletes)
+	}
+
+	if err := commitTree(to, dst, opts, creates, nil); err != nil {
+		return err
+	}
+
+	return commitTree(from, "", &TreeOptions{MaxTransactionOps: opts.MaxTransactionOps, MaxTransactionBytes: opts.MaxTransactionBytes}, deletes, nil)
+}
+
+func newTreeOptions(options *TreeOptions) *TreeOptions {
+	opts := &TreeOptions{}
+
+	if options != nil {
+		*opts = *options
+	}
+
+	if opts.MaxTransactionOps <= 0 {
+		opts.MaxTransactionOps = DEFAULT_TREE_TRANSACTION_OPS
+	}
+
+	if opts.MaxTransactionBytes <= 0 {
+		opts.MaxTransactionBytes = DEFAULT_TREE_TRANSACTION_BYTES
+	}
+
+	return opts
+}
+
+func checkTreePaths(from CuratorFramework, src string, to CuratorFramework, dst string) error {
+	if err := ValidatePath(src); err != nil {
+		return err
+	} else if err := ValidatePath(dst); err != nil {
+		return err
+	} else if src == PATH_SEPARATOR || dst == PATH_SEPARATOR {
+		return errors.New("can't copy from or to the root node")
+	}
+
+	if from.ZookeeperClient() == to.ZookeeperClient() {
+		srcPath, _ := FixForNamespace(from.Namespace(), src, false)
+		dstPath, _ := FixForNamespace(to.Namespace(), dst, false)
+
+		if srcPath == dstPath || strings.HasPrefix(dstPath, srcPath+PATH_SEPARATOR) || strings.HasPrefix(srcPath, dstPath+PATH_SEPARATOR) {
+			return fmt.Errorf("can't copy %s to %s which overlaps", srcPath, dstPath)
+		}
+	}
+
+	return nil
+}
+
+// Return the mode to recreate the node, the TTL node is recreated as a persistent node
+func treeNodeMode(stat *zk.Stat) CreateMode {
+	switch owner := stat.EphemeralOwner; {
+	case owner == 0:
+		return PERSISTENT
+	case owner == containerEphemeralOwner:
+		return CONTAINER
+	case uint64(owner)&ttlEphemeralOwnerMask == ttlEphemeralOwnerMask:
+		return PERSISTENT
+	default:
+		return EPHEMERAL
+	}
+}
+
+// Read the nodes of the subtree in pre-order, so the parents are created before their children
+func readTree(client CuratorFramework, root string, refuseEphemeral bool) ([]*treeNode, error) {
+	var nodes []*treeNode
+
+	err := Walk(client, root, func(node *WalkNode) error {
+		acls, err := client.GetACL().ForPath(node.Path)
+
+		if err == zk.ErrNoNode {
+			return ErrSkipChildren
+		} else if err != nil {
+			return err
+		}
+
+		mode := treeNodeMode(node.Stat)
+
+		if mode.IsEphemeral() && refuseEphemeral {
+			return fmt.Errorf("%w: %s", ErrEphemeralInTree, node.Path)
+		}
+
+		nodes = append(nodes, &treeNode{
+			path:     node.Path[len(root):],
+			data:     node.Data,
+			acls:     acls,
+			mode:     mode,
+			version:  node.Stat.Version,
+			children: node.Stat.NumChildren,
+		})
+
+		return nil
+	}, &WalkOptions{WithData: true})
+
+	if err != nil {
+		return nil, err
+	}
+
+	// the chunk nodes are hidden from the walk, so the node has more children than the ones walked
+	walked := make(map[string]int32)
+
+	for _, node := range nodes {
+		if len(node.path) > 0 {
+			walked[node.path[:strings.LastIndex(node.path, PATH_SEPARATOR)]]++
+		}
+	}
+
+	for _, node := range nodes {
+		node.chunked = node.children > walked[node.path]
+	}
+
+	return nodes, nil
+}
+
+// Return true if the data would be chunked by the client, which can't be created in a transaction
+func chunkedByClient(client CuratorFramework, path string, payload []byte) bool {
+	holder, ok := client.(interface{ framework() *curatorFramework })
+
+	if !ok {
+		return false
+	}
+
+	c := holder.framework()
+
+	if c.chunkSize <= 0 {
+		return false
+	}
+
+	data, err := c.encodeData(path, payload, c.shouldCompress(path, false, false))
+
+	return err == nil && c.shouldChunk(data)
+}
+
+func createTreeOperations(client CuratorFramework, root string, nodes []*treeNode) []*treeOperation {
+	ops := make([]*treeOperation, len(nodes))
+
+	for i, node := range nodes {
+		node := node
+		nodePath := root + node.path
+
+		if chunkedByClient(client, nodePath, node.data) {
+			ops[i] = &treeOperation{
+				size: len(nodePath) + TREE_OPERATION_OVERHEAD,
+				apply: func(client CuratorFramework) error {
+					_, err := client.Create().WithMode(node.mode).WithACL(node.acls...).ForPathWithData(nodePath, node.data)
+
+					return err
+				},
+			}
+
+			continue
+		}
+
+		ops[i] = &treeOperation{
+			size: len(nodePath) + len(node.data) + TREE_OPERATION_OVERHEAD,
+			add: func(txn Transaction) TransactionFinal {
+				return txn.Create().WithMode(node.mode).WithACL(node.acls...).ForPathWithData(nodePath, node.data)
+			},
+		}
+
+		if node.mode.IsContainer() {
+			ops[i].fallback = func(txn Transaction) TransactionFinal {
+				return txn.Create().WithMode(PERSISTENT).WithACL(node.acls...).ForPathWithData(nodePath, node.data)
+			}
+		}
+	}
+
+	return ops
+}
+
+// Delete the nodes of the subtree in reverse, so the children are deleted before their parents
+func deleteTreeOperations(root string, nodes []*treeNode) []*treeOperation {
+	ops := make([]*treeOperation, len(nodes))
+
+	for i, node := range nodes {
+		node := node
+		nodePath := root + node.path
+
+		op := &treeOperation{size: len(nodePath) + TREE_OPERATION_OVERHEAD}
+
+		if node.chunked {
+			op.apply = func(client CuratorFramework) error {
+				return client.Delete().WithVersion(node.version).ForPath(nodePath)
+			}
+		} else {
+			op.add = func(txn Transaction) TransactionFinal {
+				return txn.Delete().WithVersion(node.version).ForPath(nodePath)
+			}
+		}
+
+		ops[len(nodes)-1-i] = op
+	}
+
+	return ops
+}
+
+func fitsTransaction(ops []*treeOperation, options *TreeOptions) bool {
+	if len(ops) > options.MaxTransactionOps {
+		return false
+	}
+
+	size := 0
+
+	for _, op := range ops {
+		if op.apply != nil {
+			return false
+		}
+
+		size += op.size
+	}
+
+	return size <= options.MaxTransactionBytes
+}
+
+// Commit the creates, along with the deletes in the same transaction if any, in as few transactions as possible
+func commitTree(client CuratorFramework, root string, options *TreeOptions, creates, deletes []*treeOperation) error {
+	if len(root) > 0 {
+		if parent, err := SplitPath(root); err != nil {
+			return err
+		} else if err := client.NewNamespaceAwareEnsurePath(parent.Path).Ensure(client.ZookeeperClient()); err != nil {
+			return err
+		}
+	}
+
+	ops := append(creates[min(options.Resume, len(creates)):], deletes...)
+	committed := min(options.Resume, len(creates))
+
+	persistentContainers := false
+
+	for len(ops) > 0 {
+		count := 0
+
+		if ops[0].apply != nil {
+			if err := ops[0].apply(client); err != nil {
+				return err
+			}
+
+			count = 1
+		} else {
+			var txn Transaction = client.InTransaction()
+			var final TransactionFinal
+
+			size, containers := 0, false
+
+			for _, op := range ops {
+				if op.apply != nil || (count > 0 && (count+1 > options.MaxTransactionOps || size+op.size > options.MaxTransactionBytes)) {
+					break
+				}
+
+				if op.fallback != nil && persistentContainers {
+					final = op.fallback(txn)
+				} else {
+					final = op.add(txn)
+				}
+
+				txn = final
+				size += op.size
+				count++
+				containers = containers || op.fallback != nil
+			}
+
+			if _, err := final.Commit(); err == zk.ErrBadArguments && containers && !persistentContainers {
+				// the server doesn't support containers before ZooKeeper 3.5
+				persistentContainers = true
+
+				continue
+			} else if err != nil {
+				return err
+			}
+		}
+
+		ops = ops[count:]
+		committed += count
+
+		if options.Checkpoint != nil {
+			if err := options.Checkpoint(min(committed, len(creates))); err != nil {
+				return err
+			}
+		}
+	}
+
+	return nil
+}
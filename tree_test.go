@@ -0,0 +1,172 @@
+package curator
+
+import (
+	"errors"
+	"math"
+	"testing"
+
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/suite"
+	"github.com/yxdrlitao/go-zookeeper/zk"
+)
+
+type TreeTestSuite struct {
+	mockContainerTestSuite
+}
+
+func TestTree(t *testing.T) {
+	suite.Run(t, new(TreeTestSuite))
+}
+
+func (s *TreeTestSuite) mockTree(conn *mockConn, stat *zk.Stat) {
+	conn.On("Get", "/src").Return([]byte("root"), &zk.Stat{Version: 1}, nil).Once()
+	conn.On("GetACL", "/src").Return(READ_ACL_UNSAFE, stat, nil).Once()
+	conn.On("Children", "/src").Return([]string{"a"}, stat, nil).Once()
+	conn.On("Get", "/src/a").Return([]byte("a"), &zk.Stat{Version: 2}, nil).Once()
+	conn.On("GetACL", "/src/a").Return(OPEN_ACL_UNSAFE, stat, nil).Once()
+	conn.On("Children", "/src/a").Return([]string{}, stat, nil).Once()
+}
+
+func (s *TreeTestSuite) TestMoveTree() {
+	s.With(func(client CuratorFramework, conn *mockConn, stat *zk.Stat) {
+		s.mockTree(conn, stat)
+
+		conn.On("Multi", mock.Anything).Return([]zk.MultiResponse{{String: "/dst"}, {String: "/dst/a"}, {}, {}}, nil).Once()
+
+		s.NoError(MoveTree(client, "/src", client, "/dst", nil))
+		s.Equal([]interface{}{
+			&zk.CreateRequest{Path: "/dst", Data: []byte("root"), Acl: READ_ACL_UNSAFE, Flags: int32(PERSISTENT)},
+			&zk.CreateRequest{Path: "/dst/a", Data: []byte("a"), Acl: OPEN_ACL_UNSAFE, Flags: int32(PERSISTENT)},
+			&zk.DeleteRequest{Path: "/src/a", Version: 2},
+			&zk.DeleteRequest{Path: "/src", Version: 1},
+		}, conn.operations)
+	})
+}
+
+func (s *TreeTestSuite) TestCopyTreeInBatches() {
+	s.With(func(client CuratorFramework, conn *mockConn, stat *zk.Stat) {
+		s.mockTree(conn, stat)
+
+		conn.On("Exists", "/backup").Return(true, stat, nil).Once()
+		conn.On("Multi", mock.Anything).Return([]zk.MultiResponse{{String: "/backup/dst/a"}}, nil).Once()
+
+		var checkpoints []int
+
+		err := CopyTree(client, "/src", client, "/backup/dst", &TreeOptions{
+			MaxTransactionOps: 1,
+			Resume:            1,
+			Checkpoint: func(copied int) error {
+				checkpoints = append(checkpoints, copied)
+
+				return nil
+			},
+		})
+
+		s.NoError(err)
+		s.Equal([]int{2}, checkpoints)
+		s.Equal([]interface{}{
+			&zk.CreateRequest{Path: "/backup/dst/a", Data: []byte("a"), Acl: OPEN_ACL_UNSAFE, Flags: int32(PERSISTENT)},
+		}, conn.operations)
+	})
+}
+
+func (s *TreeTestSuite) TestMoveEphemeral() {
+	s.With(func(client CuratorFramework, conn *mockConn, stat *zk.Stat) {
+		conn.On("Get", "/src").Return([]byte("root"), &zk.Stat{EphemeralOwner: 1}, nil).Once()
+		conn.On("Children", "/src").Return([]string{}, stat, nil).Once()
+		conn.On("GetACL", "/src").Return(OPEN_ACL_UNSAFE, stat, nil).Once()
+
+		err := MoveTree(client, "/src", client, "/dst", nil)
+
+		s.True(errors.Is(err, ErrEphemeralInTree))
+	})
+}
+
+func (s *TreeTestSuite) TestMoveContainers() {
+	s.With(func(client CuratorFramework, conn *mockConn, stat *zk.Stat) {
+		conn.On("Get", "/src").Return([]byte("root"), &zk.Stat{Version: 1, EphemeralOwner: math.MinInt64}, nil).Once()
+		conn.On("GetACL", "/src").Return(READ_ACL_UNSAFE, stat, nil).Once()
+		conn.On("Children", "/src").Return([]string{"a"}, stat, nil).Once()
+		conn.On("Get", "/src/a").Return([]byte("a"), &zk.Stat{Version: 2, EphemeralOwner: -1<<56 | 1000}, nil).Once()
+		conn.On("GetACL", "/src/a").Return(OPEN_ACL_UNSAFE, stat, nil).Once()
+		conn.On("Children", "/src/a").Return([]string{}, stat, nil).Once()
+
+		// the container is created as a persistent node if the server doesn't support containers
+		conn.On("Multi", mock.Anything).Return(nil, zk.ErrBadArguments).Once()
+		conn.On("Multi", mock.Anything).Return([]zk.MultiResponse{{String: "/dst"}, {String: "/dst/a"}, {}, {}}, nil).Once()
+
+		s.NoError(MoveTree(client, "/src", client, "/dst", nil))
+		s.Len(conn.operations, 8)
+		s.Equal(int32(CONTAINER), conn.operations[0].(*zk.CreateRequest).Flags)
+		s.Equal(int32(PERSISTENT), conn.operations[1].(*zk.CreateRequest).Flags, "the TTL node is created as a persistent node")
+		s.Equal(int32(PERSISTENT), conn.operations[4].(*zk.CreateRequest).Flags)
+		s.Equal(int32(PERSISTENT), conn.operations[5].(*zk.CreateRequest).Flags)
+	})
+}
+
+func (s *TreeTestSuite) TestMoveChunked() {
+	s.WithPrepare(func(builder *CuratorFrameworkBuilder) {
+		builder.EnableChunking(4)
+	}, func(client CuratorFramework, conn *mockConn, stat *zk.Stat) {
+		manifest, _ := newChunkManifest([]byte("0123456789"), 4)
+		chunks := []string{CHUNK_PREFIX + manifest.Generation + "-0", CHUNK_PREFIX + manifest.Generation + "-1", CHUNK_PREFIX + manifest.Generation + "-2"}
+
+		conn.On("Get", "/src").Return([]byte("root"), &zk.Stat{Version: 1, NumChildren: 1}, nil).Once()
+		conn.On("GetACL", "/src").Return(READ_ACL_UNSAFE, stat, nil).Once()
+		conn.On("Children", "/src").Return([]string{"a"}, stat, nil).Once()
+		conn.On("Get", "/src/a").Return(manifest.encode(), &zk.Stat{Version: 2, NumChildren: 3}, nil).Once()
+		conn.On("Get", "/src/a/"+chunks[0]).Return([]byte("0123"), stat, nil).Once()
+		conn.On("Get", "/src/a/"+chunks[1]).Return([]byte("4567"), stat, nil).Once()
+		conn.On("Get", "/src/a/"+chunks[2]).Return([]byte("89"), stat, nil).Once()
+		conn.On("GetACL", "/src/a").Return(OPEN_ACL_UNSAFE, stat, nil).Once()
+		conn.On("Children", "/src/a").Return(chunks, stat, nil).Once()
+
+		// the chunked node is created and deleted outside of the transactions
+		conn.On("Multi", mock.Anything).Return([]zk.MultiResponse{{String: "/dst"}}, nil).Once()
+		conn.On("Multi", mock.Anything).Return([]zk.MultiResponse{{String: "/dst/a"}, {}, {}, {}}, nil).Once()
+		conn.On("Delete", "/src/a", int32(2)).Return(zk.ErrNotEmpty).Once()
+		conn.On("Get", "/src/a").Return(manifest.encode(), &zk.Stat{Version: 2, NumChildren: 3}, nil).Once()
+		conn.On("Children", "/src/a").Return(chunks, stat, nil).Once()
+		conn.On("Multi", mock.Anything).Return([]zk.MultiResponse{{}, {}, {}, {}}, nil).Once()
+		conn.On("Multi", mock.Anything).Return([]zk.MultiResponse{{}}, nil).Once()
+
+		s.NoError(MoveTree(client, "/src", client, "/dst", nil))
+		s.Len(conn.operations, 10)
+		s.Equal(&zk.CreateRequest{Path: "/dst", Data: []byte("root"), Acl: READ_ACL_UNSAFE, Flags: int32(PERSISTENT)}, conn.operations[0])
+		s.Equal("/dst/a", conn.operations[1].(*zk.CreateRequest).Path)
+		s.Equal(OPEN_ACL_UNSAFE, conn.operations[2].(*zk.CreateRequest).Acl)
+		s.Equal(&zk.DeleteRequest{Path: "/src/a", Version: 2}, conn.operations[8])
+		s.Equal(&zk.DeleteRequest{Path: "/src", Version: 1}, conn.operations[9])
+	})
+}
+
+func (s *TreeTestSuite) TestCopyChunkedToNamespace() {
+	s.WithPrepare(func(builder *CuratorFrameworkBuilder) {
+		builder.EnableChunking(4)
+	}, func(client CuratorFramework, conn *mockConn, stat *zk.Stat) {
+		conn.On("Get", "/src").Return([]byte("0123456789"), &zk.Stat{Version: 1}, nil).Once()
+		conn.On("GetACL", "/src").Return(OPEN_ACL_UNSAFE, stat, nil).Once()
+		conn.On("Children", "/src").Return([]string{}, stat, nil).Once()
+		conn.On("Exists", "/app").Return(true, stat, nil)
+
+		// the large data is chunked by the namespaced client outside of the transactions
+		conn.On("Multi", mock.Anything).Return([]zk.MultiResponse{{String: "/app/dst"}, {}, {}, {}}, nil).Once()
+
+		s.NoError(CopyTree(client, "/src", client.UsingNamespace("app"), "/dst", nil))
+		s.Len(conn.operations, 4)
+		s.Equal("/app/dst", conn.operations[0].(*zk.CreateRequest).Path)
+
+		manifest, ok := parseChunkManifest(conn.operations[0].(*zk.CreateRequest).Data)
+
+		s.True(ok)
+		s.Equal(3, manifest.Chunks)
+	})
+}
+
+func (s *TreeTestSuite) TestOverlaps() {
+	s.With(func(client CuratorFramework) {
+		s.Error(CopyTree(client, "/src", client, "/src/dst", nil))
+		s.Error(MoveTree(client, "/src/a", client, "/src", nil))
+		s.Error(CopyTree(client, "/", client, "/dst", nil))
+	})
+}
@@ -0,0 +1,191 @@
+package curator
+
+import (
+	"context"
+	"errors"
+	"net"
+	"sync"
+
+	"github.com/yxdrlitao/go-zookeeper/zk"
+)
+
+// The client was closed while waiting for the condition
+var ErrWaitClosed = errors.New("the client is closed while waiting")
+
+// Wait until the node exists, return the stat of the node
+func WaitForExists(ctx context.Context, client CuratorFramework, path string) (*zk.Stat, error) {
+	var stat *zk.Stat
+
+	err := waitFor(ctx, client, func(watcher Watcher) (bool, error) {
+		var err error
+
+		stat, err = client.CheckExists().UsingWatcher(watcher).ForPath(path)
+
+		return stat != nil, err
+	})
+
+	return stat, err
+}
+
+// Wait until the node doesn't exist
+func WaitForDeletion(ctx context.Context, client CuratorFramework, path string) error {
+	return waitFor(ctx, client, func(watcher Watcher) (bool, error) {
+		stat, err := client.CheckExists().UsingWatcher(watcher).ForPath(path)
+
+		return stat == nil, err
+	})
+}
+
+// Wait until the node exists and the number of its children is accepted, return the stat of the node
+func WaitForChildrenCount(ctx context.Context, client CuratorFramework, path string, accept func(count int) bool) (*zk.Stat, error) {
+	var stat zk.Stat
+
+	err := waitFor(ctx, client, func(watcher Watcher) (bool, error) {
+		for {
+			children, err := client.GetChildren().StoringStatIn(&stat).UsingWatcher(watcher).ForPath(path)
+
+			if err == zk.ErrNoNode {
+				if exists, err := watchExistence(client, path, watcher); err != nil || !exists {
+					return false, err
+				}
+
+				continue // created in the meantime
+			} else if err != nil {
+				return false, err
+			}
+
+			return accept(len(children)), nil
+		}
+	})
+
+	if err != nil {
+		return nil, err
+	}
+
+	return &stat, nil
+}
+
+// Wait until the node exists and its data is accepted, return the data and stat of the node
+func WaitForData(ctx context.Context, client CuratorFramework, path string, accept func(data []byte, stat *zk.Stat) bool) ([]byte, *zk.Stat, error) {
+	var data []byte
+	var stat zk.Stat
+
+	err := waitFor(ctx, client, func(watcher Watcher) (bool, error) {
+		for {
+			var err error
+
+			data, err = client.GetData().StoringStatIn(&stat).UsingWatcher(watcher).ForPath(path)
+
+			if err == zk.ErrNoNode {
+				if exists, err := watchExistence(client, path, watcher); err != nil || !exists {
+					return false, err
+				}
+
+				continue // created in the meantime
+			} else if err != nil {
+				return false, err
+			}
+
+			return accept(data, &stat), nil
+		}
+	})
+
+	if err != nil {
+		return nil, nil, err
+	}
+
+	return data, &stat, nil
+}
+
+// Watch the creation of the node, since no watch is left by reading a missing node
+func watchExistence(client CuratorFramework, path string, watcher Watcher) (bool, error) {
+	stat, err := client.CheckExists().UsingWatcher(watcher).ForPath(path)
+
+	return stat != nil, err
+}
+
+// Check the condition and set the watches, then check again after the watches are triggered or the client reconnects,
+// until the condition is met, the context is done or the client is closed.
+// The connection errors are waited out, and the condition is checked again on reconnection only if no watch is left,
+// since the watches survive the reconnection of the session.
+func waitFor(ctx context.Context, client CuratorFramework, check func(watcher Watcher) (bool, error)) error {
+	changed := make(chan struct{}, 1)
+
+	notify := func() {
+		select {
+		case changed <- struct{}{}:
+		default:
+		}
+	}
+
+	var lock sync.Mutex
+	var watching bool
+
+	watcher := NewWatcher(func(event *zk.Event) {
+		lock.Lock()
+		watching = false
+		lock.Unlock()
+
+		notify()
+	})
+	stateListener := NewConnectionStateListener(func(client CuratorFramework, newState ConnectionState) {
+		lock.Lock()
+		rewatch := !watching
+		lock.Unlock()
+
+		if newState.Connected() && rewatch {
+			notify()
+		}
+	})
+	closeListener := NewCuratorListener(func(client CuratorFramework, event CuratorEvent) error {
+		if event.Type() == CLOSING {
+			notify()
+		}
+
+		return nil
+	})
+
+	client.ConnectionStateListenable().AddListener(stateListener)
+	client.CuratorListenable().AddListener(closeListener)
+
+	defer client.ConnectionStateListenable().RemoveListener(stateListener)
+	defer client.CuratorListenable().RemoveListener(closeListener)
+
+	for {
+		if err := ctx.Err(); err != nil {
+			return err
+		} else if client.State() != STARTED {
+			return ErrWaitClosed
+		}
+
+		done, err := check(watcher)
+
+		if err != nil && !isConnectionError(err) {
+			return err
+		} else if done && err == nil {
+			return nil
+		}
+
+		// the watch is left unless the request failed
+		lock.Lock()
+		watching = err == nil
+		lock.Unlock()
+
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-changed:
+		}
+	}
+}
+
+func isConnectionError(err error) bool {
+	switch err {
+	case zk.ErrConnectionClosed, zk.ErrSessionExpired, zk.ErrSessionMoved, zk.ErrNoServer, ErrConnectionLoss, ErrConnectionTimeOut:
+		return true
+	}
+
+	_, ok := err.(net.Error)
+
+	return ok
+}
@@ -0,0 +1,111 @@
+package curator
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/suite"
+	"github.com/yxdrlitao/go-zookeeper/zk"
+)
+
+type WaitForTestSuite struct {
+	mockContainerTestSuite
+}
+
+func TestWaitFor(t *testing.T) {
+	suite.Run(t, new(WaitForTestSuite))
+}
+
+// Make a watch channel which has been triggered
+func (s *WaitForTestSuite) triggered(eventType zk.EventType, path string) chan zk.Event {
+	events := make(chan zk.Event, 1)
+
+	events <- zk.Event{Type: eventType, State: zk.StateHasSession, Path: path}
+
+	return events
+}
+
+func (s *WaitForTestSuite) TestWaitForExists() {
+	s.With(func(client CuratorFramework, conn *mockConn, stat *zk.Stat) {
+		conn.On("ExistsW", "/node").Return(false, nil, s.triggered(zk.EventNodeCreated, "/node"), nil).Once()
+		conn.On("ExistsW", "/node").Return(true, stat, make(chan zk.Event), nil).Once()
+
+		stat2, err := WaitForExists(context.Background(), client, "/node")
+
+		s.NoError(err)
+		s.Equal(stat, stat2)
+	})
+}
+
+func (s *WaitForTestSuite) TestWaitForDeletionTimeout() {
+	s.With(func(client CuratorFramework, conn *mockConn, stat *zk.Stat) {
+		conn.On("ExistsW", "/node").Return(true, stat, make(chan zk.Event), nil).Once()
+
+		ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+
+		defer cancel()
+
+		s.Equal(context.DeadlineExceeded, WaitForDeletion(ctx, client, "/node"))
+	})
+}
+
+func (s *WaitForTestSuite) TestWaitForData() {
+	s.With(func(client CuratorFramework, conn *mockConn, stat *zk.Stat) {
+		conn.On("GetW", "/node").Return(nil, nil, nil, zk.ErrNoNode).Once()
+		conn.On("ExistsW", "/node").Return(false, nil, s.triggered(zk.EventNodeCreated, "/node"), nil).Once()
+		conn.On("GetW", "/node").Return([]byte("old"), stat, s.triggered(zk.EventNodeDataChanged, "/node"), nil).Once()
+		conn.On("GetW", "/node").Return([]byte("new"), stat, make(chan zk.Event), nil).Once()
+
+		data, stat2, err := WaitForData(context.Background(), client, "/node", func(data []byte, stat *zk.Stat) bool {
+			return string(data) == "new"
+		})
+
+		s.NoError(err)
+		s.Equal([]byte("new"), data)
+		s.Equal(stat, stat2)
+	})
+}
+
+func (s *WaitForTestSuite) TestWaitForChildrenCount() {
+	s.With(func(client CuratorFramework, conn *mockConn, stat *zk.Stat) {
+		conn.On("ChildrenW", "/node").Return([]string{"a"}, stat, s.triggered(zk.EventNodeChildrenChanged, "/node"), nil).Once()
+		conn.On("ChildrenW", "/node").Return([]string{"a", "b"}, stat, make(chan zk.Event), nil).Once()
+
+		stat2, err := WaitForChildrenCount(context.Background(), client, "/node", func(count int) bool { return count >= 2 })
+
+		s.NoError(err)
+		s.Equal(stat, stat2)
+	})
+}
+
+func (s *WaitForTestSuite) TestWaitClosed() {
+	s.With(func(client CuratorFramework, conn *mockConn, stat *zk.Stat) {
+		app := client.UsingNamespace("app")
+
+		// the watch survives the reconnection, so the node isn't checked again
+		conn.On("Exists", "/app").Return(true, stat, nil).Once()
+		conn.On("ExistsW", "/app/node").Return(true, stat, make(chan zk.Event), nil).Once()
+
+		done := make(chan error)
+
+		go func() {
+			done <- WaitForDeletion(context.Background(), app, "/node")
+		}()
+
+		time.Sleep(10 * time.Millisecond)
+
+		client.(*curatorFramework).stateManager.AddStateChange(RECONNECTED)
+
+		time.Sleep(10 * time.Millisecond)
+
+		app.Close()
+
+		select {
+		case err := <-done:
+			s.Equal(ErrWaitClosed, err)
+		case <-time.After(time.Second):
+			s.Fail("the wait isn't woken up by closing the client")
+		}
+	})
+}
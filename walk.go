@@ -0,0 +1,185 @@
+package curator
+
+import (
+	"errors"
+	"sort"
+	"sync"
+
+	"github.com/yxdrlitao/go-zookeeper/zk"
+)
+
+type WalkOrder int
+
+const (
+	PRE_ORDER  WalkOrder = iota // visit a node before its children
+	POST_ORDER                  // visit a node after its children
+)
+
+const ONLY_ROOT = -1 // the WalkOptions.MaxDepth to visit only the root
+
+// Return it from the WalkFunc in PRE_ORDER to skip the children of the node
+var ErrSkipChildren = errors.New("skip the children of the node")
+
+// A node visited by Walk()
+type WalkNode struct {
+	Path  string   // the path relative to the namespace of the client
+	Depth int      // the depth below the root of the walk, the root is 0
+	Data  []byte   // the data of the node if WalkOptions.WithData is set
+	Stat  *zk.Stat // the stat of the node if WalkOptions.WithData is set
+}
+
+// Called for each node of the walk, any error other than ErrSkipChildren stops the walk
+type WalkFunc func(node *WalkNode) error
+
+// The options of Walk(), the zero value walks the whole subtree in PRE_ORDER one node after another
+type WalkOptions struct {
+	Order         WalkOrder              // visit the nodes in PRE_ORDER or POST_ORDER
+	MaxDepth      int                    // the max depth of the nodes to visit, 0 means no limit, ONLY_ROOT visits only the root
+	Include       func(path string) bool // visit only the accepted nodes, their children are still walked
+	Exclude       func(path string) bool // skip the rejected nodes with their subtrees
+	Concurrency   int                    // the max number of the nodes read concurrently, 0 or 1 reads them one by one
+	WithData      bool                   // read the data and stat of the nodes as GetData()
+	IncludeSystem bool                   // walk /zookeeper, which is skipped by default
+}
+
+type walker struct {
+	client  CuratorFramework
+	visitor WalkFunc
+	options WalkOptions
+	slots   chan struct{}
+	lock    sync.Mutex
+	err     error
+}
+
+// Walk the subtree of the given root, the nodes deleted during the walk are skipped,
+// except the ones at the max depth without data, which are visited without listing their children.
+//
+// The children are walked in the lexicographic order. With WalkOptions.Concurrency > 1,
+// the subtrees of the siblings are walked concurrently, and the visitor may be called concurrently.
+func Walk(client CuratorFramework, root string, visitor WalkFunc, options *WalkOptions) error {
+	w := &walker{client: client, visitor: visitor}
+
+	if options != nil {
+		w.options = *options
+	}
+
+	if w.options.Concurrency > 1 {
+		w.slots = make(chan struct{}, w.options.Concurrency-1)
+	}
+
+	w.walk(root, 0)
+
+	return w.err
+}
+
+func (w *walker) failed() bool {
+	w.lock.Lock()
+	defer w.lock.Unlock()
+
+	return w.err != nil
+}
+
+func (w *walker) fail(err error) {
+	w.lock.Lock()
+	defer w.lock.Unlock()
+
+	if w.err == nil {
+		w.err = err
+	}
+}
+
+func (w *walker) walk(nodePath string, depth int) {
+	if w.failed() || (w.options.Exclude != nil && w.options.Exclude(nodePath)) {
+		return
+	}
+
+	node := &WalkNode{Path: nodePath, Depth: depth}
+
+	if w.options.WithData {
+		var stat zk.Stat
+
+		data, err := w.client.GetData().StoringStatIn(&stat).ForPath(nodePath)
+
+		if err == zk.ErrNoNode {
+			return
+		} else if err != nil {
+			w.fail(err)
+
+			return
+		}
+
+		node.Data, node.Stat = data, &stat
+	}
+
+	var children []string
+
+	// list the children before visiting the node, so that the deleted node is skipped in both orders
+	if w.options.MaxDepth == 0 || depth < w.options.MaxDepth {
+		var err error
+
+		if children, err = w.client.GetChildren().ForPath(nodePath); err == zk.ErrNoNode {
+			return
+		} else if err != nil {
+			w.fail(err)
+
+			return
+		}
+	}
+
+	include := w.options.Include == nil || w.options.Include(nodePath)
+
+	if include && w.options.Order == PRE_ORDER {
+		if err := w.visitor(node); err == ErrSkipChildren {
+			return
+		} else if err != nil {
+			w.fail(err)
+
+			return
+		}
+	}
+
+	if len(children) > 0 {
+		sort.Strings(children)
+
+		var wg sync.WaitGroup
+
+		for _, child := range children {
+			childPath := JoinPath(nodePath, child)
+
+			if !w.options.IncludeSystem && w.isSystemNode(childPath) {
+				continue
+			}
+
+			select {
+			case w.slots <- struct{}{}:
+				wg.Add(1)
+
+				go func() {
+					defer func() {
+						<-w.slots
+
+						wg.Done()
+					}()
+
+					w.walk(childPath, depth+1)
+				}()
+			default:
+				w.walk(childPath, depth+1)
+			}
+		}
+
+		wg.Wait()
+	}
+
+	if include && w.options.Order == POST_ORDER && !w.failed() {
+		if err := w.visitor(node); err != nil && err != ErrSkipChildren {
+			w.fail(err)
+		}
+	}
+}
+
+func (w *walker) isSystemNode(nodePath string) bool {
+	fullPath, _ := FixForNamespace(w.client.Namespace(), nodePath, false)
+
+	return fullPath == ZOOKEEPER_SYSTEM_NODE
+}
@@ -0,0 +1,153 @@
+package curator
+
+import (
+	"errors"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/suite"
+	"github.com/yxdrlitao/go-zookeeper/zk"
+)
+
+type WalkTestSuite struct {
+	mockContainerTestSuite
+}
+
+func TestWalk(t *testing.T) {
+	suite.Run(t, new(WalkTestSuite))
+}
+
+func (s *WalkTestSuite) walk(client CuratorFramework, root string, options *WalkOptions) ([]string, error) {
+	var lock sync.Mutex
+	var paths []string
+
+	err := Walk(client, root, func(node *WalkNode) error {
+		lock.Lock()
+		defer lock.Unlock()
+
+		paths = append(paths, node.Path)
+
+		return nil
+	}, options)
+
+	return paths, err
+}
+
+func (s *WalkTestSuite) TestOrder() {
+	s.With(func(client CuratorFramework, conn *mockConn, stat *zk.Stat) {
+		conn.On("Children", "/").Return([]string{"zookeeper", "b", "a"}, stat, nil).Twice()
+		conn.On("Children", "/a").Return([]string{"c"}, stat, nil).Twice()
+		conn.On("Children", "/a/c").Return([]string{}, stat, nil).Twice()
+		conn.On("Children", "/b").Return(nil, nil, zk.ErrNoNode).Twice()
+
+		paths, err := s.walk(client, "/", nil)
+
+		// the deleted node is skipped in both orders
+		s.NoError(err)
+		s.Equal([]string{"/", "/a", "/a/c"}, paths)
+
+		paths, err = s.walk(client, "/", &WalkOptions{Order: POST_ORDER})
+
+		s.NoError(err)
+		s.Equal([]string{"/a/c", "/a", "/"}, paths)
+	})
+}
+
+func (s *WalkTestSuite) TestFilters() {
+	s.With(func(client CuratorFramework, conn *mockConn, stat *zk.Stat) {
+		conn.On("Children", "/root").Return([]string{"a", "b", "skipped"}, stat, nil).Once()
+
+		paths, err := s.walk(client, "/root", &WalkOptions{
+			MaxDepth: 1,
+			Include:  func(path string) bool { return path != "/root/a" },
+			Exclude:  func(path string) bool { return strings.HasSuffix(path, "/skipped") },
+		})
+
+		s.NoError(err)
+		s.Equal([]string{"/root", "/root/b"}, paths)
+
+		// visit only the root
+		paths, err = s.walk(client, "/root", &WalkOptions{MaxDepth: ONLY_ROOT})
+
+		s.NoError(err)
+		s.Equal([]string{"/root"}, paths)
+	})
+}
+
+func (s *WalkTestSuite) TestWithData() {
+	s.WithNamespace("parent", func(client CuratorFramework, conn *mockConn, data []byte, stat *zk.Stat) {
+		conn.On("Exists", "/parent").Return(true, nil, nil).Once()
+		conn.On("Get", "/parent/node").Return(data, stat, nil).Once()
+		conn.On("Children", "/parent/node").Return([]string{"gone"}, stat, nil).Once()
+		conn.On("Get", "/parent/node/gone").Return(nil, nil, zk.ErrNoNode).Once()
+
+		var nodes []*WalkNode
+
+		err := Walk(client, "/node", func(node *WalkNode) error {
+			nodes = append(nodes, node)
+
+			return nil
+		}, &WalkOptions{WithData: true})
+
+		s.NoError(err)
+		s.Equal([]*WalkNode{{Path: "/node", Depth: 0, Data: data, Stat: stat}}, nodes)
+	})
+}
+
+func (s *WalkTestSuite) TestSkipChildren() {
+	s.With(func(client CuratorFramework, conn *mockConn, stat *zk.Stat) {
+		conn.On("Children", "/root").Return([]string{"a", "b"}, stat, nil).Once()
+		conn.On("Children", "/root/a").Return([]string{"c"}, stat, nil).Once()
+		conn.On("Children", "/root/b").Return([]string{}, stat, nil).Once()
+
+		var paths []string
+
+		err := Walk(client, "/root", func(node *WalkNode) error {
+			paths = append(paths, node.Path)
+
+			if node.Path == "/root/a" {
+				return ErrSkipChildren
+			}
+
+			return nil
+		}, nil)
+
+		s.NoError(err)
+		s.Equal([]string{"/root", "/root/a", "/root/b"}, paths)
+	})
+}
+
+func (s *WalkTestSuite) TestConcurrency() {
+	s.With(func(client CuratorFramework, conn *mockConn, stat *zk.Stat) {
+		conn.On("Children", "/root").Return([]string{"a", "b", "c", "d"}, stat, nil).Once()
+
+		for _, child := range []string{"a", "b", "c", "d"} {
+			conn.On("Children", "/root/"+child).Return([]string{}, stat, nil).Once()
+		}
+
+		paths, err := s.walk(client, "/root", &WalkOptions{Concurrency: 3})
+
+		s.NoError(err)
+		s.ElementsMatch([]string{"/root", "/root/a", "/root/b", "/root/c", "/root/d"}, paths)
+	})
+}
+
+func (s *WalkTestSuite) TestError() {
+	s.With(func(client CuratorFramework, conn *mockConn, stat *zk.Stat) {
+		conn.On("Children", "/root").Return([]string{"a", "b"}, stat, nil).Once()
+		conn.On("Children", "/root/a").Return([]string{}, stat, nil).Once()
+
+		failure := errors.New("failure")
+
+		err := Walk(client, "/root", func(node *WalkNode) error {
+			if node.Path == "/root/a" {
+				return failure
+			}
+
+			return nil
+		}, nil)
+
+		s.Equal(failure, err)
+	})
+}
@@ -46,9 +46,9 @@ func (w *Watchers) Remove(watcher Watcher) Watcher {
 	defer w.lock.Unlock()
 
 	for i, v := range w.watchers {
-		if v == watcher {
+		if sameWatcher(v, watcher) {
 			w.watchers = append(w.watchers[:i], w.watchers[i+1:]...)
-			return watcher
+			return v
 		}
 	}
 	return nil
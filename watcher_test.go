@@ -66,3 +66,33 @@ func TestWatchers(t *testing.T) {
 	assert.Equal(t, 1, len(events[2]))
 	assert.Equal(t, &evt, events[0][1])
 }
+
+func TestNamespaceWatcher(t *testing.T) {
+	var events []*zk.Event
+
+	watcher := NewWatcher(func(event *zk.Event) {
+		events = append(events, event)
+	})
+
+	n := &namespaceImpl{namespace: "app"}
+	w := n.newNamespaceWatcher(watcher)
+
+	assert.Equal(t, w, n.newNamespaceWatcher(w), "wrapped only once")
+	assert.Equal(t, watcher, (&namespaceImpl{}).newNamespaceWatcher(watcher), "no namespace")
+
+	for _, path := range []string{"/app/node", "/app", "/application/node", "/other", ""} {
+		w.process(&zk.Event{Type: zk.EventNodeDataChanged, Path: path})
+	}
+
+	if assert.Len(t, events, 3) {
+		assert.Equal(t, "/node", events[0].Path)
+		assert.Equal(t, "/", events[1].Path)
+		assert.Equal(t, "", events[2].Path, "session event")
+	}
+
+	// remove the wrapped watcher with the original one
+	watchers := NewWatchers(w)
+
+	assert.Equal(t, w, watchers.Remove(watcher))
+	assert.Equal(t, 0, watchers.Len())
+}